@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// account is an entry of the --accounts-file
+type account struct {
+	id   string
+	name string
+}
+
+// label returns the name shown in output for the account
+func (a account) label() string {
+	if a.name != "" {
+		return a.name
+	}
+	return a.id
+}
+
+// readAccountsFile reads an accounts file. Each non-empty line holds a 12-digit
+// account ID, optionally followed by a name; text after "#" is ignored.
+//
+//	123456789012  prod-eu
+//	210987654321  # staging
+func readAccountsFile(path string) ([]account, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open accounts file: %w", err)
+	}
+	defer f.Close()
+
+	var accounts []account
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !accountIDPattern.MatchString(fields[0]) {
+			return nil, fmt.Errorf("%s:%d: invalid account ID %q", path, lineNumber, fields[0])
+		}
+
+		acct := account{id: fields[0]}
+		if len(fields) > 1 {
+			acct.name = strings.Join(fields[1:], " ")
+		}
+		accounts = append(accounts, acct)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("accounts file %s does not list any accounts", path)
+	}
+	return accounts, nil
+}
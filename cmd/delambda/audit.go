@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/audit"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/report"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// auditFlags holds the --audit-log flag and the audit log shared by every target of a command
+type auditFlags struct {
+	path string
+
+	once    sync.Once
+	journal *repository.AuditRepository
+}
+
+// newAuditFlags registers --audit-log on fs
+func newAuditFlags(fs *flag.FlagSet, settings config.Settings) *auditFlags {
+	a := &auditFlags{}
+	fs.StringVar(&a.path, "audit-log", settings.AuditLog, "Audit log file (default ~/.local/state/delambda/audit.jsonl, also DELAMBDA_AUDIT_LOG)")
+	return a
+}
+
+// repository returns the audit log, resolving the default path on first use
+func (a *auditFlags) repository() (*repository.AuditRepository, error) {
+	var err error
+	a.once.Do(func() {
+		path := a.path
+		if path == "" {
+			path, err = config.DefaultAuditLogPath()
+			if err != nil {
+				return
+			}
+		}
+		a.journal = repository.NewAuditRepository(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.journal == nil {
+		return nil, fmt.Errorf("audit log is not available")
+	}
+	return a.journal, nil
+}
+
+// repositories returns function and log group repositories for awsClient that
+// record every change in the audit log and, with a recorder, every lookup and
+// change in the run report. A dry run changes nothing, so it writes no audit
+// records and does not look up the caller.
+func (a *auditFlags) repositories(ctx context.Context, awsClient *client.AWSClient, dryRun bool, recorder *report.Recorder) (function.Repository, loggroup.Repository, error) {
+	functionRepo := newFunctionRepository(awsClient)
+	logGroupRepo := repository.NewLogGroupRepository(awsClient.Logs)
+	if dryRun {
+		if recorder == nil {
+			return functionRepo, logGroupRepo, nil
+		}
+		return repository.NewAuditedFunctionRepository(functionRepo, nil, audit.Actor{}, recorder),
+			repository.NewAuditedLogGroupRepository(logGroupRepo, nil, audit.Actor{}, recorder),
+			nil
+	}
+
+	journal, err := a.repository()
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := awsClient.CallerIdentity(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to identify the caller for the audit log: %w", err)
+	}
+	actor := audit.Actor{
+		Caller:  identity.ARN,
+		UserID:  identity.UserID,
+		Account: identity.Account,
+		Region:  awsClient.Config.Region,
+	}
+
+	return repository.NewAuditedFunctionRepository(functionRepo, journal, actor, recorder),
+		repository.NewAuditedLogGroupRepository(logGroupRepo, journal, actor, recorder),
+		nil
+}
+
+// parseTimeFlag parses an RFC 3339 timestamp, a YYYY-MM-DD date or a duration
+// before now such as 90m, 12h or 7d. A date is the start of the day, or the end
+// of it when endOfDay is set.
+func parseTimeFlag(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339, YYYY-MM-DD or a duration such as 12h or 7d", value)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "empty", value: "", want: time.Time{}},
+		{name: "RFC 3339", value: "2026-03-01T08:00:00Z", want: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{name: "date", value: "2026-03-01", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{name: "date includes the whole day", value: "2026-03-01", endOfDay: true, want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)},
+		{name: "days", value: "7d", want: now.AddDate(0, 0, -7)},
+		{name: "duration", value: "90m", want: now.Add(-90 * time.Minute)},
+		{name: "invalid", value: "last week", wantErr: true},
+		{name: "negative duration", value: "-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeFlag(tt.value, now, tt.endOfDay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimeFlag() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// replayPollInterval is the poll interval while replaying a recording
+const replayPollInterval = 10 * time.Millisecond
+
+// awsFlags holds the flags that select the AWS credentials, accounts and regions a command runs against
+type awsFlags struct {
+	region       string
+	profile      string
+	regions      string
+	allRegions   bool
+	roleARN      string
+	roleName     string
+	externalID   string
+	sessionName  string
+	mfaSerial    string
+	accountsFile string
+	endpointURL  string
+	context      string
+	concurrency  int
+	maxRetries   int
+	retryMode    string
+	maxTPS       float64
+	record       string
+	replay       string
+
+	// structuredOutput sends progress and summaries to stderr without target
+	// labels, so stdout only carries the command's machine readable output
+	structuredOutput bool
+
+	// mfaSession authenticates with the MFA code once and assumes the role of
+	// every account from it, nil without --mfa-serial
+	mfaSession *client.MFASession
+
+	// limiter is the client-side rate limit shared by every client, nil if disabled
+	limiter *client.RateLimiter
+
+	// recorder records the API calls of every client for --record, and
+	// replayer serves them for --replay
+	recorder *client.Recorder
+	replayer *client.Replayer
+
+	// finishers run once the command has run against every target, before it exits
+	finishers []func()
+}
+
+// newAWSFlags registers the AWS flags on fs, using the config settings as defaults
+func newAWSFlags(fs *flag.FlagSet, settings config.Settings) *awsFlags {
+	af := &awsFlags{}
+	fs.StringVar(&af.context, "context", "", "Named context from the config file bundling profile, region and role")
+	fs.StringVar(&af.region, "region", settings.Region, "AWS region")
+	fs.StringVar(&af.profile, "profile", settings.Profile, "AWS profile")
+	fs.StringVar(&af.regions, "regions", "", "Comma-separated list of AWS regions to run against concurrently")
+	fs.BoolVar(&af.allRegions, "all-regions", false, "Run against every region enabled for the account")
+	fs.StringVar(&af.roleARN, "role-arn", settings.RoleARN, "ARN of an IAM role to assume")
+	fs.StringVar(&af.roleName, "role-name", "", "Name of the IAM role to assume in each account of --accounts-file (defaults to the name in --role-arn)")
+	fs.StringVar(&af.externalID, "external-id", settings.ExternalID, "External ID to pass when assuming the role")
+	fs.StringVar(&af.sessionName, "role-session-name", "delambda", "Session name to use when assuming the role")
+	fs.StringVar(&af.mfaSerial, "mfa-serial", "", "Serial number or ARN of the MFA device required by the role")
+	fs.StringVar(&af.accountsFile, "accounts-file", "", "File listing account IDs to run against, assuming the same role in each")
+	fs.StringVar(&af.endpointURL, "endpoint-url", "", "Send all API calls to this endpoint, e.g. a LocalStack instance (overrides AWS_ENDPOINT_URL)")
+	fs.IntVar(&af.concurrency, "concurrency", settings.Concurrency, "Maximum number of accounts and regions processed at once (0 means all at once)")
+	fs.IntVar(&af.maxRetries, "max-retries", maxRetriesDefault(settings), "Maximum retries of an API call, including throttled calls, and of a change that conflicts with an update in progress")
+	fs.StringVar(&af.retryMode, "retry-mode", retryModeDefault(settings), "SDK retry mode: standard, or adaptive to slow down while throttled")
+	fs.Float64Var(&af.maxTPS, "max-tps", maxTPSDefault(settings), "Maximum API calls per second per service and region, shared by all workers (0 means unlimited)")
+	fs.StringVar(&af.record, "record", "", "Record the API calls and responses to this cassette file, with credentials scrubbed")
+	fs.StringVar(&af.replay, "replay", "", "Serve the API calls from a cassette file written by --record instead of calling AWS")
+	return af
+}
+
+// isFanOut checks if the command should run against more than one account or region
+func (af *awsFlags) isFanOut() bool {
+	return af.regions != "" || af.allRegions || af.accountsFile != ""
+}
+
+// newClient creates an AWS client for the given region, assuming roleARN if set
+func (af *awsFlags) newClient(ctx context.Context, region, roleARN string) (*client.AWSClient, error) {
+	opts := []client.Option{client.WithRetries(af.maxRetries, aws.RetryMode(af.retryMode))}
+	if af.limiter != nil {
+		opts = append(opts, client.WithRateLimiter(af.limiter))
+	}
+	if af.endpointURL != "" {
+		opts = append(opts, client.WithEndpointURL(af.endpointURL))
+	}
+	if af.recorder != nil {
+		opts = append(opts, client.WithRecorder(af.recorder))
+	}
+	if af.replayer != nil {
+		opts = append(opts, client.WithReplayer(af.replayer))
+	}
+	if roleARN != "" {
+		opts = append(opts, client.WithAssumeRole(client.AssumeRole{
+			RoleARN:     roleARN,
+			ExternalID:  af.externalID,
+			SessionName: af.sessionName,
+			MFASession:  af.mfaSession,
+		}))
+	}
+
+	awsClient, err := client.NewAWSClient(ctx, region, af.profile, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS client: %w", err)
+	}
+	return awsClient, nil
+}
+
+// target is a single account and region a command runs against
+type target struct {
+	label   string
+	region  string
+	roleARN string
+}
+
+// resolveTargets returns every account and region combination selected by the flags
+func (af *awsFlags) resolveTargets(ctx context.Context) ([]target, error) {
+	if af.regions != "" && af.allRegions {
+		return nil, fmt.Errorf("--regions and --all-regions cannot be combined")
+	}
+
+	roles := []target{{roleARN: af.roleARN}}
+	if af.accountsFile != "" {
+		accounts, err := readAccountsFile(af.accountsFile)
+		if err != nil {
+			return nil, err
+		}
+		roles, err = af.accountRoles(accounts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var targets []target
+	for _, role := range roles {
+		regions, err := af.resolveRegions(ctx, role.roleARN)
+		if err != nil {
+			if role.label != "" {
+				return nil, fmt.Errorf("%s: %w", role.label, err)
+			}
+			return nil, err
+		}
+
+		for _, region := range regions {
+			label := strings.TrimSpace(role.label + " " + region)
+			targets = append(targets, target{
+				label:   label,
+				region:  region,
+				roleARN: role.roleARN,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// accountRoles returns the role to assume in each account, labelled with the account
+func (af *awsFlags) accountRoles(accounts []account) ([]target, error) {
+	partition := "aws"
+	roleName := af.roleName
+	if af.roleARN != "" {
+		parsed, err := arn.Parse(af.roleARN)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --role-arn: %w", err)
+		}
+		partition = parsed.Partition
+		if roleName == "" {
+			roleName = strings.TrimPrefix(parsed.Resource, "role/")
+		}
+	}
+	if roleName == "" {
+		return nil, fmt.Errorf("--accounts-file requires --role-name or --role-arn")
+	}
+
+	roles := make([]target, 0, len(accounts))
+	for _, acct := range accounts {
+		roles = append(roles, target{
+			label:   acct.label(),
+			roleARN: fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, acct.id, roleName),
+		})
+	}
+	return roles, nil
+}
+
+// resolveRegions returns the regions selected by --region, --regions or --all-regions
+func (af *awsFlags) resolveRegions(ctx context.Context, roleARN string) ([]string, error) {
+	if af.allRegions {
+		// Use the default (or --region) client to discover the enabled regions
+		awsClient, err := af.newClient(ctx, af.region, roleARN)
+		if err != nil {
+			return nil, err
+		}
+		return awsClient.EnabledRegions(ctx)
+	}
+
+	if af.regions == "" {
+		return []string{af.region}, nil
+	}
+
+	var regions []string
+	for _, region := range strings.Split(af.regions, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("--regions must list at least one region")
+	}
+	return regions, nil
+}
+
+// targetAction is the work a command performs against a single account and region.
+// All output must be written to out so it can be labelled with the target.
+type targetAction func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error
+
+// run executes action against the selected account and region, or concurrently
+// against every selected account and region with labelled output followed by a
+// combined summary. Failures are reported with failureMessage and exit with a
+// non-zero status.
+func (af *awsFlags) run(ctx context.Context, failureMessage string, action targetAction) {
+	if af.concurrency < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --concurrency must not be negative")
+		os.Exit(1)
+	}
+	if af.maxRetries < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-retries must not be negative")
+		os.Exit(1)
+	}
+	if af.retryMode != config.RetryModeStandard && af.retryMode != config.RetryModeAdaptive {
+		fmt.Fprintf(os.Stderr, "Error: --retry-mode must be %s or %s\n", config.RetryModeStandard, config.RetryModeAdaptive)
+		os.Exit(1)
+	}
+	if af.maxTPS < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-tps must not be negative")
+		os.Exit(1)
+	}
+	if af.maxTPS > 0 {
+		af.limiter = client.NewRateLimiter(af.maxTPS)
+	}
+	if af.mfaSerial != "" {
+		af.mfaSession = client.NewMFASession(af.mfaSerial, stscreds.StdinTokenProvider)
+	}
+	if af.record != "" && af.replay != "" {
+		fmt.Fprintln(os.Stderr, "Error: --record and --replay cannot be combined")
+		os.Exit(1)
+	}
+	if af.record != "" {
+		af.recorder = client.NewRecorder(af.record)
+	}
+	if af.replay != "" {
+		replayer, err := client.LoadReplayer(af.replay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load recording: %v\n", err)
+			os.Exit(1)
+		}
+		af.replayer = replayer
+	}
+
+	// Progress and summaries go to stderr when stdout carries structured output
+	var progress io.Writer = os.Stdout
+	if af.structuredOutput {
+		progress = os.Stderr
+	}
+
+	if !af.isFanOut() {
+		awsClient, err := af.newClient(ctx, af.region, af.roleARN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create AWS client: %v\n", err)
+			os.Exit(1)
+		}
+		err = action(ctx, awsClient, os.Stdout)
+		af.printThrottled(progress)
+		af.finish()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", failureMessage, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	targets, err := af.resolveTargets(ctx)
+	if err != nil {
+		af.finish()
+		fmt.Fprintf(os.Stderr, "Failed to resolve accounts and regions: %v\n", err)
+		os.Exit(1)
+	}
+
+	labels := make([]string, 0, len(targets))
+	for _, t := range targets {
+		labels = append(labels, t.label)
+	}
+	fmt.Fprintf(progress, "Running against %d target(s): %s\n\n", len(targets), strings.Join(labels, ", "))
+
+	limit := af.concurrency
+	if limit == 0 {
+		limit = len(targets)
+	}
+	sem := make(chan struct{}, limit)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			prefix := fmt.Sprintf("[%s] ", t.label)
+			if af.structuredOutput {
+				prefix = ""
+			}
+			out := newPrefixWriter(os.Stdout, &mu, prefix)
+			defer out.Flush()
+
+			awsClient, err := af.newClient(ctx, t.region, t.roleARN)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if err := action(ctx, awsClient, out); err != nil {
+				errs[i] = err
+				if !af.structuredOutput {
+					fmt.Fprintf(out, "%s: %v\n", failureMessage, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	af.finish()
+
+	failureCount := 0
+	fmt.Fprintf(progress, "\n=== Combined Summary ===\n")
+	for i, t := range targets {
+		if errs[i] != nil {
+			failureCount++
+			fmt.Fprintf(progress, "  ❌ %s: %v\n", t.label, errs[i])
+		} else {
+			fmt.Fprintf(progress, "  ✓ %s\n", t.label)
+		}
+	}
+	fmt.Fprintf(progress, "Total targets: %d, succeeded: %d, failed: %d\n", len(targets), len(targets)-failureCount, failureCount)
+	af.printThrottled(progress)
+
+	if failureCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// printThrottled reports how long API calls waited for the client-side rate limit, if at all
+func (af *awsFlags) printThrottled(out io.Writer) {
+	if af.limiter == nil || af.limiter.ThrottledTime() == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\nThrottled locally for %s by --max-tps %g (%s)\n",
+		af.limiter.ThrottledTime().Round(100*time.Millisecond), af.maxTPS, af.limiter.ThrottledSummary())
+}
+
+// onFinish registers f to run once the command has run against every target,
+// whether it succeeded or not
+func (af *awsFlags) onFinish(f func()) {
+	af.finishers = append(af.finishers, f)
+}
+
+// finish saves the recording and runs the registered finishers
+func (af *awsFlags) finish() {
+	af.saveRecording()
+	for _, f := range af.finishers {
+		f()
+	}
+}
+
+// saveRecording writes the cassette of --record, if recording
+func (af *awsFlags) saveRecording() {
+	if af.recorder == nil {
+		return
+	}
+	if err := af.recorder.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save recording: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Recorded %d API call(s) to %s\n", af.recorder.Len(), af.recorder.Path())
+}
+
+// prefixWriter prefixes every line written to it and writes whole lines to the
+// underlying writer, so output from concurrent regions does not interleave mid-line
+type prefixWriter struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+// newPrefixWriter creates a prefixWriter. mu must be shared by all writers of out.
+func newPrefixWriter(out io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	return &prefixWriter{
+		out:    out,
+		mu:     mu,
+		prefix: prefix,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered partial line
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
+
+// newFunctionRepository creates the function repository for awsClient, retrying
+// conflicting changes as often as the client retries API calls
+func newFunctionRepository(awsClient *client.AWSClient) *repository.FunctionRepository {
+	opts := []repository.FunctionRepositoryOption{repository.WithMaxRetries(awsClient.MaxRetries())}
+	if awsClient.Replaying() {
+		opts = append(opts, repository.WithWaiter(replayPollInterval, 5*time.Minute))
+	}
+	return repository.NewFunctionRepository(awsClient.Lambda, opts...)
+}
+
+// pollInterval returns how often a use case polls while waiting for a change
+// to complete, zero for its default. A replay only needs the recorded
+// responses in order, so it polls without waiting.
+func pollInterval(awsClient *client.AWSClient) time.Duration {
+	if awsClient.Replaying() {
+		return replayPollInterval
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/report"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// benchmarkJSON is the JSON representation of a benchmark result, with
+// durations in seconds so results can be compared across runs
+type benchmarkJSON struct {
+	Stack                string                     `json:"stack"`
+	Region               string                     `json:"region"`
+	Mode                 string                     `json:"mode"`
+	StartedAt            time.Time                  `json:"startedAt"`
+	Functions            int                        `json:"functions"`
+	VPCFunctions         int                        `json:"vpcFunctions"`
+	DetachSeconds        float64                    `json:"detachSeconds"`
+	StackDeletionSeconds float64                    `json:"stackDeletionSeconds"`
+	TotalSeconds         float64                    `json:"totalSeconds"`
+	ENIRelease           percentilesJSON            `json:"eniRelease"`
+	ENIsRemaining        int                        `json:"enisRemaining"`
+	Steps                map[string]percentilesJSON `json:"steps"`
+}
+
+// percentilesJSON is the JSON representation of percentiles, in seconds
+type percentilesJSON struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// newPercentilesJSON converts percentiles to seconds
+func newPercentilesJSON(p report.Percentiles) percentilesJSON {
+	return percentilesJSON{
+		Count: p.Count,
+		P50:   p.P50.Seconds(),
+		P90:   p.P90.Seconds(),
+		P99:   p.P99.Seconds(),
+		Max:   p.Max.Seconds(),
+	}
+}
+
+func handleBenchmark(settings config.Settings) {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	reports := newReportFlags(fs)
+	stackFlag := fs.String("stack", "", "CloudFormation stack to delete and measure")
+	baseline := fs.Bool("baseline", false, "Delete the stack without detaching VPC first, to measure the time delambda saves")
+	waitStable := fs.Bool("wait-stable", false, "Wait for an in-progress stack operation to finish instead of refusing")
+	waitTimeout := fs.Duration("wait-timeout", waitTimeoutDefault(settings), "Maximum time to wait for the stack to become stable and for the deletion to complete")
+	eniTimeout := fs.Duration("eni-timeout", usecase.DefaultENITimeout, "Maximum time to wait for the network interfaces of the functions to be released")
+	output := fs.String("output", outputDefault(settings), "Output format: text or json (one JSON object per run, durations in seconds)")
+	fs.Parse(os.Args[2:])
+
+	// Validate flags
+	if *stackFlag == "" {
+		fmt.Fprintln(os.Stderr, "Error: --stack must be specified")
+		fmt.Fprintln(os.Stderr, "Usage: delambda benchmark --stack <stack-name> [--baseline]")
+		os.Exit(1)
+	}
+	if *output != config.OutputText && *output != config.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s\n", config.OutputText, config.OutputJSON)
+		os.Exit(1)
+	}
+	af.structuredOutput = *output == config.OutputJSON
+	reports.quiet = af.structuredOutput
+
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to benchmark stack deletion", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		// Keep progress out of structured output
+		progress := out
+		if af.structuredOutput {
+			progress = os.Stderr
+		}
+
+		functionRepo, _, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+		if err != nil {
+			return err
+		}
+		stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+		if err := checkStackStatus(ctx, stackRepo, *stackFlag, *waitStable, *waitTimeout, progress); err != nil {
+			return err
+		}
+		eniRepo := repository.NewENIRepository(awsClient.EC2)
+		benchmarkUseCase := usecase.NewBenchmarkStackUseCase(functionRepo, stackRepo, eniRepo, progress)
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+
+		input := &usecase.BenchmarkStackInput{
+			StackName:    *stackFlag,
+			Baseline:     *baseline,
+			DisableIPv6:  true,
+			PollInterval: pollInterval(awsClient),
+			Timeout:      *waitTimeout,
+			ENITimeout:   *eniTimeout,
+			Guard:        guard,
+			DryRun:       pf.dryRun,
+		}
+
+		result, err := benchmarkUseCase.Execute(ctx, input)
+		if err != nil || result == nil || !af.structuredOutput {
+			return err
+		}
+
+		steps := make(map[string]percentilesJSON)
+		for _, t := range reports.stepTimings(awsClient) {
+			steps[string(t.Action)] = newPercentilesJSON(t.Percentiles)
+		}
+		return json.NewEncoder(out).Encode(&benchmarkJSON{
+			Stack:                result.Stack,
+			Region:               awsClient.Config.Region,
+			Mode:                 result.Mode,
+			StartedAt:            result.StartedAt.UTC(),
+			Functions:            result.Functions,
+			VPCFunctions:         result.VPCFunctions,
+			DetachSeconds:        result.Detach.Seconds(),
+			StackDeletionSeconds: result.StackDeletion.Seconds(),
+			TotalSeconds:         result.Total.Seconds(),
+			ENIRelease:           newPercentilesJSON(result.ENIRelease),
+			ENIsRemaining:        result.ENIsRemaining,
+			Steps:                steps,
+		})
+	}))
+}
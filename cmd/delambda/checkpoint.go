@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// checkpointFlags holds the --resume flag and the checkpoint file shared by every target of a command
+type checkpointFlags struct {
+	resume string
+	repo   *repository.CheckpointRepository
+}
+
+// newCheckpointFlags registers --resume on fs
+func newCheckpointFlags(fs *flag.FlagSet) *checkpointFlags {
+	c := &checkpointFlags{}
+	fs.StringVar(&c.resume, "resume", "", "Resume an interrupted run from its checkpoint file, skipping completed steps")
+	return c
+}
+
+// open loads the checkpoint file given with --resume, or starts a new one in
+// the state directory, and tells the user how to resume
+func (c *checkpointFlags) open(now time.Time) error {
+	if c.resume != "" {
+		repo, err := repository.LoadCheckpointRepository(c.resume)
+		if err != nil {
+			return err
+		}
+		c.repo = repo
+		fmt.Printf("Resuming from checkpoint %s\n", repo.Path())
+		return nil
+	}
+
+	dir, err := config.DefaultCheckpointDir()
+	if err != nil {
+		return err
+	}
+	c.repo = repository.NewCheckpointRepository(filepath.Join(dir, "delete-"+now.Format("20060102-150405")+".json"))
+	fmt.Printf("Recording progress in %s (resume with --resume %s)\n", c.repo.Path(), c.repo.Path())
+	return nil
+}
+
+// tracker returns the checkpoint tracker for the account and region of awsClient
+func (c *checkpointFlags) tracker(ctx context.Context, awsClient *client.AWSClient) (*usecase.CheckpointTracker, error) {
+	identity, err := awsClient.CallerIdentity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify the account for the checkpoint: %w", err)
+	}
+	return usecase.NewCheckpointTracker(c.repo, identity.Account, awsClient.Config.Region), nil
+}
+
+// finish removes the checkpoint file once every target has been processed successfully
+func (c *checkpointFlags) finish() error {
+	return c.repo.Remove()
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+const (
+	// completionCacheTTL is how long shell completion reuses the names it listed from AWS
+	completionCacheTTL = 5 * time.Minute
+
+	// completionTimeout bounds the AWS calls of a single completion, so a slow
+	// or unreachable API does not hang the shell
+	completionTimeout = 5 * time.Second
+)
+
+// completionCommands are the commands offered by shell completion, in usage order
+var completionCommands = []string{"list", "detach", "delete", "delete-stack", "delete-logs", "history", "benchmark", "select", "stale", "gc", "logs", "completion", "help"}
+
+// completionSubcommands are the subcommands of the commands that only dispatch
+// to them, which have no flags of their own
+var completionSubcommands = map[string][]string{
+	"gc":   {"logs"},
+	"logs": {"retention"},
+}
+
+// completionShells are the shells a completion script can be generated for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// dynamicFlags are the flags whose values are completed with names listed from AWS
+var dynamicFlags = []string{"lambda", "stack", "region"}
+
+// completionFlag is a flag of a command as offered by shell completion
+type completionFlag struct {
+	Name string
+
+	// TakesValue is set for a flag followed by a value, as opposed to a boolean flag
+	TakesValue bool
+}
+
+// Dynamic checks if the values of the flag are names listed from AWS
+func (f completionFlag) Dynamic() bool {
+	return f.TakesValue && slices.Contains(dynamicFlags, f.Name)
+}
+
+// completionCommand is a command and its flags as offered by shell completion.
+// A command that dispatches to subcommands has them instead of flags.
+type completionCommand struct {
+	Name        string
+	Flags       []completionFlag
+	Subcommands []completionCommand
+}
+
+// SubcommandNames returns the names of the subcommands of the command
+func (c completionCommand) SubcommandNames() string {
+	names := make([]string, len(c.Subcommands))
+	for i, sub := range c.Subcommands {
+		names[i] = sub.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// FlagNames returns the flags of the command with their leading dashes
+func (c completionCommand) FlagNames() string {
+	names := make([]string, len(c.Flags))
+	for i, f := range c.Flags {
+		names[i] = "--" + f.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// ValueFlagNames returns the flags of the command that take a value, with their leading dashes
+func (c completionCommand) ValueFlagNames() string {
+	var names []string
+	for _, f := range c.Flags {
+		if f.TakesValue {
+			names = append(names, "--"+f.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func handleCompletion() {
+	if len(os.Args) < 3 || !slices.Contains(completionShells, os.Args[2]) {
+		fmt.Fprintf(os.Stderr, "Error: a shell must be specified: %s\n", strings.Join(completionShells, ", "))
+		fmt.Fprintln(os.Stderr, "Usage: delambda completion <bash|zsh|fish>")
+		os.Exit(1)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate completion: %v\n", err)
+		os.Exit(1)
+	}
+	var commands []completionCommand
+	for _, name := range completionCommands {
+		if name == "completion" || name == "help" {
+			continue
+		}
+		command, err := newCompletionCommand(exe, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate completion: %v\n", err)
+			os.Exit(1)
+		}
+		commands = append(commands, command)
+	}
+
+	if err := writeCompletionScript(os.Stdout, os.Args[2], commands); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate completion: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newCompletionCommand returns the command name with its flags, or with the
+// flags of each of its subcommands
+func newCompletionCommand(exe, name string) (completionCommand, error) {
+	subs, ok := completionSubcommands[name]
+	if !ok {
+		flags, err := commandFlags(exe, name)
+		return completionCommand{Name: name, Flags: flags}, err
+	}
+	command := completionCommand{Name: name}
+	for _, sub := range subs {
+		flags, err := commandFlags(exe, name, sub)
+		if err != nil {
+			return completionCommand{}, err
+		}
+		command.Subcommands = append(command.Subcommands, completionCommand{Name: sub, Flags: flags})
+	}
+	return command, nil
+}
+
+// commandFlags runs the delambda binary at exe to print the help of the command
+// given by words, such as gc logs, and returns its flags, so the scripts always
+// match the flags of the binary
+func commandFlags(exe string, words ...string) ([]completionFlag, error) {
+	// -h prints the flags to stderr and exits successfully
+	out, err := exec.Command(exe, append(words, "-h")...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the flags of %s: %w", strings.Join(words, " "), err)
+	}
+	return parseFlagDefaults(strings.NewReader(string(out))), nil
+}
+
+// parseFlagDefaults reads the flags from the output of flag.PrintDefaults.
+// Every flag starts a line with two spaces and a dash; a flag that takes a
+// value is followed by the name of its type, a boolean flag is not.
+func parseFlagDefaults(r io.Reader) []completionFlag {
+	var flags []completionFlag
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "  -")
+		if !ok {
+			continue
+		}
+		// A single-letter flag has its usage on the same line, after a tab
+		line, _, _ = strings.Cut(line, "\t")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		flags = append(flags, completionFlag{Name: fields[0], TakesValue: len(fields) > 1})
+	}
+	return flags
+}
+
+// writeCompletionScript writes the completion script for shell
+func writeCompletionScript(w io.Writer, shell string, commands []completionCommand) error {
+	tmpl, ok := completionTemplates[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %s: use %s", shell, strings.Join(completionShells, ", "))
+	}
+	return tmpl.Execute(w, struct {
+		Commands    []completionCommand
+		Names       string
+		Shells      string
+		DynamicCase string
+	}{
+		Commands:    commands,
+		Names:       strings.Join(completionCommands, " "),
+		Shells:      strings.Join(completionShells, " "),
+		DynamicCase: "--" + strings.Join(dynamicFlags, "|--"),
+	})
+}
+
+// completionTemplates are the completion scripts per shell. Each one completes
+// the commands, their flags, the shells of completion and the values of the
+// dynamic flags through the hidden __complete command, passing the words typed
+// so far so that --profile, --region and --context are taken into account.
+var completionTemplates = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for delambda
+# Load it with: source <(delambda completion bash)
+
+_delambda() {
+    local cur prev flags values
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "{{.Names}}" -- "${cur}"))
+        return
+    fi
+
+    case "${COMP_WORDS[1]}" in
+{{- range .Commands}}
+    {{.Name}})
+{{- if .Subcommands}}
+        if [[ ${COMP_CWORD} -eq 2 ]]; then
+            COMPREPLY=($(compgen -W "{{.SubcommandNames}}" -- "${cur}"))
+            return
+        fi
+        case "${COMP_WORDS[2]}" in
+{{- range .Subcommands}}
+        {{.Name}})
+            flags="{{.FlagNames}}"
+            values="{{.ValueFlagNames}}"
+            ;;
+{{- end}}
+        *)
+            return
+            ;;
+        esac
+{{- else}}
+        flags="{{.FlagNames}}"
+        values="{{.ValueFlagNames}}"
+{{- end}}
+        ;;
+{{- end}}
+    completion)
+        [[ ${COMP_CWORD} -eq 2 ]] && COMPREPLY=($(compgen -W "{{.Shells}}" -- "${cur}"))
+        return
+        ;;
+    *)
+        return
+        ;;
+    esac
+
+    if [[ " ${values} " == *" ${prev} "* ]]; then
+        case "${prev}" in
+        {{.DynamicCase}})
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(delambda __complete "${prev#--}" "${COMP_WORDS[@]:2:COMP_CWORD-3}" 2>/dev/null)" -- "${cur}"))
+            ;;
+        esac
+        # Other values fall back to file names
+        return
+    fi
+
+    if [[ "${cur}" == -* ]]; then
+        COMPREPLY=($(compgen -W "${flags}" -- "${cur}"))
+    fi
+}
+
+complete -o default -F _delambda delambda
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef delambda
+# zsh completion for delambda
+# Load it with: source <(delambda completion zsh), after compinit
+
+_delambda() {
+    local -a flags values names
+
+    if (( CURRENT == 2 )); then
+        compadd -- {{.Names}}
+        return
+    fi
+
+    case "${words[2]}" in
+{{- range .Commands}}
+    {{.Name}})
+{{- if .Subcommands}}
+        if (( CURRENT == 3 )); then
+            compadd -- {{.SubcommandNames}}
+            return
+        fi
+        case "${words[3]}" in
+{{- range .Subcommands}}
+        {{.Name}})
+            flags=({{.FlagNames}})
+            values=({{.ValueFlagNames}})
+            ;;
+{{- end}}
+        *)
+            return
+            ;;
+        esac
+{{- else}}
+        flags=({{.FlagNames}})
+        values=({{.ValueFlagNames}})
+{{- end}}
+        ;;
+{{- end}}
+    completion)
+        (( CURRENT == 3 )) && compadd -- {{.Shells}}
+        return
+        ;;
+    *)
+        return
+        ;;
+    esac
+
+    local prev="${words[CURRENT-1]}"
+    if (( ${values[(Ie)$prev]} )); then
+        case "${prev}" in
+        {{.DynamicCase}})
+            names=(${(f)"$(delambda __complete "${prev#--}" "${(@)words[3,CURRENT-2]}" 2>/dev/null)"})
+            compadd -a names
+            ;;
+        *)
+            _files
+            ;;
+        esac
+        return
+    fi
+
+    if [[ "${PREFIX}" == -* ]]; then
+        compadd -a flags
+    else
+        _files
+    fi
+}
+
+compdef _delambda delambda
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for delambda
+# Load it with: delambda completion fish | source
+
+function __delambda_complete_values
+    set -l tokens (commandline -opc)
+    delambda __complete $argv[1] $tokens[3..-1] 2>/dev/null
+end
+
+# __delambda_using checks if the command line starts with the given command and subcommand
+function __delambda_using
+    set -l tokens (commandline -opc)
+    test (count $tokens) -gt (count $argv); and test "$tokens[2..(math (count $argv) + 1)]" = "$argv"
+end
+
+# __delambda_needs_subcommand checks if the subcommand of the given command is typed next
+function __delambda_needs_subcommand
+    set -l tokens (commandline -opc)
+    test (count $tokens) -eq 2; and test "$tokens[2]" = $argv[1]
+end
+
+complete -c delambda -f
+complete -c delambda -n __fish_use_subcommand -a "{{.Names}}"
+complete -c delambda -n "__fish_seen_subcommand_from completion" -a "{{.Shells}}"
+{{- range $cmd := .Commands}}
+{{- range .Flags}}
+complete -c delambda -n "__fish_seen_subcommand_from {{$cmd.Name}}" -l {{.Name}}
+{{- if .Dynamic}} -x -a "(__delambda_complete_values {{.Name}})"{{else if .TakesValue}} -r -F{{end}}
+{{- end}}
+{{- if .Subcommands}}
+complete -c delambda -n "__delambda_needs_subcommand {{$cmd.Name}}" -a "{{$cmd.SubcommandNames}}"
+{{- end}}
+{{- range $sub := .Subcommands}}
+{{- range .Flags}}
+complete -c delambda -n "__delambda_using {{$cmd.Name}} {{$sub.Name}}" -l {{.Name}}
+{{- if .Dynamic}} -x -a "(__delambda_complete_values {{.Name}})"{{else if .TakesValue}} -r -F{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+`)),
+}
+
+// handleComplete prints the names a flag value can be completed with, one per
+// line, for the completion scripts. The remaining arguments are the words typed
+// so far, which select the profile, region and context. Any failure prints
+// nothing, so the shell simply offers no names.
+func handleComplete(settings config.Settings) {
+	if len(os.Args) < 3 {
+		return
+	}
+	kind, args := os.Args[2], os.Args[3:]
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	names, err := completeNames(ctx, settings, kind, args)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+// completeNames returns the names of kind for the profile and region selected
+// by args, from the completion cache if it has recent ones
+func completeNames(ctx context.Context, settings config.Settings, kind string, args []string) ([]string, error) {
+	if !slices.Contains(dynamicFlags, kind) {
+		return nil, fmt.Errorf("unsupported completion %s", kind)
+	}
+
+	profile := settings.Profile
+	if value, ok := findFlagValue(args, "profile"); ok {
+		profile = value
+	}
+	region := settings.Region
+	if value, ok := findFlagValue(args, "region"); ok && kind != "region" {
+		region = value
+	}
+	roleARN := settings.RoleARN
+	if value, ok := findFlagValue(args, "role-arn"); ok {
+		roleARN = value
+	}
+
+	// A completion fails fast instead of retrying
+	opts := []client.Option{client.WithRetries(0, aws.RetryModeStandard)}
+	if roleARN != "" {
+		opts = append(opts, client.WithAssumeRole(client.AssumeRole{
+			RoleARN:     roleARN,
+			ExternalID:  settings.ExternalID,
+			SessionName: "delambda",
+		}))
+	}
+	awsClient, err := client.NewAWSClient(ctx, region, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheProfile := profile
+	if cacheProfile == "" {
+		cacheProfile = cmp.Or(os.Getenv("AWS_PROFILE"), "default")
+	}
+	key := strings.Join([]string{kind, cacheProfile, roleARN, awsClient.Config.Region}, "-")
+	if kind == "region" {
+		key = strings.Join([]string{kind, cacheProfile, roleARN}, "-")
+	}
+
+	cacheDir, err := config.DefaultCompletionCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	cache := repository.NewNameCache(cacheDir, completionCacheTTL)
+	return cache.Names(key, func() ([]string, error) {
+		switch kind {
+		case "lambda":
+			functions, err := repository.NewFunctionRepository(awsClient.Lambda).FindAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			names := make([]string, len(functions))
+			for i, fn := range functions {
+				names[i] = fn.Name()
+			}
+			slices.Sort(names)
+			return names, nil
+		case "stack":
+			stacks, err := repository.NewStackRepository(awsClient.CloudFormation).FindAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			names := make([]string, len(stacks))
+			for i, st := range stacks {
+				names[i] = st.Name()
+			}
+			slices.Sort(names)
+			return names, nil
+		default:
+			return awsClient.EnabledRegions(ctx)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlagDefaults(t *testing.T) {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.String("lambda", "", "Lambda function name")
+	fs.Bool("dry-run", false, "Show what would be done")
+	fs.Duration("wait-timeout", time.Hour, "Maximum time to wait\nacross several lines")
+	fs.Int("n", 0, "A single-letter flag")
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+
+	got := parseFlagDefaults(&buf)
+	want := []completionFlag{
+		{Name: "dry-run"},
+		{Name: "lambda", TakesValue: true},
+		{Name: "n", TakesValue: true},
+		{Name: "wait-timeout", TakesValue: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFlagDefaults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteCompletionScript(t *testing.T) {
+	commands := []completionCommand{
+		{Name: "delete", Flags: []completionFlag{{Name: "lambda", TakesValue: true}, {Name: "dry-run"}, {Name: "report", TakesValue: true}}},
+		{Name: "gc", Subcommands: []completionCommand{{Name: "logs", Flags: []completionFlag{{Name: "region", TakesValue: true}, {Name: "yes"}}}}},
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "bash", want: []string{
+			"complete -o default -F _delambda delambda", `flags="--lambda --dry-run --report"`, `values="--lambda --report"`, "--lambda|--stack|--region)",
+			`COMPREPLY=($(compgen -W "logs" -- "${cur}"))`, `flags="--region --yes"`,
+		}},
+		{shell: "zsh", want: []string{"compdef _delambda delambda", "flags=(--lambda --dry-run --report)", "--lambda|--stack|--region)", "compadd -- logs", "flags=(--region --yes)"}},
+		{shell: "fish", want: []string{
+			`-n "__fish_seen_subcommand_from delete" -l lambda -x -a "(__delambda_complete_values lambda)"`,
+			`-n "__fish_seen_subcommand_from delete" -l dry-run` + "\n",
+			`-n "__fish_seen_subcommand_from delete" -l report -r -F`,
+			`-n "__delambda_needs_subcommand gc" -a "logs"`,
+			`-n "__delambda_using gc logs" -l region -x -a "(__delambda_complete_values region)"`,
+			`-n "__delambda_using gc logs" -l yes` + "\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletionScript(&buf, tt.shell, commands); err != nil {
+				t.Fatalf("writeCompletionScript() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("script does not contain %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+
+	if err := writeCompletionScript(&bytes.Buffer{}, "tcsh", commands); err == nil {
+		t.Error("writeCompletionScript() with an unsupported shell succeeded")
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+// End-to-end tests that run the delambda binary against an AWS API emulator
+// such as LocalStack. They are skipped unless DELAMBDA_E2E_ENDPOINT is set:
+//
+//	docker run --rm -p 4566:4566 localstack/localstack
+//	DELAMBDA_E2E_ENDPOINT=http://localhost:4566 go test ./cmd/delambda -run E2E -v
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+const (
+	e2eEndpointEnv = "DELAMBDA_E2E_ENDPOINT"
+	e2eRegion      = "us-east-1"
+	e2eRoleARN     = "arn:aws:iam::000000000000:role/delambda-e2e"
+)
+
+// e2eEnv holds what every end-to-end test needs
+type e2eEnv struct {
+	endpoint string
+	binary   string
+	aws      *client.AWSClient
+}
+
+// setupE2E skips the test unless an emulator endpoint is configured, then
+// builds the CLI and creates SDK clients pointing at the emulator
+func setupE2E(t *testing.T) *e2eEnv {
+	t.Helper()
+
+	endpoint := os.Getenv(e2eEndpointEnv)
+	if endpoint == "" {
+		t.Skipf("%s not set, skipping end-to-end test", e2eEndpointEnv)
+	}
+
+	// Emulators accept any credentials, but the SDK still needs some
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		t.Setenv("AWS_ACCESS_KEY_ID", "test")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	}
+
+	binary := buildDelambda(t)
+
+	awsClient, err := client.NewAWSClient(context.Background(), e2eRegion, "", client.WithEndpointURL(endpoint))
+	if err != nil {
+		t.Fatalf("failed to create AWS client: %v", err)
+	}
+
+	return &e2eEnv{
+		endpoint: endpoint,
+		binary:   binary,
+		aws:      awsClient,
+	}
+}
+
+// buildDelambda builds the CLI into a temporary directory and returns its path
+func buildDelambda(t *testing.T) string {
+	t.Helper()
+
+	binary := filepath.Join(t.TempDir(), "delambda")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build delambda: %v\n%s", err, out)
+	}
+	return binary
+}
+
+// run executes the CLI with the emulator configured through AWS_ENDPOINT_URL
+func (e *e2eEnv) run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(e.binary, args...)
+	cmd.Env = append(os.Environ(),
+		"AWS_ENDPOINT_URL="+e.endpoint,
+		"AWS_REGION="+e2eRegion,
+	)
+	out, err := cmd.CombinedOutput()
+	t.Logf("delambda %s\n%s", strings.Join(args, " "), out)
+	return string(out), err
+}
+
+// uniqueName returns a resource name that does not clash between runs
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+}
+
+// handlerZip returns a deployment package with a minimal Python handler
+func handlerZip(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("index.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("def handler(event, context):\n    return event\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// createFunction creates a function with the given tags and its log group, and waits until it is active
+func (e *e2eEnv) createFunction(t *testing.T, name string, tags map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := e.aws.Lambda.CreateFunction(ctx, &lambda.CreateFunctionInput{
+		FunctionName: aws.String(name),
+		Runtime:      types.RuntimePython312,
+		Handler:      aws.String("index.handler"),
+		Role:         aws.String(e2eRoleARN),
+		Code:         &types.FunctionCode{ZipFile: handlerZip(t)},
+		Tags:         tags,
+	})
+	if err != nil {
+		t.Fatalf("failed to create function %s: %v", name, err)
+	}
+
+	waiter := lambda.NewFunctionActiveV2Waiter(e.aws.Lambda)
+	if err := waiter.Wait(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(name)}, 2*time.Minute); err != nil {
+		t.Fatalf("function %s did not become active: %v", name, err)
+	}
+
+	_, err = e.aws.Logs.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String("/aws/lambda/" + name),
+	})
+	if err != nil {
+		t.Fatalf("failed to create log group for %s: %v", name, err)
+	}
+}
+
+// functionExists checks if the function can still be found
+func (e *e2eEnv) functionExists(t *testing.T, name string) bool {
+	t.Helper()
+
+	_, err := e.aws.Lambda.GetFunction(context.Background(), &lambda.GetFunctionInput{
+		FunctionName: aws.String(name),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("failed to get function %s: %v", name, err)
+	}
+	return true
+}
+
+// logGroupExists checks if the log group can still be found
+func (e *e2eEnv) logGroupExists(t *testing.T, name string) bool {
+	t.Helper()
+
+	output, err := e.aws.Logs.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(name),
+	})
+	if err != nil {
+		t.Fatalf("failed to describe log groups: %v", err)
+	}
+	for _, lg := range output.LogGroups {
+		if aws.ToString(lg.LogGroupName) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestE2EListAndDeleteFunction(t *testing.T) {
+	e := setupE2E(t)
+	name := uniqueName("delambda-e2e-fn")
+	e.createFunction(t, name, nil)
+
+	out, err := e.run(t, "list")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(out, name) || !strings.Contains(out, "No VPC") {
+		t.Errorf("list output does not show %s without VPC", name)
+	}
+
+	// The flag must work without the environment variable as well
+	cmd := exec.Command(e.binary, "list", "--endpoint-url", e.endpoint, "--region", e2eRegion)
+	flagOut, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("list --endpoint-url failed: %v\n%s", err, flagOut)
+	}
+	if !strings.Contains(string(flagOut), name) {
+		t.Errorf("list --endpoint-url output does not show %s", name)
+	}
+
+	if _, err := e.run(t, "delete", "--lambda", name); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if e.functionExists(t, name) {
+		t.Errorf("function %s still exists after delete", name)
+	}
+	if e.logGroupExists(t, "/aws/lambda/"+name) {
+		t.Errorf("log group of %s still exists after delete", name)
+	}
+}
+
+func TestE2EDeleteWithoutLogs(t *testing.T) {
+	e := setupE2E(t)
+	name := uniqueName("delambda-e2e-keep-logs")
+	e.createFunction(t, name, nil)
+
+	if _, err := e.run(t, "delete", "--lambda", name, "--without-logs"); err != nil {
+		t.Fatalf("delete --without-logs failed: %v", err)
+	}
+	if e.functionExists(t, name) {
+		t.Errorf("function %s still exists after delete", name)
+	}
+	if !e.logGroupExists(t, "/aws/lambda/"+name) {
+		t.Errorf("log group of %s was deleted despite --without-logs", name)
+	}
+}
+
+func TestE2EProtectedFunction(t *testing.T) {
+	e := setupE2E(t)
+	name := uniqueName("delambda-e2e-protected")
+	e.createFunction(t, name, map[string]string{"delambda:protect": "true"})
+
+	out, err := e.run(t, "delete", "--lambda", name, "--dry-run")
+	if err != nil {
+		t.Fatalf("delete --dry-run failed: %v", err)
+	}
+	if !strings.Contains(out, "tagged delambda:protect=true") || !strings.Contains(out, "Would delete function "+name) {
+		t.Error("dry run output does not show the violation and the plan")
+	}
+
+	out, err = e.run(t, "delete", "--lambda", name)
+	if err == nil {
+		t.Fatal("delete of a protected function succeeded")
+	}
+	if !strings.Contains(out, "protection rules block this operation") {
+		t.Error("refusal does not explain the protection rule")
+	}
+	if !e.functionExists(t, name) {
+		t.Errorf("protected function %s was deleted", name)
+	}
+}
+
+func TestE2EStackListAndDeleteStack(t *testing.T) {
+	e := setupE2E(t)
+	ctx := context.Background()
+	stackName := uniqueName("delambda-e2e-stack")
+	functionName := stackName + "-fn"
+
+	template := fmt.Sprintf(`{
+  "Resources": {
+    "Handler": {
+      "Type": "AWS::Lambda::Function",
+      "Properties": {
+        "FunctionName": %q,
+        "Runtime": "python3.12",
+        "Handler": "index.handler",
+        "Role": %q,
+        "Code": {"ZipFile": "def handler(event, context):\n    return event\n"}
+      }
+    }
+  }
+}`, functionName, e2eRoleARN)
+
+	_, err := e.aws.CloudFormation.CreateStack(ctx, &cloudformation.CreateStackInput{
+		StackName:    aws.String(stackName),
+		TemplateBody: aws.String(template),
+		Tags:         []cfntypes.Tag{{Key: aws.String("delambda-e2e"), Value: aws.String("true")}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	waiter := cloudformation.NewStackCreateCompleteWaiter(e.aws.CloudFormation)
+	if err := waiter.Wait(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)}, 5*time.Minute); err != nil {
+		t.Fatalf("stack %s was not created: %v", stackName, err)
+	}
+
+	out, err := e.run(t, "list", "--stack", stackName)
+	if err != nil {
+		t.Fatalf("list --stack failed: %v", err)
+	}
+	if !strings.Contains(out, functionName) {
+		t.Errorf("list --stack output does not show %s", functionName)
+	}
+
+	out, err = e.run(t, "delete-stack", "--stack", stackName)
+	if err != nil {
+		t.Fatalf("delete-stack failed: %v", err)
+	}
+	if !strings.Contains(out, "=== Timings ===") {
+		t.Error("delete-stack output does not show timings")
+	}
+	if e.functionExists(t, functionName) {
+		t.Errorf("function %s still exists after delete-stack", functionName)
+	}
+}
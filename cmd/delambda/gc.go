@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+	"golang.org/x/term"
+)
+
+// orphanedLogGroupRecord is the JSON representation of an orphaned log group
+type orphanedLogGroupRecord struct {
+	LogGroup      string     `json:"logGroup"`
+	Function      string     `json:"function"`
+	EdgeRegion    string     `json:"edgeRegion,omitempty"`
+	Account       string     `json:"account,omitempty"`
+	Region        string     `json:"region"`
+	StoredBytes   int64      `json:"storedBytes"`
+	LastEventTime *time.Time `json:"lastEventTime,omitempty"`
+}
+
+// handleGC dispatches the gc subcommands, which find and delete resources
+// that deleted functions left behind
+func handleGC(settings config.Settings) {
+	if len(os.Args) > 2 && os.Args[2] == "logs" {
+		handleGCLogs(settings)
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: delambda gc logs [options]")
+	if len(os.Args) > 2 && (os.Args[2] == "-h" || os.Args[2] == "--help") {
+		return
+	}
+	os.Exit(1)
+}
+
+func handleGCLogs(settings config.Settings) {
+	fs := flag.NewFlagSet("gc logs", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	yes := fs.Bool("yes", false, "Delete the orphaned log groups without asking for confirmation")
+	output := fs.String("output", outputDefault(settings), "Output format: text or json (one JSON object per log group and line)")
+	fs.Parse(os.Args[3:])
+
+	if *output != config.OutputText && *output != config.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s\n", config.OutputText, config.OutputJSON)
+		os.Exit(1)
+	}
+	af.structuredOutput = *output == config.OutputJSON
+
+	// Confirmation is asked once, on the terminal, so it needs one and a single target
+	if !*yes && !pf.dryRun {
+		if af.isFanOut() {
+			fmt.Fprintln(os.Stderr, "Error: --regions, --all-regions and --accounts-file require --yes or --dry-run")
+			os.Exit(1)
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintln(os.Stderr, "Error: confirmation needs an interactive terminal, pass --yes to delete without it")
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to delete orphaned log groups", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		progress := out
+		if af.structuredOutput {
+			progress = os.Stderr
+		}
+
+		region := awsClient.Config.Region
+		findUseCase := usecase.NewFindOrphanedLogGroupsUseCase(
+			repository.NewLogGroupRepository(awsClient.Logs),
+			newFunctionRepository(awsClient),
+			func(region string) (function.Repository, error) {
+				return newFunctionRepository(awsClient.ForRegion(region)), nil
+			},
+		)
+		orphans, err := findUseCase.Execute(ctx, &usecase.FindOrphanedLogGroupsInput{Region: region})
+		if err != nil {
+			return err
+		}
+		target, err := newRecordTarget(ctx, awsClient, *output)
+		if err != nil {
+			return err
+		}
+		if err := printOrphanedLogGroups(out, *output, target, orphans); err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			return nil
+		}
+
+		if !*yes && !pf.dryRun {
+			ok, err := confirm(os.Stdin, os.Stderr, fmt.Sprintf("Delete %d log group(s) storing %s?", len(orphans), formatBytes(storedBytes(orphanLogGroups(orphans)))))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(progress, "Nothing was deleted")
+				return nil
+			}
+		}
+
+		_, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, nil)
+		if err != nil {
+			return err
+		}
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(progress)
+		return usecase.NewDeleteLogGroupUseCase(logGroupRepo, progress).Execute(ctx, &usecase.DeleteLogGroupInput{
+			LogGroups: orphanLogGroups(orphans),
+			Guard:     guard,
+			DryRun:    pf.dryRun,
+		})
+	})
+}
+
+// printOrphanedLogGroups prints the log groups found by gc logs in the given output format
+func printOrphanedLogGroups(out io.Writer, output string, target recordTarget, orphans []*usecase.OrphanedLogGroup) error {
+	if output == config.OutputJSON {
+		encoder := json.NewEncoder(out)
+		for _, orphan := range orphans {
+			record := orphanedLogGroupRecord{
+				LogGroup:    orphan.LogGroup.Name(),
+				Function:    orphan.FunctionName,
+				EdgeRegion:  orphan.Region,
+				Account:     target.Account,
+				Region:      target.Region,
+				StoredBytes: orphan.LogGroup.StoredBytes(),
+			}
+			if !orphan.LastEventTime.IsZero() {
+				lastEventTime := orphan.LastEventTime.UTC()
+				record.LastEventTime = &lastEventTime
+			}
+			if err := encoder.Encode(&record); err != nil {
+				return fmt.Errorf("failed to encode log group: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(orphans) == 0 {
+		fmt.Fprintln(out, "No orphaned Lambda log groups found")
+		return nil
+	}
+
+	width := 0
+	for _, orphan := range orphans {
+		width = max(width, len(orphan.LogGroup.Name()))
+	}
+	fmt.Fprintf(out, "Found %d log group(s) of Lambda functions that no longer exist, storing %s:\n\n", len(orphans), formatBytes(storedBytes(orphanLogGroups(orphans))))
+	for _, orphan := range orphans {
+		lastEvent := "no events"
+		if !orphan.LastEventTime.IsZero() {
+			lastEvent = "last event " + orphan.LastEventTime.UTC().Format(time.DateOnly)
+		}
+		line := fmt.Sprintf("%-*s  %10s  %s", width, orphan.LogGroup.Name(), formatBytes(orphan.LogGroup.StoredBytes()), lastEvent)
+		if orphan.Region != "" {
+			line += fmt.Sprintf(" (Lambda@Edge replica of %s in %s)", orphan.FunctionName, orphan.Region)
+		}
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// orphanLogGroups returns the log groups of orphans
+func orphanLogGroups(orphans []*usecase.OrphanedLogGroup) []*loggroup.LogGroup {
+	logGroups := make([]*loggroup.LogGroup, 0, len(orphans))
+	for _, orphan := range orphans {
+		logGroups = append(logGroups, orphan.LogGroup)
+	}
+	return logGroups
+}
+
+// formatBytes formats a byte count with a binary unit, such as 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// confirm asks question on out and reads the answer from in, accepting y or yes
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 5 << 30, want: "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{answer: "y\n", want: true},
+		{answer: " YES \n", want: true},
+		{answer: "n\n", want: false},
+		{answer: "\n", want: false},
+		{answer: "", want: false},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		got, err := confirm(strings.NewReader(tt.answer), &out, "Delete?")
+		if err != nil {
+			t.Fatalf("confirm(%q) error = %v", tt.answer, err)
+		}
+		if got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+		if out.String() != "Delete? [y/N] " {
+			t.Errorf("confirm() asked %q", out.String())
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+	"golang.org/x/term"
+)
+
+// handleLogs dispatches the logs subcommands, which manage the log groups of
+// functions without deleting the functions
+func handleLogs(settings config.Settings) {
+	if len(os.Args) > 2 && os.Args[2] == "retention" {
+		handleLogsRetention(settings)
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: delambda logs retention --stack <stack-name-or-pattern> --days <days>")
+	if len(os.Args) > 2 && (os.Args[2] == "-h" || os.Args[2] == "--help") {
+		return
+	}
+	os.Exit(1)
+}
+
+func handleLogsRetention(settings config.Settings) {
+	fs := flag.NewFlagSet("logs retention", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	stackSel := newStackSelector(fs)
+	days := fs.String("days", "", "Retention of the log groups, in days such as 30 or 30d")
+	fs.Parse(os.Args[3:])
+
+	if !stackSel.isSet() || *days == "" {
+		fmt.Fprintln(os.Stderr, "Error: --stack or --stack-tag, and --days must be specified")
+		fmt.Fprintln(os.Stderr, "Usage: delambda logs retention --stack <stack-name-or-pattern> --days <days>")
+		os.Exit(1)
+	}
+	retentionDays, err := parseRetention(*days)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --days: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to set log retention", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		_, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, nil)
+		if err != nil {
+			return err
+		}
+		stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+		listStackUseCase := usecase.NewListStackFunctionsUseCase(newFunctionRepository(awsClient), stackRepo)
+		retentionUseCase := usecase.NewSetLogRetentionUseCase(logGroupRepo, out)
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+
+		return runForStacks(ctx, stackRepo, stackSel, out, func(stackName string) error {
+			functions, err := listStackUseCase.Execute(ctx, stackName)
+			if err != nil {
+				return err
+			}
+			if len(functions) == 0 {
+				fmt.Fprintf(out, "No Lambda functions found in stack %s\n", stackName)
+				return nil
+			}
+
+			return retentionUseCase.Execute(ctx, &usecase.SetLogRetentionInput{
+				StackName: stackName,
+				Functions: functions,
+				Days:      retentionDays,
+				Guard:     guard,
+				DryRun:    pf.dryRun,
+			})
+		})
+	})
+}
+
+func handleDeleteLogs(settings config.Settings) {
+	fs := flag.NewFlagSet("delete-logs", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	logGroupName := fs.String("log-group", "", "Full name of the log group to delete, such as /aws/lambda/my-function")
+	functionName := fs.String("function", "", "Delete the log group of this Lambda function, /aws/lambda/<name>")
+	prefix := fs.String("prefix", "", "Delete every log group whose name starts with this prefix under /aws/lambda/, after confirming")
+	fromStdin := fs.Bool("from-stdin", false, "Delete the log groups named on stdin, one per line, as names or JSON objects with a logGroup field as written by gc logs --output json")
+	yes := fs.Bool("yes", false, "Delete the log groups matched by --prefix or --from-stdin without asking for confirmation")
+	fs.Parse(os.Args[2:])
+
+	// A positional argument is the full log group name, as --log-group
+	if fs.NArg() > 0 && *logGroupName == "" {
+		*logGroupName = fs.Arg(0)
+	}
+
+	forms := 0
+	for _, set := range []bool{*logGroupName != "", *functionName != "", *prefix != "", *fromStdin} {
+		if set {
+			forms++
+		}
+	}
+	if forms == 0 {
+		fmt.Fprintln(os.Stderr, "Error: Either --log-group, --function, --prefix or --from-stdin must be specified")
+		fmt.Fprintln(os.Stderr, "Usage: delambda delete-logs --log-group <name> | --function <name> | --prefix <prefix> | --from-stdin")
+		os.Exit(1)
+	}
+	if forms > 1 {
+		fmt.Fprintln(os.Stderr, "Error: only one of --log-group, --function, --prefix and --from-stdin can be specified")
+		os.Exit(1)
+	}
+
+	if *prefix != "" {
+		if err := loggroup.ValidatePrefix(*prefix); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --prefix: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	input := &usecase.FindLogGroupsInput{Prefix: *prefix}
+	switch {
+	case *logGroupName != "":
+		input.Names = []string{*logGroupName}
+	case *functionName != "":
+		input.Names = []string{loggroup.NewLogGroupForFunction(*functionName).Name()}
+	}
+
+	// The records name the account and region of each log group, so they are
+	// checked against a single one instead of being applied to all of them
+	var entries []stdinName
+	if *fromStdin {
+		if af.isFanOut() {
+			fmt.Fprintln(os.Stderr, "Error: --from-stdin cannot be combined with --regions, --all-regions or --accounts-file")
+			os.Exit(1)
+		}
+		var err error
+		entries, err = readLogGroupNames(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --from-stdin: %v\n", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --from-stdin: no log group names on stdin")
+			os.Exit(1)
+		}
+	}
+
+	// Matches of a prefix or a list are confirmed on the terminal before deleting
+	bulk := *prefix != "" || *fromStdin
+	if bulk && !*yes && !pf.dryRun {
+		if *fromStdin {
+			fmt.Fprintln(os.Stderr, "Error: --from-stdin reads the names from stdin, so it requires --yes or --dry-run")
+			os.Exit(1)
+		}
+		if af.isFanOut() {
+			fmt.Fprintln(os.Stderr, "Error: --regions, --all-regions and --accounts-file require --yes or --dry-run with --prefix")
+			os.Exit(1)
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintln(os.Stderr, "Error: confirmation needs an interactive terminal, pass --yes to delete without it")
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to delete log groups", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		if *fromStdin {
+			names, err := selectNamesFor(ctx, awsClient, entries)
+			if err != nil {
+				return fmt.Errorf("--from-stdin: %w", err)
+			}
+			input.Names = names
+		}
+
+		found, err := usecase.NewFindLogGroupsUseCase(repository.NewLogGroupRepository(awsClient.Logs)).Execute(ctx, input)
+		if err != nil {
+			return err
+		}
+		printMatchedLogGroups(out, found)
+		if len(found.LogGroups) == 0 {
+			return nil
+		}
+
+		if bulk && !*yes && !pf.dryRun {
+			ok, err := confirm(os.Stdin, os.Stderr, fmt.Sprintf("Delete %d log group(s) storing %s?", len(found.LogGroups), formatBytes(storedBytes(found.LogGroups))))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "Nothing was deleted")
+				return nil
+			}
+		}
+
+		_, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, nil)
+		if err != nil {
+			return err
+		}
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+		return usecase.NewDeleteLogGroupUseCase(logGroupRepo, out).Execute(ctx, &usecase.DeleteLogGroupInput{
+			LogGroups: found.LogGroups,
+			Guard:     guard,
+			DryRun:    pf.dryRun,
+		})
+	})
+}
+
+// printMatchedLogGroups prints the log groups matched by delete-logs with their
+// size and creation date, followed by the names that do not exist
+func printMatchedLogGroups(out io.Writer, found *usecase.FoundLogGroups) {
+	if len(found.LogGroups) == 0 {
+		fmt.Fprintln(out, "No log groups matched")
+	} else {
+		width := 0
+		for _, lg := range found.LogGroups {
+			width = max(width, len(lg.Name()))
+		}
+		fmt.Fprintf(out, "Matched %d log group(s) storing %s:\n\n", len(found.LogGroups), formatBytes(storedBytes(found.LogGroups)))
+		for _, lg := range found.LogGroups {
+			line := fmt.Sprintf("%-*s  %10s", width, lg.Name(), formatBytes(lg.StoredBytes()))
+			if created := lg.CreationTime(); !created.IsZero() {
+				line += "  created " + created.UTC().Format(time.DateOnly)
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+
+	for _, name := range found.Missing {
+		fmt.Fprintf(out, "Not found: %s\n", name)
+	}
+}
+
+// storedBytes returns the bytes stored by all log groups
+func storedBytes(logGroups []*loggroup.LogGroup) int64 {
+	var total int64
+	for _, lg := range logGroups {
+		total += lg.StoredBytes()
+	}
+	return total
+}
+
+// parseRetention parses a log retention period in days, such as 30 or 30d,
+// accepting only the periods CloudWatch Logs supports
+func parseRetention(s string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention %q, expected days such as 30 or 30d", s)
+	}
+	if err := loggroup.ValidateRetention(days); err != nil {
+		return 0, err
+	}
+	return days, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{s: "30", want: 30},
+		{s: "30d", want: 30},
+		{s: "3653d", want: 3653},
+		{s: "31d", wantErr: true},
+		{s: "0", wantErr: true},
+		{s: "2w", wantErr: true},
+		{s: "d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseRetention(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRetention(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRetention(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/audit"
 	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/stack"
 	"github.com/shirasu/delambda/internal/infrastructure/repository"
 	"github.com/shirasu/delambda/pkg/client"
 )
@@ -18,256 +26,722 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Global flags
-	region := flag.String("region", "", "AWS region")
-	profile := flag.String("profile", "", "AWS profile")
-
 	command := os.Args[1]
 
 	switch command {
-	case "list":
-		handleList(region, profile)
-	case "detach":
-		handleDetach(region, profile)
-	case "delete":
-		handleDelete(region, profile)
-	case "delete-logs":
-		handleDeleteLogs(region, profile)
+	case "list", "detach", "delete", "delete-stack", "delete-logs", "history", "benchmark", "select", "stale", "gc", "logs", "__complete":
+	case "completion":
+		handleCompletion()
+		return
 	case "help", "-h", "--help":
 		printUsage()
+		return
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		printUsage()
 		os.Exit(1)
 	}
+
+	// Config file defaults, overridden by environment variables and then by flags
+	settings, err := loadSettings(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "list":
+		handleList(settings)
+	case "detach":
+		handleDetach(settings)
+	case "delete":
+		handleDelete(settings)
+	case "delete-stack":
+		handleDeleteStack(settings)
+	case "delete-logs":
+		handleDeleteLogs(settings)
+	case "history":
+		handleHistory(settings)
+	case "benchmark":
+		handleBenchmark(settings)
+	case "select":
+		handleSelect(settings)
+	case "stale":
+		handleStale(settings)
+	case "gc":
+		handleGC(settings)
+	case "logs":
+		handleLogs(settings)
+	case "__complete":
+		handleComplete(settings)
+	}
 }
 
-func handleList(region, profile *string) {
+func handleList(settings config.Settings) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	regionFlag := fs.String("region", *region, "AWS region")
-	profileFlag := fs.String("profile", *profile, "AWS profile")
-	stackFlag := fs.String("stack", "", "CloudFormation stack name")
+	af := newAWSFlags(fs, settings)
+	stackSel := newStackSelector(fs)
+	output := fs.String("output", outputDefault(settings), "Output format: text or json (one JSON object per function and line)")
 	fs.Parse(os.Args[2:])
 
-	ctx := context.Background()
-	awsClient, err := client.NewAWSClient(ctx, *regionFlag, *profileFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create AWS client: %v\n", err)
+	if *output != config.OutputText && *output != config.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s\n", config.OutputText, config.OutputJSON)
 		os.Exit(1)
 	}
+	af.structuredOutput = *output == config.OutputJSON
 
-	var functions []*function.Function
+	ctx := context.Background()
 
-	if *stackFlag != "" {
-		// List functions in a specific stack
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
-		listStackUseCase := usecase.NewListStackFunctionsUseCase(functionRepo, stackRepo)
+	if stackSel.isSet() {
+		// List functions in the selected stacks
+		af.run(ctx, "Failed to list functions in stack", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo := newFunctionRepository(awsClient)
+			stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+			listStackUseCase := usecase.NewListStackFunctionsUseCase(functionRepo, stackRepo)
+
+			// Keep stack progress out of structured output
+			progress := out
+			if af.structuredOutput {
+				progress = os.Stderr
+			}
+
+			target, err := newRecordTarget(ctx, awsClient, *output)
+			if err != nil {
+				return err
+			}
 
-		functions, err = listStackUseCase.Execute(ctx, *stackFlag)
+			return runForStacks(ctx, stackRepo, stackSel, progress, func(stackName string) error {
+				stackFunctions, err := listStackUseCase.Execute(ctx, stackName)
+				if err != nil {
+					return err
+				}
+				return printFunctions(out, *output, target, stackFunctions)
+			})
+		})
+		return
+	}
+
+	// List all functions
+	af.run(ctx, "Failed to list functions", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		functionRepo := newFunctionRepository(awsClient)
+		listUseCase := usecase.NewListFunctionsUseCase(functionRepo)
+
+		functions, err := listUseCase.Execute(ctx)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to list functions in stack: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-	} else {
-		// List all functions
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		listUseCase := usecase.NewListFunctionsUseCase(functionRepo)
 
-		functions, err = listUseCase.Execute(ctx)
+		stackFunctions := make([]*usecase.StackFunction, 0, len(functions))
+		for _, fn := range functions {
+			stackFunctions = append(stackFunctions, &usecase.StackFunction{Function: fn})
+		}
+		target, err := newRecordTarget(ctx, awsClient, *output)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to list functions: %v\n", err)
-			os.Exit(1)
+			return err
+		}
+		return printFunctions(out, *output, target, stackFunctions)
+	})
+}
+
+// printFunctions prints the functions found by the list command in the given output format
+func printFunctions(out io.Writer, output string, target recordTarget, functions []*usecase.StackFunction) error {
+	if output == config.OutputJSON {
+		encoder := json.NewEncoder(out)
+		for _, sf := range functions {
+			if err := encoder.Encode(newFunctionRecord(sf.Function, sf.StackPath, target)); err != nil {
+				return fmt.Errorf("failed to encode function: %w", err)
+			}
 		}
+		return nil
 	}
 
 	if len(functions) == 0 {
-		fmt.Println("No Lambda functions found")
-		return
+		fmt.Fprintln(out, "No Lambda functions found")
+		return nil
 	}
 
-	fmt.Printf("Found %d Lambda function(s):\n\n", len(functions))
-	for _, fn := range functions {
-		vpcInfo := "No VPC"
-		if fn.VPCConfig() != nil && len(fn.VPCConfig().SubnetIds) > 0 {
-			vpcInfo = fmt.Sprintf("VPC: %s", fn.VPCConfig().VPCId)
-			if fn.HasIPv6Enabled() {
-				vpcInfo += " (IPv6 enabled)"
-			}
+	fmt.Fprintf(out, "Found %d Lambda function(s):\n\n", len(functions))
+	for _, sf := range functions {
+		printFunction(out, sf.Function, sf.StackPath)
+	}
+	return nil
+}
+
+// functionRecord is the JSON representation of a function in list output
+type functionRecord struct {
+	Name             string   `json:"name"`
+	Runtime          string   `json:"runtime"`
+	State            string   `json:"state"`
+	Account          string   `json:"account,omitempty"`
+	Region           string   `json:"region"`
+	VPCId            string   `json:"vpcId,omitempty"`
+	SubnetIds        []string `json:"subnetIds,omitempty"`
+	SecurityGroupIds []string `json:"securityGroupIds,omitempty"`
+	IPv6             bool     `json:"ipv6"`
+	Stack            string   `json:"stack,omitempty"`
+}
+
+// newFunctionRecord creates the JSON representation of fn
+func newFunctionRecord(fn *function.Function, stackPath string, target recordTarget) functionRecord {
+	record := functionRecord{
+		Name:    fn.Name(),
+		Runtime: string(fn.Runtime()),
+		State:   string(fn.State()),
+		Account: target.Account,
+		Region:  target.Region,
+		IPv6:    fn.HasIPv6Enabled(),
+		Stack:   stackPath,
+	}
+	if fn.IsAttachedToVPC() {
+		record.VPCId = fn.VPCConfig().VPCId
+		record.SubnetIds = fn.VPCConfig().SubnetIds
+		record.SecurityGroupIds = fn.VPCConfig().SecurityGroupIds
+	}
+	return record
+}
+
+// printFunction prints a single function line for the list command.
+// stackPath is shown when the owning stack is known.
+func printFunction(out io.Writer, fn *function.Function, stackPath string) {
+	fmt.Fprintln(out, formatFunction(fn, stackPath))
+}
+
+// formatFunction formats a function as a line of the list command
+func formatFunction(fn *function.Function, stackPath string) string {
+	vpcInfo := "No VPC"
+	if fn.VPCConfig() != nil && len(fn.VPCConfig().SubnetIds) > 0 {
+		vpcInfo = fmt.Sprintf("VPC: %s", fn.VPCConfig().VPCId)
+		if fn.HasIPv6Enabled() {
+			vpcInfo += " (IPv6 enabled)"
 		}
-		fmt.Printf("  - %s [%v] %s\n", fn.Name(), fn.Runtime(), vpcInfo)
 	}
+	line := fmt.Sprintf("  - %s [%v] %s", fn.Name(), fn.Runtime(), vpcInfo)
+	if stackPath != "" {
+		line += fmt.Sprintf(" {stack: %s}", stackPath)
+	}
+	return line
 }
 
-func handleDetach(region, profile *string) {
+func handleDetach(settings config.Settings) {
 	fs := flag.NewFlagSet("detach", flag.ExitOnError)
-	regionFlag := fs.String("region", *region, "AWS region")
-	profileFlag := fs.String("profile", *profile, "AWS profile")
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	reports := newReportFlags(fs)
 	lambdaFlag := fs.String("lambda", "", "Lambda function name")
-	stackFlag := fs.String("stack", "", "CloudFormation stack name")
+	stackSel := newStackSelector(fs)
+	vpcFlag := fs.String("vpc", "", "Detach all functions attached to this VPC ID")
+	subnetFlag := fs.String("subnet", "", "Detach all functions attached to this subnet ID")
+	securityGroupFlag := fs.String("security-group", "", "Detach all functions attached to this security group ID")
+	waitStable := fs.Bool("wait-stable", false, "Wait for an in-progress stack operation to finish instead of refusing")
+	waitTimeout := fs.Duration("wait-timeout", waitTimeoutDefault(settings), "Maximum time to wait for the stack to become stable")
+	interactive := fs.Bool("interactive", false, "Pick the functions in a terminal UI, from the selected stacks if --stack or --stack-tag is given")
 	fs.Parse(os.Args[2:])
 
+	networkTarget := *vpcFlag != "" || *subnetFlag != "" || *securityGroupFlag != ""
+
+	if *interactive {
+		if *lambdaFlag != "" || networkTarget {
+			fmt.Fprintln(os.Stderr, "Error: --interactive cannot be combined with --lambda, --vpc, --subnet or --security-group")
+			os.Exit(1)
+		}
+		runSelect(af, pf, audits, reports, stackSel, false, 0, detachSelectAction(pf.dryRun))
+		return
+	}
+
 	// Validate flags
-	if *lambdaFlag == "" && *stackFlag == "" {
-		fmt.Fprintln(os.Stderr, "Error: Either --lambda or --stack must be specified")
+	if *lambdaFlag == "" && !stackSel.isSet() && !networkTarget {
+		fmt.Fprintln(os.Stderr, "Error: Either --lambda, --stack, --vpc, --subnet, --security-group or --interactive must be specified")
 		fmt.Fprintln(os.Stderr, "Usage: delambda detach --lambda <function-name>")
-		fmt.Fprintln(os.Stderr, "       delambda detach --stack <stack-name>")
+		fmt.Fprintln(os.Stderr, "       delambda detach --stack <stack-name-or-pattern> [--stack-tag key=value]")
+		fmt.Fprintln(os.Stderr, "       delambda detach --vpc <vpc-id> | --subnet <subnet-id> | --security-group <sg-id>")
+		fmt.Fprintln(os.Stderr, "       delambda detach --interactive [--stack <stack-name-or-pattern>]")
 		os.Exit(1)
 	}
 
-	if *lambdaFlag != "" && *stackFlag != "" {
-		fmt.Fprintln(os.Stderr, "Error: Cannot specify both --lambda and --stack")
+	targets := 0
+	for _, set := range []bool{*lambdaFlag != "", stackSel.isSet(), networkTarget} {
+		if set {
+			targets++
+		}
+	}
+	if targets > 1 {
+		fmt.Fprintln(os.Stderr, "Error: --lambda, --stack and --vpc/--subnet/--security-group cannot be combined")
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
-	awsClient, err := client.NewAWSClient(ctx, *regionFlag, *profileFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create AWS client: %v\n", err)
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
 		os.Exit(1)
 	}
 
+	ctx := context.Background()
+
 	if *lambdaFlag != "" {
 		// Detach VPC from a single function
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		detachVPCUseCase := usecase.NewDetachVPCUseCase(functionRepo)
+		af.run(ctx, "Failed to detach VPC", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo, _, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+			if err != nil {
+				return err
+			}
+			detachVPCUseCase := usecase.NewDetachVPCUseCase(functionRepo, out)
+			guard, err := pf.newGuard(ctx, awsClient)
+			if err != nil {
+				return err
+			}
 
-		input := &usecase.DetachVPCInput{
-			FunctionName: *lambdaFlag,
-			DisableIPv6:  true,
-		}
+			input := &usecase.DetachVPCInput{
+				FunctionName: *lambdaFlag,
+				DisableIPv6:  true,
+				Guard:        guard,
+				DryRun:       pf.dryRun,
+			}
 
-		if err := detachVPCUseCase.Execute(ctx, input); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to detach VPC: %v\n", err)
-			os.Exit(1)
-		}
+			if err := detachVPCUseCase.Execute(ctx, input); err != nil {
+				return err
+			}
+
+			if pf.dryRun {
+				return nil
+			}
+			fmt.Fprintf(out, "Successfully detached VPC from %s\n", *lambdaFlag)
+			return nil
+		}))
+	} else if stackSel.isSet() {
+		// Detach VPC from all functions in the selected stacks
+		af.run(ctx, "Failed to detach VPC from stack", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo, _, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+			if err != nil {
+				return err
+			}
+			stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+			detachVPCStackUseCase := usecase.NewDetachVPCStackUseCase(functionRepo, stackRepo, out)
+			guard, err := pf.newGuard(ctx, awsClient)
+			if err != nil {
+				return err
+			}
 
-		fmt.Printf("Successfully detached VPC from %s\n", *lambdaFlag)
+			return runForStacks(ctx, stackRepo, stackSel, out, func(stackName string) error {
+				if err := checkStackStatus(ctx, stackRepo, stackName, *waitStable, *waitTimeout, out); err != nil {
+					return err
+				}
+
+				input := &usecase.DetachVPCStackInput{
+					StackName:   stackName,
+					DisableIPv6: true,
+					Guard:       guard,
+					DryRun:      pf.dryRun,
+				}
+
+				if err := detachVPCStackUseCase.Execute(ctx, input); err != nil {
+					return err
+				}
+
+				if pf.dryRun {
+					return nil
+				}
+				fmt.Fprintf(out, "Successfully detached VPC from all functions in stack %s\n", stackName)
+				return nil
+			})
+		}))
 	} else {
-		// Detach VPC from all functions in a stack
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
-		detachVPCStackUseCase := usecase.NewDetachVPCStackUseCase(functionRepo, stackRepo)
+		// Detach VPC from all functions referencing a VPC, subnet or security group
+		af.run(ctx, "Failed to detach VPC from functions", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo, _, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+			if err != nil {
+				return err
+			}
+			detachVPCNetworkUseCase := usecase.NewDetachVPCNetworkUseCase(functionRepo, out)
+			guard, err := pf.newGuard(ctx, awsClient)
+			if err != nil {
+				return err
+			}
 
-		input := &usecase.DetachVPCStackInput{
-			StackName:   *stackFlag,
-			DisableIPv6: true,
-		}
+			input := &usecase.DetachVPCNetworkInput{
+				VPCId:           *vpcFlag,
+				SubnetId:        *subnetFlag,
+				SecurityGroupId: *securityGroupFlag,
+				DisableIPv6:     true,
+				Guard:           guard,
+				DryRun:          pf.dryRun,
+			}
 
-		if err := detachVPCStackUseCase.Execute(ctx, input); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to detach VPC from stack: %v\n", err)
-			os.Exit(1)
-		}
+			if err := detachVPCNetworkUseCase.Execute(ctx, input); err != nil {
+				return err
+			}
 
-		fmt.Printf("Successfully detached VPC from all functions in stack %s\n", *stackFlag)
+			if pf.dryRun {
+				return nil
+			}
+			fmt.Fprintln(out, "Successfully detached VPC from all matching functions")
+			return nil
+		}))
 	}
 }
 
-func handleDelete(region, profile *string) {
+func handleDelete(settings config.Settings) {
 	fs := flag.NewFlagSet("delete", flag.ExitOnError)
-	regionFlag := fs.String("region", *region, "AWS region")
-	profileFlag := fs.String("profile", *profile, "AWS profile")
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	reports := newReportFlags(fs)
+	checkpoints := newCheckpointFlags(fs)
 	lambdaFlag := fs.String("lambda", "", "Lambda function name")
-	stackFlag := fs.String("stack", "", "CloudFormation stack name")
-	withoutLogs := fs.Bool("without-logs", false, "Don't delete CloudWatch logs (logs are deleted by default)")
+	stackSel := newStackSelector(fs)
+	withoutLogs := fs.Bool("without-logs", !deleteLogsDefault(settings), "Don't delete CloudWatch logs (logs are deleted by default)")
+	logsRetention := fs.String("logs-retention", "", "Keep the CloudWatch logs and set their retention instead of deleting them, such as 30d")
+	waitStable := fs.Bool("wait-stable", false, "Wait for an in-progress stack operation to finish instead of refusing")
+	waitTimeout := fs.Duration("wait-timeout", waitTimeoutDefault(settings), "Maximum time to wait for the stack to become stable")
+	interactive := fs.Bool("interactive", false, "Pick the functions in a terminal UI, from the selected stacks if --stack or --stack-tag is given")
+	fromStdin := fs.Bool("from-stdin", false, "Delete the functions named on stdin, one name or JSON object per line as written by list and stale --output json")
 	fs.Parse(os.Args[2:])
 
+	logsRetentionDays := 0
+	if *logsRetention != "" {
+		days, err := parseRetention(*logsRetention)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --logs-retention: %v\n", err)
+			os.Exit(1)
+		}
+		logsRetentionDays = days
+	}
+
+	if *fromStdin {
+		if *lambdaFlag != "" || stackSel.isSet() || *interactive || checkpoints.resume != "" {
+			fmt.Fprintln(os.Stderr, "Error: --from-stdin cannot be combined with --lambda, --stack, --interactive or --resume")
+			os.Exit(1)
+		}
+		deleteFunctionsFromStdin(af, pf, audits, reports, !*withoutLogs, logsRetentionDays)
+		return
+	}
+
+	if *interactive {
+		if *lambdaFlag != "" || checkpoints.resume != "" {
+			fmt.Fprintln(os.Stderr, "Error: --interactive cannot be combined with --lambda or --resume")
+			os.Exit(1)
+		}
+		deleteLogs := !*withoutLogs
+		runSelect(af, pf, audits, reports, stackSel, deleteLogs, logsRetentionDays, deleteSelectAction(pf.dryRun, deleteLogs, logsRetentionDays))
+		return
+	}
+
 	// Validate flags
-	if *lambdaFlag == "" && *stackFlag == "" {
-		fmt.Fprintln(os.Stderr, "Error: Either --lambda or --stack must be specified")
+	if *lambdaFlag == "" && !stackSel.isSet() {
+		fmt.Fprintln(os.Stderr, "Error: Either --lambda, --stack, --interactive or --from-stdin must be specified")
 		fmt.Fprintln(os.Stderr, "Usage: delambda delete --lambda <function-name>")
-		fmt.Fprintln(os.Stderr, "       delambda delete --stack <stack-name>")
+		fmt.Fprintln(os.Stderr, "       delambda delete --stack <stack-name-or-pattern> [--stack-tag key=value]")
+		fmt.Fprintln(os.Stderr, "       delambda delete --interactive [--stack <stack-name-or-pattern>]")
+		fmt.Fprintln(os.Stderr, "       delambda stale --days 90 --output json | delambda delete --from-stdin")
 		os.Exit(1)
 	}
 
-	if *lambdaFlag != "" && *stackFlag != "" {
+	if *lambdaFlag != "" && stackSel.isSet() {
 		fmt.Fprintln(os.Stderr, "Error: Cannot specify both --lambda and --stack")
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
-	awsClient, err := client.NewAWSClient(ctx, *regionFlag, *profileFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create AWS client: %v\n", err)
+	if checkpoints.resume != "" && (*lambdaFlag != "" || pf.dryRun) {
+		fmt.Fprintln(os.Stderr, "Error: --resume can only be used with --stack and without --dry-run")
 		os.Exit(1)
 	}
 
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
 	// Delete logs by default (unless --without-logs is specified)
 	deleteLogs := !*withoutLogs
 
 	if *lambdaFlag != "" {
 		// Delete a single function
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		logGroupRepo := repository.NewLogGroupRepository(awsClient.Logs)
-		deleteUseCase := usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, os.Stdout)
+		af.run(ctx, "Failed to delete function", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+			if err != nil {
+				return err
+			}
+			deleteUseCase := usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, out)
+			guard, err := pf.newGuard(ctx, awsClient)
+			if err != nil {
+				return err
+			}
 
-		input := &usecase.DeleteFunctionInput{
-			FunctionName: *lambdaFlag,
-			DetachVPC:    true,
-			DisableIPv6:  true,
-			DeleteLogs:   deleteLogs,
+			input := &usecase.DeleteFunctionInput{
+				FunctionName:      *lambdaFlag,
+				DetachVPC:         true,
+				DisableIPv6:       true,
+				DeleteLogs:        deleteLogs,
+				Guard:             guard,
+				DryRun:            pf.dryRun,
+				LogsRetentionDays: logsRetentionDays,
+			}
+
+			if err := deleteUseCase.Execute(ctx, input); err != nil {
+				return err
+			}
+
+			if pf.dryRun {
+				return nil
+			}
+			fmt.Fprintf(out, "\nSuccessfully deleted function %s\n", *lambdaFlag)
+			return nil
+		}))
+	} else {
+		// Delete all functions in the selected stacks, recording progress so an interrupted run can be resumed
+		if !pf.dryRun {
+			if err := checkpoints.open(time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open checkpoint: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
-		if err := deleteUseCase.Execute(ctx, input); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to delete function: %v\n", err)
-			os.Exit(1)
+		af.run(ctx, "Failed to delete stack functions", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+			functionRepo, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+			if err != nil {
+				return err
+			}
+			stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+			deleteStackUseCase := usecase.NewDeleteStackFunctionsUseCase(functionRepo, logGroupRepo, stackRepo, out)
+			guard, err := pf.newGuard(ctx, awsClient)
+			if err != nil {
+				return err
+			}
+			var tracker *usecase.CheckpointTracker
+			if !pf.dryRun {
+				tracker, err = checkpoints.tracker(ctx, awsClient)
+				if err != nil {
+					return err
+				}
+			}
+
+			return runForStacks(ctx, stackRepo, stackSel, out, func(stackName string) error {
+				if err := checkStackStatus(ctx, stackRepo, stackName, *waitStable, *waitTimeout, out); err != nil {
+					return err
+				}
+
+				input := &usecase.DeleteStackFunctionsInput{
+					StackName:         stackName,
+					DetachVPC:         true,
+					DisableIPv6:       true,
+					DeleteLogs:        deleteLogs,
+					Guard:             guard,
+					DryRun:            pf.dryRun,
+					Checkpoint:        tracker,
+					LogsRetentionDays: logsRetentionDays,
+				}
+
+				if err := deleteStackUseCase.Execute(ctx, input); err != nil {
+					return err
+				}
+
+				if pf.dryRun {
+					return nil
+				}
+				fmt.Fprintf(out, "\nSuccessfully deleted all functions in stack %s\n", stackName)
+				return nil
+			})
+		}))
+
+		// Every target succeeded, so there is nothing left to resume
+		if !pf.dryRun {
+			if err := checkpoints.finish(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
 		}
+	}
+}
 
-		fmt.Printf("\nSuccessfully deleted function %s\n", *lambdaFlag)
-	} else {
-		// Delete all functions in a stack
-		functionRepo := repository.NewFunctionRepository(awsClient.Lambda)
-		logGroupRepo := repository.NewLogGroupRepository(awsClient.Logs)
+func handleDeleteStack(settings config.Settings) {
+	fs := flag.NewFlagSet("delete-stack", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	reports := newReportFlags(fs)
+	stackFlag := fs.String("stack", "", "CloudFormation stack name")
+	waitStable := fs.Bool("wait-stable", false, "Wait for an in-progress stack operation to finish instead of refusing")
+	waitTimeout := fs.Duration("wait-timeout", waitTimeoutDefault(settings), "Maximum time to wait for the stack to become stable and for the deletion to complete")
+	fs.Parse(os.Args[2:])
+
+	// Validate flags
+	if *stackFlag == "" {
+		fmt.Fprintln(os.Stderr, "Error: --stack must be specified")
+		fmt.Fprintln(os.Stderr, "Usage: delambda delete-stack --stack <stack-name>")
+		os.Exit(1)
+	}
+
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to delete stack", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		functionRepo, _, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+		if err != nil {
+			return err
+		}
 		stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
-		deleteStackUseCase := usecase.NewDeleteStackFunctionsUseCase(functionRepo, logGroupRepo, stackRepo, os.Stdout)
+		if err := checkStackStatus(ctx, stackRepo, *stackFlag, *waitStable, *waitTimeout, out); err != nil {
+			return err
+		}
+		deleteStackUseCase := usecase.NewDeleteStackUseCase(functionRepo, stackRepo, out)
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
 
-		input := &usecase.DeleteStackFunctionsInput{
-			StackName:   *stackFlag,
-			DetachVPC:   true,
-			DisableIPv6: true,
-			DeleteLogs:  deleteLogs,
+		input := &usecase.DeleteStackInput{
+			StackName:    *stackFlag,
+			DisableIPv6:  true,
+			Timeout:      *waitTimeout,
+			PollInterval: pollInterval(awsClient),
+			Guard:        guard,
+			DryRun:       pf.dryRun,
 		}
 
 		if err := deleteStackUseCase.Execute(ctx, input); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to delete stack functions: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
-		fmt.Printf("\nSuccessfully deleted all functions in stack %s\n", *stackFlag)
+		if pf.dryRun {
+			return nil
+		}
+		fmt.Fprintf(out, "\nSuccessfully deleted stack %s\n", *stackFlag)
+		return nil
+	}))
+}
+
+// checkStackStatus verifies that the stack exists and is stable before its functions are modified
+func checkStackStatus(ctx context.Context, stackRepo stack.Repository, stackName string, waitStable bool, waitTimeout time.Duration, out io.Writer) error {
+	checkStackStatusUseCase := usecase.NewCheckStackStatusUseCase(stackRepo, out)
+
+	input := &usecase.CheckStackStatusInput{
+		StackName:  stackName,
+		WaitStable: waitStable,
+		Timeout:    waitTimeout,
+	}
+
+	if _, err := checkStackStatusUseCase.Execute(ctx, input); err != nil {
+		if errors.Is(err, stack.ErrNotFound) {
+			return fmt.Errorf("stack %s does not exist", stackName)
+		}
+		return fmt.Errorf("stack preflight failed: %w", err)
 	}
+	return nil
 }
 
-func handleDeleteLogs(region, profile *string) {
-	fs := flag.NewFlagSet("delete-logs", flag.ExitOnError)
-	regionFlag := fs.String("region", *region, "AWS region")
-	profileFlag := fs.String("profile", *profile, "AWS profile")
+func handleHistory(settings config.Settings) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	audits := newAuditFlags(fs, settings)
+	functionFlag := fs.String("function", "", "Only show records for this function (glob pattern)")
+	stackFlag := fs.String("stack", "", "Only show records for functions created by this stack (glob pattern)")
+	sinceFlag := fs.String("since", "", "Only show records at or after this time (RFC 3339, YYYY-MM-DD, or a duration such as 7d)")
+	untilFlag := fs.String("until", "", "Only show records before this time (RFC 3339, YYYY-MM-DD inclusive, or a duration such as 1d)")
+	output := fs.String("output", outputDefault(settings), "Output format: text or json (one JSON record per line)")
 	fs.Parse(os.Args[2:])
 
-	args := fs.Args()
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Log group name is required")
-		fmt.Fprintln(os.Stderr, "Usage: delambda delete-logs <log-group-name>")
+	if *output != config.OutputText && *output != config.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s\n", config.OutputText, config.OutputJSON)
 		os.Exit(1)
 	}
 
-	logGroupName := args[0]
-
-	ctx := context.Background()
-	awsClient, err := client.NewAWSClient(ctx, *regionFlag, *profileFlag)
+	now := time.Now()
+	since, err := parseTimeFlag(*sinceFlag, now, false)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create AWS client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: --since: %v\n", err)
+		os.Exit(1)
+	}
+	until, err := parseTimeFlag(*untilFlag, now, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --until: %v\n", err)
 		os.Exit(1)
 	}
 
-	logGroupRepo := repository.NewLogGroupRepository(awsClient.Logs)
-	deleteLogsUseCase := usecase.NewDeleteLogGroupUseCase(logGroupRepo)
+	journal, err := audits.repository()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open audit log: %v\n", err)
+		os.Exit(1)
+	}
 
-	if err := deleteLogsUseCase.Execute(ctx, logGroupName); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to delete log group: %v\n", err)
+	historyUseCase := usecase.NewAuditHistoryUseCase(journal)
+	records, err := historyUseCase.Execute(context.Background(), &audit.Filter{
+		Function: *functionFlag,
+		Stack:    *stackFlag,
+		Since:    since,
+		Until:    until,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to query audit log: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Successfully deleted log group %s\n", logGroupName)
+	if *output == config.OutputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to encode record: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("No matching records in %s\n", journal.Path())
+		return
+	}
+
+	fmt.Printf("Found %d record(s) in %s:\n\n", len(records), journal.Path())
+	for _, record := range records {
+		printAuditRecord(os.Stdout, record)
+	}
+}
+
+// printAuditRecord prints a single audit record for the history command
+func printAuditRecord(out io.Writer, r *audit.Record) {
+	marker := "✓"
+	if r.Outcome != audit.OutcomeSuccess {
+		marker = "❌"
+	}
+	fmt.Fprintf(out, "%s %s  %-15s %s\n", marker, r.Timestamp.Local().Format(time.DateTime), r.Action, r.Target)
+
+	where := fmt.Sprintf("account %s, %s", r.Account, r.Region)
+	if r.Stack != "" {
+		where += ", stack " + r.Stack
+	}
+	fmt.Fprintf(out, "    by %s (%s)\n", r.Caller, where)
+
+	if r.Before != nil {
+		fmt.Fprintf(out, "    before: %s\n", describeAuditState(r.Before))
+	}
+	if r.RetentionDays > 0 {
+		fmt.Fprintf(out, "    retention: %d days\n", r.RetentionDays)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(out, "    error: %s\n", r.Error)
+	}
+}
+
+// describeAuditState returns a one-line description of a recorded before-state
+func describeAuditState(state *audit.State) string {
+	if !state.Exists {
+		return "did not exist"
+	}
+	if state.Runtime == "" && state.State == "" {
+		return "existed"
+	}
+	desc := fmt.Sprintf("runtime %s, state %s", state.Runtime, state.State)
+	if state.VPC == nil {
+		return desc + ", no VPC"
+	}
+	desc += fmt.Sprintf(", VPC %s (subnets %s; security groups %s)",
+		state.VPC.VPCId, strings.Join(state.VPC.SubnetIds, ","), strings.Join(state.VPC.SecurityGroupIds, ","))
+	if state.VPC.IPv6 {
+		desc += ", IPv6 enabled"
+	}
+	return desc
 }
 
 func printUsage() {
@@ -280,12 +754,48 @@ Commands:
   list                 List all Lambda functions with VPC status
   detach               Detach VPC from a Lambda function
   delete               Delete a Lambda function
-  delete-logs          Delete a CloudWatch Logs log group
+  delete-stack         Detach VPCs, then delete a CloudFormation stack and wait for completion
+  delete-logs          Delete CloudWatch Logs log groups by name, function, prefix or from stdin
+  history              Show the audit log of changes made by delambda
+  benchmark            Delete a stack and measure every phase, with or without detaching VPCs first
+  select               Pick functions in a terminal UI, then detach or delete them
+  stale                List functions without changes or invocations for a number of days
+  gc logs              Delete the log groups of Lambda functions that no longer exist
+  logs retention       Set the retention of the log groups of a stack's functions
+  completion           Print the completion script for bash, zsh or fish
   help                 Show this help message
 
 Global Options:
+  --context name       Use a named context from the config file (also DELAMBDA_CONTEXT env var)
   --region string      AWS region (optional, uses default or AWS_REGION env var)
   --profile string     AWS profile (optional, uses default or AWS_PROFILE env var)
+  --regions string     Comma-separated regions to run against concurrently
+  --all-regions        Run against every region enabled for the account
+  --role-arn string    IAM role to assume (with --external-id, --role-session-name, --mfa-serial)
+  --accounts-file path Run against every account in the file, assuming --role-name in each
+  --endpoint-url url   Send all API calls to a custom endpoint such as LocalStack
+                       (also AWS_ENDPOINT_URL and AWS_ENDPOINT_URL_<SERVICE> env vars)
+  --concurrency int    Maximum accounts/regions processed at once (0 = all, also DELAMBDA_CONCURRENCY)
+  --max-retries int    Retries of throttled API calls and of changes conflicting with an update in progress (default 5)
+  --retry-mode mode    SDK retry mode: standard or adaptive (also AWS_RETRY_MODE)
+  --max-tps float      API calls per second per service and region, shared by all workers (default 10, 0 = unlimited)
+  --record file        Record the API calls and responses to a cassette file, with credentials scrubbed
+  --replay file        Serve the API calls from a cassette file written by --record instead of calling AWS
+
+Safety Options (detach, delete, delete-stack, delete-logs, benchmark, select, gc logs, logs retention):
+  --dry-run            Show what would be done and any protection rule violations
+  --break-glass        Allow changes in production accounts listed in the config file
+  --resume file        Resume an interrupted delete --stack from its checkpoint file
+
+Audit Log (detach, delete, delete-stack, delete-logs, benchmark, select, gc logs, logs retention, history):
+  --audit-log string   Audit log file (default ~/.local/state/delambda/audit.jsonl)
+
+Run Report (detach, delete, delete-stack, benchmark, select):
+  --report file        Write a report of every function, step, duration and error to a .md, .html or .csv file
+
+Configuration:
+  Defaults are read from ~/.config/delambda/config.yaml and a per-repo .delambda.yaml.
+  Flags override environment variables, which override the config files.
 
 Examples:
   # List all Lambda functions in the account and region
@@ -294,23 +804,89 @@ Examples:
   # List Lambda functions in a specific CloudFormation stack
   delambda list --stack my-stack
 
+  # List Lambda functions as JSON lines, using the prod-eu context from the config file
+  delambda list --output json --context prod-eu
+
   # Detach VPC from a single Lambda function
   delambda detach --lambda my-function
 
   # Detach VPC from all Lambda functions in a CloudFormation stack
   delambda detach --stack my-stack
 
+  # Pick functions with fuzzy search, then detach or delete them after confirming
+  delambda select
+  delambda delete --interactive --stack 'dev-*'
+
+  # List functions neither changed nor invoked for 90 days, then delete them
+  delambda stale --days 90
+  delambda stale --days 90 --output json | delambda delete --from-stdin --dry-run
+
+  # List the log groups of deleted functions with their size, then delete them after confirming
+  delambda gc logs
+
+  # Detach VPC from every Lambda function attached to a subnet (also --vpc, --security-group)
+  delambda detach --subnet subnet-0123456789abcdef0
+
   # Delete a Lambda function and its log group (VPC will be automatically detached if attached)
   delambda delete --lambda my-function
 
   # Delete a Lambda function without deleting its log group
   delambda delete --lambda my-function --without-logs
 
+  # Delete a Lambda function but keep its logs for 30 days
+  delambda delete --lambda my-function --logs-retention 30d
+
   # Delete all Lambda functions in a CloudFormation stack (including log groups)
   delambda delete --stack my-stack
 
-  # Delete CloudWatch Logs log group
-  delambda delete-logs /aws/lambda/my-function
+  # Preview a deletion, including any protection rule violations
+  delambda delete --stack my-stack --dry-run
+
+  # Detach VPCs from a stack's functions, delete the stack and stream its events
+  delambda delete-stack --stack my-stack
+
+  # Wait for an in-progress stack update to finish before detaching
+  delambda detach --stack my-stack --wait-stable
+
+  # Delete the functions in every stack matching a pattern and/or carrying a tag
+  delambda delete --stack 'pr-123-*'
+  delambda delete --stack-tag env=preview
+
+  # List functions in several regions at once, or in every enabled region
+  delambda list --regions us-east-1,eu-west-1
+  delambda detach --vpc vpc-0123456789abcdef0 --all-regions
+
+  # Assume a role, or run against many accounts assuming the same role in each
+  delambda list --role-arn arn:aws:iam::123456789012:role/Cleanup --external-id my-id
+  delambda detach --vpc vpc-0123456789abcdef0 --accounts-file accounts.txt --role-name Cleanup
+
+  # Resume an interrupted delete from the checkpoint file it printed
+  delambda delete --stack my-stack --resume ~/.local/state/delambda/checkpoints/delete-20260318-150405.json
+
+  # Write a report of a deletion to attach to a change ticket
+  delambda delete --stack my-stack --report CHG-1234.md
+
+  # Measure detach-then-delete of a stack end to end, or a direct deletion as the baseline
+  delambda benchmark --stack my-stack
+  delambda benchmark --stack my-stack-copy --baseline --output json
+
+  # Show who changed a function, or everything changed in a stack during the last week
+  delambda history --function my-function
+  delambda history --stack my-stack --since 7d
+
+  # Delete a CloudWatch Logs log group by its full name or by its function
+  delambda delete-logs --log-group /aws/lambda/my-function
+  delambda delete-logs --function my-function
+
+  # Delete every log group with a prefix after confirming, or those listed by gc logs
+  delambda delete-logs --prefix /aws/lambda/pr-123-
+  delambda gc logs --output json | delambda delete-logs --from-stdin --yes
+
+  # Keep the logs of a stack's functions for 30 days instead of forever
+  delambda logs retention --stack my-stack --days 30
+
+  # Enable shell completion, including function, stack and region names
+  source <(delambda completion bash)
 `
 	fmt.Print(usage)
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/protection"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// protectionFlags holds the protection rules from the config file together
+// with the --dry-run and --break-glass flags of a mutating command
+type protectionFlags struct {
+	rules      *protection.Rules
+	dryRun     bool
+	breakGlass bool
+}
+
+// newProtectionFlags registers --dry-run and --break-glass on fs
+func newProtectionFlags(fs *flag.FlagSet, settings config.Settings) *protectionFlags {
+	p := settings.Protection
+	pf := &protectionFlags{
+		rules: protection.NewRules(p.Tags, p.Names, p.Stacks, p.ProductionAccounts),
+	}
+	fs.BoolVar(&pf.dryRun, "dry-run", false, "Show what would be done and any protection rule violations without changing anything")
+	fs.BoolVar(&pf.breakGlass, "break-glass", false, "Allow changes in accounts listed as production accounts in the config file")
+	return pf
+}
+
+// newGuard creates the protection guard for the account of awsClient. The
+// account is only looked up when production accounts are configured.
+func (pf *protectionFlags) newGuard(ctx context.Context, awsClient *client.AWSClient) (*usecase.ProtectionGuard, error) {
+	var accountID string
+	if pf.rules.HasProductionAccounts() {
+		identity, err := awsClient.CallerIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		accountID = identity.Account
+	}
+	return usecase.NewProtectionGuard(pf.rules, accountID, pf.breakGlass), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/report"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// reportFlags records the functions and steps of every target of a command,
+// prints the step timings of each target and writes the --report file
+type reportFlags struct {
+	path string
+	repo *repository.ReportRepository
+
+	// quiet leaves the step timings out of the output, for commands that
+	// include them in structured output instead
+	quiet bool
+
+	// withoutTimings leaves the step timings out of the summary, set by --without-timings
+	withoutTimings bool
+
+	mu        sync.Mutex
+	report    *report.Report
+	recorders map[*client.AWSClient]*report.Recorder
+}
+
+// newReportFlags registers --report on fs
+func newReportFlags(fs *flag.FlagSet) *reportFlags {
+	r := &reportFlags{recorders: make(map[*client.AWSClient]*report.Recorder)}
+	fs.StringVar(&r.path, "report", "", "Write a report of the run to this file: Markdown (.md), HTML (.html) or CSV (.csv)")
+	fs.BoolVar(&r.withoutTimings, "without-timings", false, "Don't print the step timings at the end of the run")
+	return r
+}
+
+// open starts the report of a run, if --report is set, and writes it once af
+// has run against every target
+func (r *reportFlags) open(af *awsFlags, dryRun bool) error {
+	if r.path == "" {
+		return nil
+	}
+	repo, err := repository.NewReportRepository(r.path)
+	if err != nil {
+		return err
+	}
+	r.repo = repo
+	r.report = &report.Report{
+		Command:   strings.Join(append([]string{"delambda"}, os.Args[1:]...), " "),
+		StartedAt: time.Now(),
+		DryRun:    dryRun,
+	}
+	af.onFinish(r.save)
+	return nil
+}
+
+// action wraps action to record the run against each target, print its step
+// timings and add it to the report
+func (r *reportFlags) action(action targetAction) targetAction {
+	return func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		recorder := r.newRecorder(ctx, awsClient)
+		err := action(ctx, awsClient, out)
+
+		target := recorder.Finish(err)
+		if !r.quiet && !r.withoutTimings {
+			printStepTimings(out, target.StepTimings())
+		}
+		if r.report != nil {
+			r.mu.Lock()
+			r.report.Targets = append(r.report.Targets, target)
+			r.mu.Unlock()
+		}
+		return err
+	}
+}
+
+// newRecorder creates the recorder for the account and region of awsClient.
+// The caller is only identified for the report; one that cannot be identified
+// is reported without account and operator.
+func (r *reportFlags) newRecorder(ctx context.Context, awsClient *client.AWSClient) *report.Recorder {
+	var account, operator string
+	if r.report != nil {
+		if identity, err := awsClient.CallerIdentity(ctx); err == nil {
+			account = identity.Account
+			operator = identity.ARN
+		}
+	}
+	recorder := report.NewRecorder(account, awsClient.Config.Region, operator)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorders[awsClient] = recorder
+	return recorder
+}
+
+// recorder returns the recorder of the run against awsClient, nil outside of action
+func (r *reportFlags) recorder(awsClient *client.AWSClient) *report.Recorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recorders[awsClient]
+}
+
+// stepTimings returns the step timings recorded so far for awsClient
+func (r *reportFlags) stepTimings(awsClient *client.AWSClient) []report.StepTiming {
+	r.mu.Lock()
+	recorder, ok := r.recorders[awsClient]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return recorder.StepTimings()
+}
+
+// printStepTimings writes the percentiles of the duration of each step, if any step was performed
+func printStepTimings(out io.Writer, timings []report.StepTiming) {
+	if len(timings) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\n=== Step Timings ===\n")
+	fmt.Fprintf(out, "%-16s %6s %9s %9s %9s %9s\n", "Step", "Count", "p50", "p90", "p99", "Max")
+	for _, t := range timings {
+		fmt.Fprintf(out, "%-16s %6d %9s %9s %9s %9s\n", t.Action, t.Count,
+			formatStepDuration(t.P50), formatStepDuration(t.P90), formatStepDuration(t.P99), formatStepDuration(t.Max))
+	}
+}
+
+// formatStepDuration rounds a step duration for the timings table
+func formatStepDuration(d time.Duration) string {
+	if d >= time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// save writes the report. A failure is reported but does not change the outcome of the run.
+func (r *reportFlags) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.FinishedAt = time.Now()
+	// Concurrent targets finish in any order
+	slices.SortStableFunc(r.report.Targets, func(a, b *report.Target) int {
+		return cmp.Or(cmp.Compare(a.Account, b.Account), cmp.Compare(a.Region, b.Region))
+	})
+	if err := r.repo.Save(context.Background(), r.report); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write report: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote report to %s\n", r.repo.Path())
+}
@@ -0,0 +1,144 @@
+package main
+
+// Scenario tests replay CLI runs recorded against the CDK test stack and
+// compare the output with the recorded output. Record them after deploying a
+// fresh stack, since some scenarios delete its functions:
+//
+//	make deploy
+//	DELAMBDA_RECORD_SCENARIOS=1 go test ./cmd/delambda -run TestScenario -v -count=1
+//
+// The committed cassettes are synthetic: they were recorded with
+// AWS_ENDPOINT_URL pointing at a local stand-in for the stack, with the hosts
+// rewritten to the regional endpoints and zeroed request IDs, not from real
+// AWS traffic. They pin down the output of the CLI for well-behaved responses
+// only and do not cover:
+//
+//   - pagination: every list fits in a single page without NextToken or NextMarker
+//   - error shapes: every call succeeds, so no AWS error response is parsed
+//   - retries: no ResourceConflictException or throttling is returned while
+//     a function update is in progress, so nothing is retried
+//
+// Re-recording them against the deployed stack replaces them with real
+// traffic. A scenario without a cassette fails instead of being skipped.
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const (
+	recordScenariosEnv = "DELAMBDA_RECORD_SCENARIOS"
+	scenarioRegion     = "us-east-1"
+	scenarioDir        = "testdata/scenarios"
+)
+
+// scenarios run in order, since the later ones change the stack
+var scenarios = []struct {
+	name string
+	args []string
+}{
+	{name: "list-stack", args: []string{"list", "--stack", "TestInfrastructureStack"}},
+	{name: "delete-stack-dry-run", args: []string{"delete", "--stack", "TestInfrastructureStack", "--dry-run"}},
+	// One function with IPv6, one in a VPC without IPv6 and one without VPC
+	{name: "delete-stack-functions", args: []string{"delete", "--stack", "TestInfrastructureStack", "--without-logs"}},
+}
+
+// volatilePatterns match output that differs between runs, such as durations and timestamps
+var volatilePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\d{8}-\d{6}`), "<time>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(h|m|s|ms|µs|ns)(\d+(\.\d+)?(m|s|ms))*\b`), "<duration>"},
+	// Table columns are padded to the width of the duration
+	{regexp.MustCompile(` {2,}<duration>`), " <duration>"},
+}
+
+// normalizeOutput replaces the state directory and the volatile parts of output
+func normalizeOutput(output, stateDir string) string {
+	output = strings.ReplaceAll(output, stateDir, "<state>")
+	for _, v := range volatilePatterns {
+		output = v.pattern.ReplaceAllString(output, v.replacement)
+	}
+	return output
+}
+
+func TestScenario(t *testing.T) {
+	record := os.Getenv(recordScenariosEnv) != ""
+	binary := buildDelambda(t)
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			cassette := filepath.Join(scenarioDir, sc.name+".cassette.json")
+			golden := filepath.Join(scenarioDir, sc.name+".golden")
+			if !record {
+				if _, err := os.Stat(cassette); errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("%s has not been recorded, set %s to record it", sc.name, recordScenariosEnv)
+				}
+			}
+
+			stateDir := t.TempDir()
+			args := append([]string{}, sc.args...)
+			// A replay answers at once, so the local rate limit would only add waits that vary between runs
+			args = append(args, "--region", scenarioRegion, "--max-tps", "0")
+			env := append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+			if record {
+				args = append(args, "--record", cassette)
+			} else {
+				// A replay must not depend on the local AWS or delambda configuration
+				env = slices.DeleteFunc(env, func(v string) bool {
+					return strings.HasPrefix(v, "AWS_") || strings.HasPrefix(v, "DELAMBDA_")
+				})
+				home := t.TempDir()
+				env = append(env, "HOME="+home, "XDG_CONFIG_HOME="+home, "AWS_CONFIG_FILE="+filepath.Join(home, "aws-config"))
+				args = append(args, "--replay", cassette)
+			}
+
+			cmd := exec.Command(binary, args...)
+			cmd.Env = env
+			cmd.Stderr = os.Stderr
+			out, err := cmd.Output()
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				t.Fatalf("failed to run delambda: %v", err)
+			}
+			got := normalizeOutput(string(out), stateDir)
+
+			if record {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read recorded output: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("delambda %s output differs from the recording\n--- got ---\n%s\n--- want ---\n%s", strings.Join(sc.args, " "), got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	output := "Checkpoint: /tmp/state/delambda/checkpoints/delete-20250102-030405.json\n" +
+		"  ✓ Deleted function api (1.234s)\n" +
+		"Total time: 2m3.5s at 2025-01-02T03:04:05Z\n" +
+		"DeleteFunction        3     1.2ms  850µs\n"
+	want := "Checkpoint: <state>/delambda/checkpoints/delete-<time>.json\n" +
+		"  ✓ Deleted function api (<duration>)\n" +
+		"Total time: <duration> at <time>\n" +
+		"DeleteFunction        3 <duration> <duration>\n"
+
+	if got := normalizeOutput(output, "/tmp/state"); got != want {
+		t.Errorf("normalizeOutput() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/internal/tui"
+	"github.com/shirasu/delambda/pkg/client"
+	"golang.org/x/term"
+)
+
+const (
+	// selectDetach is the picker key that hands the selection to detach
+	selectDetach = 'd'
+	// selectDelete is the picker key that hands the selection to delete
+	selectDelete = 'x'
+)
+
+func handleSelect(settings config.Settings) {
+	fs := flag.NewFlagSet("select", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	pf := newProtectionFlags(fs, settings)
+	audits := newAuditFlags(fs, settings)
+	reports := newReportFlags(fs)
+	stackSel := newStackSelector(fs)
+	withoutLogs := fs.Bool("without-logs", !deleteLogsDefault(settings), "Don't delete CloudWatch logs when deleting (logs are deleted by default)")
+	fs.Parse(os.Args[2:])
+
+	deleteLogs := !*withoutLogs
+	runSelect(af, pf, audits, reports, stackSel, deleteLogs, 0, detachSelectAction(pf.dryRun), deleteSelectAction(pf.dryRun, deleteLogs, 0))
+}
+
+// detachSelectAction is the picker action handing the selection to detach
+func detachSelectAction(dryRun bool) tui.Action {
+	action := tui.Action{Key: selectDetach, Label: "detach VPC", Confirm: "Detach VPC from %d function(s)?"}
+	if dryRun {
+		action.Confirm = "[dry-run] Show what detaching VPC from %d function(s) would do?"
+	}
+	return action
+}
+
+// deleteSelectAction is the picker action handing the selection to delete
+func deleteSelectAction(dryRun, deleteLogs bool, logsRetentionDays int) tui.Action {
+	what := "%d function(s)"
+	if logsRetentionDays > 0 {
+		what += fmt.Sprintf(", keeping their logs for %d days,", logsRetentionDays)
+	} else if deleteLogs {
+		what += " and their log groups"
+	}
+	action := tui.Action{Key: selectDelete, Label: "delete", Confirm: "Delete " + what + "?"}
+	if dryRun {
+		action.Confirm = "[dry-run] Show what deleting " + what + " would do?"
+	}
+	return action
+}
+
+// runSelect lists the functions of the account and region, or of the selected
+// stacks, in the interactive picker and hands the confirmed selection to the
+// chosen action: detach or delete
+func runSelect(af *awsFlags, pf *protectionFlags, audits *auditFlags, reports *reportFlags, stackSel *stackSelector, deleteLogs bool, logsRetentionDays int, actions ...tui.Action) {
+	if af.isFanOut() {
+		fmt.Fprintln(os.Stderr, "Error: the picker runs against a single account and region: --regions, --all-regions and --accounts-file cannot be used")
+		os.Exit(1)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stderr.Fd())) {
+		fmt.Fprintln(os.Stderr, "Error: the picker needs an interactive terminal")
+		os.Exit(1)
+	}
+
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to act on the selected functions", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		functions, err := selectableFunctions(ctx, awsClient, stackSel)
+		if err != nil {
+			return err
+		}
+		if len(functions) == 0 {
+			fmt.Fprintln(out, "No Lambda functions found")
+			return nil
+		}
+
+		items := make([]tui.Item, 0, len(functions))
+		for _, sf := range functions {
+			items = append(items, newPickerItem(sf))
+		}
+		// The picker draws on stderr, leaving stdout to the output of the action
+		result, err := tui.Run(os.Stdin, os.Stderr, tui.NewPicker(items, actions))
+		if errors.Is(err, tui.ErrCanceled) {
+			fmt.Fprintln(out, "Selection canceled, nothing was changed")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(result.Selected))
+		for _, i := range result.Selected {
+			names = append(names, functions[i].Function.Name())
+		}
+
+		functionRepo, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+		if err != nil {
+			return err
+		}
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+
+		var act func(name string) error
+		switch result.Action.Key {
+		case selectDetach:
+			detachVPCUseCase := usecase.NewDetachVPCUseCase(functionRepo, out)
+			act = func(name string) error {
+				return detachVPCUseCase.Execute(ctx, &usecase.DetachVPCInput{
+					FunctionName: name,
+					DisableIPv6:  true,
+					Guard:        guard,
+					DryRun:       pf.dryRun,
+				})
+			}
+		default:
+			deleteUseCase := usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, out)
+			act = func(name string) error {
+				return deleteUseCase.Execute(ctx, &usecase.DeleteFunctionInput{
+					FunctionName:      name,
+					DetachVPC:         true,
+					DisableIPv6:       true,
+					DeleteLogs:        deleteLogs,
+					Guard:             guard,
+					DryRun:            pf.dryRun,
+					LogsRetentionDays: logsRetentionDays,
+				})
+			}
+		}
+		return runForFunctions(out, names, act)
+	}))
+}
+
+// selectableFunctions returns the functions offered by the picker: every
+// function of the account and region, or those of the selected stacks
+func selectableFunctions(ctx context.Context, awsClient *client.AWSClient, stackSel *stackSelector) ([]*usecase.StackFunction, error) {
+	functionRepo := newFunctionRepository(awsClient)
+
+	if !stackSel.isSet() {
+		functions, err := usecase.NewListFunctionsUseCase(functionRepo).Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stackFunctions := make([]*usecase.StackFunction, 0, len(functions))
+		for _, fn := range functions {
+			stackFunctions = append(stackFunctions, &usecase.StackFunction{Function: fn})
+		}
+		return stackFunctions, nil
+	}
+
+	stackRepo := repository.NewStackRepository(awsClient.CloudFormation)
+	stackNames, err := stackSel.resolve(ctx, stackRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stacks: %w", err)
+	}
+	listStackUseCase := usecase.NewListStackFunctionsUseCase(functionRepo, stackRepo)
+	var stackFunctions []*usecase.StackFunction
+	for _, stackName := range stackNames {
+		functions, err := listStackUseCase.Execute(ctx, stackName)
+		if err != nil {
+			return nil, err
+		}
+		stackFunctions = append(stackFunctions, functions...)
+	}
+	return stackFunctions, nil
+}
+
+// newPickerItem shows a function in the picker with its VPC and IPv6
+// indicators and a preview of its configuration
+func newPickerItem(sf *usecase.StackFunction) tui.Item {
+	fn := sf.Function
+	vpc, ipv6 := "   ", "    "
+	if fn.IsAttachedToVPC() {
+		vpc = "VPC"
+	}
+	if fn.HasIPv6Enabled() {
+		ipv6 = "IPv6"
+	}
+
+	stackName := sf.StackPath
+	if stackName == "" {
+		stackName = fn.StackName()
+	}
+
+	preview := []string{
+		"Function: " + fn.Name(),
+		"Runtime:  " + string(fn.Runtime()),
+		"State:    " + string(fn.State()),
+	}
+	if stackName != "" {
+		preview = append(preview, "Stack:    "+stackName)
+	}
+	if fn.IsAttachedToVPC() {
+		vpcConfig := fn.VPCConfig()
+		ipv6Status := "disabled"
+		if fn.HasIPv6Enabled() {
+			ipv6Status = "enabled"
+		}
+		preview = append(preview, "", "VPC:      "+vpcConfig.VPCId, "IPv6:     "+ipv6Status, "Subnets:")
+		for _, id := range vpcConfig.SubnetIds {
+			preview = append(preview, "  "+id)
+		}
+		preview = append(preview, "Security groups:")
+		for _, id := range vpcConfig.SecurityGroupIds {
+			preview = append(preview, "  "+id)
+		}
+	} else {
+		preview = append(preview, "", "VPC:      none")
+	}
+	if tags := fn.Tags(); len(tags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for key := range tags {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		preview = append(preview, "", "Tags:")
+		for _, key := range keys {
+			preview = append(preview, fmt.Sprintf("  %s=%s", key, tags[key]))
+		}
+	}
+
+	return tui.Item{
+		Label:   fn.Name(),
+		Detail:  stackName,
+		Badges:  []string{vpc, ipv6},
+		Preview: preview,
+	}
+}
+
+// runForFunctions runs action against every selected function, continuing
+// past failures, and writes a per-function summary to out
+func runForFunctions(out io.Writer, names []string, action func(name string) error) error {
+	failures := make(map[string]error)
+	for _, name := range names {
+		fmt.Fprintf(out, "\n########## Function: %s ##########\n", name)
+		if err := action(name); err != nil {
+			fmt.Fprintf(out, "❌ %v\n", err)
+			failures[name] = err
+		}
+	}
+
+	fmt.Fprintf(out, "\n=== Function Summary ===\n")
+	for _, name := range names {
+		if err, failed := failures[name]; failed {
+			fmt.Fprintf(out, "  ❌ %s: %v\n", name, err)
+		} else {
+			fmt.Fprintf(out, "  ✓ %s\n", name)
+		}
+	}
+	fmt.Fprintf(out, "Total functions: %d, succeeded: %d, failed: %d\n",
+		len(names), len(names)-len(failures), len(failures))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d function(s) failed", len(failures), len(names))
+	}
+	return nil
+}
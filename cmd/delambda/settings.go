@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/config"
+)
+
+// loadSettings loads the user and per-repo config files and resolves the
+// context selected by --context or DELAMBDA_CONTEXT. The returned settings are
+// used as flag defaults, so flags override them; environment variables are
+// applied on top of the config so they override it as well, except for the
+// region and profile of a context given with --context.
+func loadSettings(args []string) (config.Settings, error) {
+	userPath, err := config.UserPath()
+	if err != nil {
+		return config.Settings{}, err
+	}
+	var repoPath string
+	if wd, err := os.Getwd(); err == nil {
+		repoPath = config.FindRepoPath(wd)
+	}
+
+	cfg, err := config.Load(userPath, repoPath)
+	if err != nil {
+		return config.Settings{}, err
+	}
+
+	contextName := os.Getenv("DELAMBDA_CONTEXT")
+	var explicit config.Settings
+	if name, ok := findFlagValue(args, "context"); ok {
+		contextName = name
+		explicit = cfg.Contexts[name]
+	}
+
+	settings, err := cfg.Resolve(contextName)
+	if err != nil {
+		return config.Settings{}, err
+	}
+	if err := applyEnv(&settings, explicit); err != nil {
+		return config.Settings{}, err
+	}
+	return settings, nil
+}
+
+// applyEnv gives environment variables precedence over the config file.
+// The AWS variables are left for the SDK to read, so the config values are
+// cleared, unless they come from explicitContext, the context given with
+// --context: its region and profile belong with its role, and mixing them with
+// the environment would silently run against another account or region.
+func applyEnv(settings *config.Settings, explicitContext config.Settings) error {
+	if (os.Getenv("AWS_REGION") != "" || os.Getenv("AWS_DEFAULT_REGION") != "") && explicitContext.Region == "" {
+		settings.Region = ""
+	}
+	if os.Getenv("AWS_PROFILE") != "" && explicitContext.Profile == "" {
+		settings.Profile = ""
+	}
+	// The retry settings are always passed to the SDK explicitly, so its
+	// environment variables are applied here instead of being left to it
+	if maxAttempts := os.Getenv("AWS_MAX_ATTEMPTS"); maxAttempts != "" {
+		n, err := strconv.Atoi(maxAttempts)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid AWS_MAX_ATTEMPTS %q: must be a positive number", maxAttempts)
+		}
+		retries := n - 1
+		settings.MaxRetries = &retries
+	}
+	if retryMode := os.Getenv("AWS_RETRY_MODE"); retryMode != "" {
+		settings.RetryMode = retryMode
+	}
+	if auditLog := os.Getenv("DELAMBDA_AUDIT_LOG"); auditLog != "" {
+		settings.AuditLog = auditLog
+	}
+	if output := os.Getenv("DELAMBDA_OUTPUT"); output != "" {
+		settings.Output = output
+	}
+	if concurrency := os.Getenv("DELAMBDA_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil {
+			return fmt.Errorf("invalid DELAMBDA_CONCURRENCY: %w", err)
+		}
+		settings.Concurrency = n
+	}
+	if maxTPS := os.Getenv("DELAMBDA_MAX_TPS"); maxTPS != "" {
+		tps, err := strconv.ParseFloat(maxTPS, 64)
+		if err != nil {
+			return fmt.Errorf("invalid DELAMBDA_MAX_TPS: %w", err)
+		}
+		settings.MaxTPS = &tps
+	}
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid environment: %w", err)
+	}
+	return nil
+}
+
+// findFlagValue returns the value of -name/--name in args before the flags are
+// parsed, since the selected context provides the defaults of the other flags
+func findFlagValue(args []string, name string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if trimmed == arg {
+			continue
+		}
+		if trimmed == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if value, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// deleteLogsDefault returns whether log groups are deleted by default, which
+// is the case unless the config sets delete-logs: false
+func deleteLogsDefault(settings config.Settings) bool {
+	return settings.DeleteLogs == nil || *settings.DeleteLogs
+}
+
+// waitTimeoutDefault returns the --wait-timeout default, one hour unless configured
+func waitTimeoutDefault(settings config.Settings) time.Duration {
+	if settings.WaitTimeout > 0 {
+		return settings.WaitTimeout
+	}
+	return time.Hour
+}
+
+// outputDefault returns the --output default, text unless configured
+func outputDefault(settings config.Settings) string {
+	if settings.Output != "" {
+		return settings.Output
+	}
+	return config.OutputText
+}
+
+// maxRetriesDefault returns the --max-retries default, five unless configured
+func maxRetriesDefault(settings config.Settings) int {
+	if settings.MaxRetries != nil {
+		return *settings.MaxRetries
+	}
+	return 5
+}
+
+// retryModeDefault returns the --retry-mode default, standard unless configured
+func retryModeDefault(settings config.Settings) string {
+	if settings.RetryMode != "" {
+		return settings.RetryMode
+	}
+	return config.RetryModeStandard
+}
+
+// maxTPSDefault returns the --max-tps default, ten calls per second unless configured
+func maxTPSDefault(settings config.Settings) float64 {
+	if settings.MaxTPS != nil {
+		return *settings.MaxTPS
+	}
+	return 10
+}
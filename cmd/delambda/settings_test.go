@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shirasu/delambda/internal/config"
+)
+
+func TestFindFlagValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   string
+		wantOK bool
+	}{
+		{name: "double dash with separate value", args: []string{"--stack", "s", "--context", "prod"}, want: "prod", wantOK: true},
+		{name: "single dash with equals", args: []string{"-context=dev"}, want: "dev", wantOK: true},
+		{name: "not given", args: []string{"--stack", "context"}, wantOK: false},
+		{name: "after terminator", args: []string{"--", "--context", "prod"}, wantOK: false},
+		{name: "missing value", args: []string{"--context"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findFlagValue(tt.args, "context")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("findFlagValue() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		explicit config.Settings
+		want     config.Settings
+		wantErr  bool
+	}{
+		{
+			name: "config applies without env",
+			want: config.Settings{Region: "eu-west-1", Profile: "prod", Concurrency: 2, Output: "text"},
+		},
+		{
+			name: "AWS env overrides config",
+			env:  map[string]string{"AWS_DEFAULT_REGION": "us-east-1", "AWS_PROFILE": "dev"},
+			want: config.Settings{Concurrency: 2, Output: "text"},
+		},
+		{
+			name:     "explicit context wins over AWS env",
+			env:      map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "dev"},
+			explicit: config.Settings{Region: "eu-west-1", Profile: "prod"},
+			want:     config.Settings{Region: "eu-west-1", Profile: "prod", Concurrency: 2, Output: "text"},
+		},
+		{
+			name:     "AWS env overrides what the explicit context leaves unset",
+			env:      map[string]string{"AWS_REGION": "us-east-1"},
+			explicit: config.Settings{Profile: "prod"},
+			want:     config.Settings{Profile: "prod", Concurrency: 2, Output: "text"},
+		},
+		{
+			name: "delambda env overrides config",
+			env:  map[string]string{"DELAMBDA_OUTPUT": "json", "DELAMBDA_CONCURRENCY": "8"},
+			want: config.Settings{Region: "eu-west-1", Profile: "prod", Concurrency: 8, Output: "json"},
+		},
+		{
+			name: "AWS retry env overrides config",
+			env:  map[string]string{"AWS_MAX_ATTEMPTS": "10", "AWS_RETRY_MODE": "adaptive"},
+			want: config.Settings{Region: "eu-west-1", Profile: "prod", Concurrency: 2, Output: "text", MaxRetries: intPtr(9), RetryMode: "adaptive"},
+		},
+		{
+			name:    "invalid max attempts",
+			env:     map[string]string{"AWS_MAX_ATTEMPTS": "0"},
+			wantErr: true,
+		},
+		{
+			name:    "negative max TPS",
+			env:     map[string]string{"DELAMBDA_MAX_TPS": "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid output",
+			env:     map[string]string{"DELAMBDA_OUTPUT": "yaml"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid concurrency",
+			env:     map[string]string{"DELAMBDA_CONCURRENCY": "many"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE", "AWS_MAX_ATTEMPTS", "AWS_RETRY_MODE", "DELAMBDA_AUDIT_LOG", "DELAMBDA_OUTPUT", "DELAMBDA_CONCURRENCY", "DELAMBDA_MAX_TPS"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			settings := config.Settings{Region: "eu-west-1", Profile: "prod", Concurrency: 2, Output: "text"}
+			err := applyEnv(&settings, tt.explicit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(settings, tt.want) {
+				t.Errorf("applyEnv() = %+v, want %+v", settings, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// tagFlags collects repeated --stack-tag key=value flags
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	pairs := make([]string, 0, len(t))
+	for key, value := range t {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (t tagFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	t[key] = val
+	return nil
+}
+
+// stackSelector selects one stack by name, or several stacks by glob pattern and tags
+type stackSelector struct {
+	name string
+	tags tagFlags
+}
+
+// newStackSelector registers the --stack and --stack-tag flags on fs
+func newStackSelector(fs *flag.FlagSet) *stackSelector {
+	sel := &stackSelector{tags: tagFlags{}}
+	fs.StringVar(&sel.name, "stack", "", "CloudFormation stack name or glob pattern (e.g. 'pr-123-*')")
+	fs.Var(sel.tags, "stack-tag", "Select stacks with this tag (key=value, repeatable)")
+	return sel
+}
+
+// isSet checks if any stack selection flag was given
+func (s *stackSelector) isSet() bool {
+	return s.name != "" || len(s.tags) > 0
+}
+
+// isMulti checks if the selection may match more than one stack
+func (s *stackSelector) isMulti() bool {
+	return strings.ContainsAny(s.name, "*?[") || len(s.tags) > 0
+}
+
+// describe returns a human readable description of the selection
+func (s *stackSelector) describe() string {
+	var parts []string
+	if s.name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", s.name))
+	}
+	if len(s.tags) > 0 {
+		parts = append(parts, fmt.Sprintf("tags %s", s.tags))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// resolve returns the names of the selected stacks. A plain stack name is
+// returned as is, without listing the stacks in the account.
+func (s *stackSelector) resolve(ctx context.Context, stackRepo stack.Repository) ([]string, error) {
+	if !s.isMulti() {
+		return []string{s.name}, nil
+	}
+
+	findStacksUseCase := usecase.NewFindStacksUseCase(stackRepo)
+	stacks, err := findStacksUseCase.Execute(ctx, &usecase.FindStacksInput{
+		NamePattern: s.name,
+		Tags:        s.tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stacks) == 0 {
+		return nil, fmt.Errorf("no stacks found matching %s", s.describe())
+	}
+
+	names := make([]string, 0, len(stacks))
+	for _, st := range stacks {
+		names = append(names, st.Name())
+	}
+	return names, nil
+}
+
+// runForStacks runs action against every selected stack. With a single stack,
+// the action's error is returned as is; with multiple stacks, every stack is
+// processed and a per-stack summary is written to out.
+func runForStacks(ctx context.Context, stackRepo stack.Repository, sel *stackSelector, out io.Writer, action func(stackName string) error) error {
+	stackNames, err := sel.resolve(ctx, stackRepo)
+	if err != nil {
+		return fmt.Errorf("failed to resolve stacks: %w", err)
+	}
+
+	if !sel.isMulti() {
+		return action(stackNames[0])
+	}
+
+	fmt.Fprintf(out, "Matched %d stack(s) by %s\n", len(stackNames), sel.describe())
+
+	failures := make(map[string]error)
+	for _, stackName := range stackNames {
+		fmt.Fprintf(out, "\n########## Stack: %s ##########\n", stackName)
+		if err := action(stackName); err != nil {
+			fmt.Fprintf(out, "❌ %v\n", err)
+			failures[stackName] = err
+		}
+	}
+
+	fmt.Fprintf(out, "\n=== Stack Summary ===\n")
+	for _, stackName := range stackNames {
+		if err, failed := failures[stackName]; failed {
+			fmt.Fprintf(out, "  ❌ %s: %v\n", stackName, err)
+		} else {
+			fmt.Fprintf(out, "  ✓ %s\n", stackName)
+		}
+	}
+	fmt.Fprintf(out, "Total stacks: %d, succeeded: %d, failed: %d\n",
+		len(stackNames), len(stackNames)-len(failures), len(failures))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d stack(s) failed", len(failures), len(stackNames))
+	}
+	return nil
+}
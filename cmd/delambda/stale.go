@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// defaultStaleDays is how long a function must go unchanged and uninvoked to be stale by default
+const defaultStaleDays = 90
+
+// staleFunctionRecord is the JSON representation of a stale function, which
+// delete --from-stdin reads back
+type staleFunctionRecord struct {
+	functionRecord
+	LastModified *time.Time `json:"lastModified,omitempty"`
+}
+
+func handleStale(settings config.Settings) {
+	fs := flag.NewFlagSet("stale", flag.ExitOnError)
+	af := newAWSFlags(fs, settings)
+	days := fs.Int("days", defaultStaleDays, "List functions neither changed nor invoked for this many days")
+	output := fs.String("output", outputDefault(settings), "Output format: text or json (one JSON object per function and line, which delete --from-stdin accepts)")
+	fs.Parse(os.Args[2:])
+
+	if *days <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --days must be positive")
+		os.Exit(1)
+	}
+	if *output != config.OutputText && *output != config.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: --output must be %s or %s\n", config.OutputText, config.OutputJSON)
+		os.Exit(1)
+	}
+	af.structuredOutput = *output == config.OutputJSON
+
+	ctx := context.Background()
+	now := time.Now()
+	af.run(ctx, "Failed to find stale functions", func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		functionRepo := newFunctionRepository(awsClient)
+		invocationRepo := repository.NewInvocationRepository(awsClient.CloudWatch)
+		findStaleUseCase := usecase.NewFindStaleFunctionsUseCase(functionRepo, invocationRepo)
+
+		functions, err := findStaleUseCase.Execute(ctx, &usecase.FindStaleFunctionsInput{
+			Days: *days,
+			Now:  now,
+		})
+		if err != nil {
+			return err
+		}
+		target, err := newRecordTarget(ctx, awsClient, *output)
+		if err != nil {
+			return err
+		}
+		return printStaleFunctions(out, *output, target, *days, now, functions)
+	})
+}
+
+// printStaleFunctions prints the functions found by the stale command in the given output format
+func printStaleFunctions(out io.Writer, output string, target recordTarget, days int, now time.Time, functions []*function.Function) error {
+	if output == config.OutputJSON {
+		encoder := json.NewEncoder(out)
+		for _, fn := range functions {
+			record := staleFunctionRecord{functionRecord: newFunctionRecord(fn, fn.StackName(), target)}
+			if lastModified := fn.LastModified(); !lastModified.IsZero() {
+				lastModified = lastModified.UTC()
+				record.LastModified = &lastModified
+			}
+			if err := encoder.Encode(&record); err != nil {
+				return fmt.Errorf("failed to encode function: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(functions) == 0 {
+		fmt.Fprintf(out, "No Lambda functions without changes or invocations in the last %d days\n", days)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Found %d Lambda function(s) without changes or invocations in the last %d days:\n\n", len(functions), days)
+	for _, fn := range functions {
+		line := formatFunction(fn, fn.StackName())
+		if lastModified := fn.LastModified(); !lastModified.IsZero() {
+			line += fmt.Sprintf(" last modified %s (%d days ago)", lastModified.UTC().Format(time.DateOnly), int(now.Sub(lastModified).Hours()/24))
+		}
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// recordTarget is the account and region a JSON record was written for, which
+// --from-stdin checks against the account and region it runs against
+type recordTarget struct {
+	Account string
+	Region  string
+}
+
+// newRecordTarget returns the account and region of awsClient for the records
+// of output. The account is only looked up for JSON output.
+func newRecordTarget(ctx context.Context, awsClient *client.AWSClient, output string) (recordTarget, error) {
+	target := recordTarget{Region: awsClient.Config.Region}
+	if output != config.OutputJSON {
+		return target, nil
+	}
+	identity, err := awsClient.CallerIdentity(ctx)
+	if err != nil {
+		return recordTarget{}, err
+	}
+	target.Account = identity.Account
+	return target, nil
+}
+
+// stdinName is a name piped into a command, with the account and region of the
+// JSON record it came from, both empty for a plain name
+type stdinName struct {
+	name    string
+	account string
+	region  string
+}
+
+// readFunctionNames reads function names piped into a command, one per line.
+// A line is either a plain name or a JSON object with a name field, as written
+// by list and stale with --output json. Blank lines and lines starting with #
+// are skipped; any other line fails the whole input, so a text listing piped
+// by mistake is refused before anything is changed.
+func readFunctionNames(r io.Reader) ([]stdinName, error) {
+	return readNames(r, "name", "function name")
+}
+
+// readLogGroupNames reads log group names piped into a command, one per line,
+// like readFunctionNames. A JSON object names the log group in its logGroup
+// field, as written by gc logs --output json.
+func readLogGroupNames(r io.Reader) ([]stdinName, error) {
+	return readNames(r, "logGroup", "log group name")
+}
+
+// readNames reads the names of kind from r, each a plain name or a JSON object
+// with the name in field, skipping duplicates
+func readNames(r io.Reader, field, kind string) ([]stdinName, error) {
+	var names []stdinName
+	seen := make(map[stdinName]bool)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := stdinName{name: line}
+		if strings.HasPrefix(line, "{") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return nil, fmt.Errorf("line %d is not a JSON object with a %s: %q", lineNumber, field, line)
+			}
+			value, ok := record[field].(string)
+			if !ok || value == "" {
+				return nil, fmt.Errorf("line %d is not a JSON object with a %s: %q", lineNumber, field, line)
+			}
+			entry.name = value
+			entry.account, _ = record["account"].(string)
+			entry.region, _ = record["region"].(string)
+		} else if strings.ContainsAny(line, " \t") {
+			return nil, fmt.Errorf("line %d is not a %s: %q", lineNumber, kind, line)
+		}
+
+		if !seen[entry] {
+			seen[entry] = true
+			names = append(names, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %ss: %w", kind, err)
+	}
+	return names, nil
+}
+
+// selectNames returns the names piped into a command that runs against region
+// and the account returned by account, which is only looked up if a record
+// names one. A record written for another account or region fails the whole
+// input, so a name is never changed in an account or region it was not listed in.
+func selectNames(entries []stdinName, region string, account func() (string, error)) ([]string, error) {
+	var currentAccount string
+	var names []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.region != "" && entry.region != region {
+			return nil, fmt.Errorf("%s was listed in region %s, not %s; pass --region %s", entry.name, entry.region, region, entry.region)
+		}
+		if entry.account != "" {
+			if currentAccount == "" {
+				var err error
+				if currentAccount, err = account(); err != nil {
+					return nil, err
+				}
+			}
+			if entry.account != currentAccount {
+				return nil, fmt.Errorf("%s was listed in account %s, not %s", entry.name, entry.account, currentAccount)
+			}
+		}
+
+		if !seen[entry.name] {
+			seen[entry.name] = true
+			names = append(names, entry.name)
+		}
+	}
+	return names, nil
+}
+
+// selectNamesFor returns the names piped into a command for the account and region of awsClient
+func selectNamesFor(ctx context.Context, awsClient *client.AWSClient, entries []stdinName) ([]string, error) {
+	return selectNames(entries, awsClient.Config.Region, func() (string, error) {
+		identity, err := awsClient.CallerIdentity(ctx)
+		if err != nil {
+			return "", err
+		}
+		return identity.Account, nil
+	})
+}
+
+// deleteFunctionsFromStdin deletes the functions named on stdin, one after
+// another, followed by a per-function summary
+func deleteFunctionsFromStdin(af *awsFlags, pf *protectionFlags, audits *auditFlags, reports *reportFlags, deleteLogs bool, logsRetentionDays int) {
+	// The records name the account and region of each function, so they are
+	// checked against a single one instead of being applied to all of them
+	if af.isFanOut() {
+		fmt.Fprintln(os.Stderr, "Error: --from-stdin cannot be combined with --regions, --all-regions or --accounts-file")
+		os.Exit(1)
+	}
+
+	entries, err := readFunctionNames(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --from-stdin: %v\n", err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --from-stdin: no function names on stdin")
+		os.Exit(1)
+	}
+
+	if err := reports.open(af, pf.dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --report: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	af.run(ctx, "Failed to delete functions", reports.action(func(ctx context.Context, awsClient *client.AWSClient, out io.Writer) error {
+		names, err := selectNamesFor(ctx, awsClient, entries)
+		if err != nil {
+			return fmt.Errorf("--from-stdin: %w", err)
+		}
+		functionRepo, logGroupRepo, err := audits.repositories(ctx, awsClient, pf.dryRun, reports.recorder(awsClient))
+		if err != nil {
+			return err
+		}
+		deleteUseCase := usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, out)
+		guard, err := pf.newGuard(ctx, awsClient)
+		if err != nil {
+			return err
+		}
+
+		return runForFunctions(out, names, func(name string) error {
+			return deleteUseCase.Execute(ctx, &usecase.DeleteFunctionInput{
+				FunctionName:      name,
+				DetachVPC:         true,
+				DisableIPv6:       true,
+				DeleteLogs:        deleteLogs,
+				Guard:             guard,
+				DryRun:            pf.dryRun,
+				LogsRetentionDays: logsRetentionDays,
+			})
+		})
+	}))
+}
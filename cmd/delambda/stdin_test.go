@@ -0,0 +1,134 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadFunctionNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []stdinName
+		wantErr bool
+	}{
+		{
+			name:  "plain names",
+			input: "api\n\n# comment\n  worker  \napi\n",
+			want:  []stdinName{{name: "api"}, {name: "worker"}},
+		},
+		{
+			name: "JSON lines",
+			input: `{"name":"api","account":"111111111111","region":"us-east-1","ipv6":false}` + "\n" +
+				`{"name":"api","account":"222222222222","region":"us-east-1"}` + "\n" +
+				`{"name":"worker","stack":"app","lastModified":"2025-01-01T00:00:00Z"}` + "\n",
+			want: []stdinName{
+				{name: "api", account: "111111111111", region: "us-east-1"},
+				{name: "api", account: "222222222222", region: "us-east-1"},
+				{name: "worker"},
+			},
+		},
+		{
+			name:    "text listing",
+			input:   "Found 2 Lambda function(s):\n  - api [python3.12] No VPC\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSON without a name",
+			input:   `{"region":"us-east-1"}` + "\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFunctionNames(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readFunctionNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readFunctionNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectNames(t *testing.T) {
+	entries := []stdinName{
+		{name: "api", account: "111111111111", region: "us-east-1"},
+		{name: "api"},
+		{name: "worker", region: "us-east-1"},
+	}
+	tests := []struct {
+		name    string
+		entries []stdinName
+		region  string
+		account string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "matching account and region",
+			entries: entries,
+			region:  "us-east-1",
+			account: "111111111111",
+			want:    []string{"api", "worker"},
+		},
+		{
+			name:    "other region",
+			entries: entries,
+			region:  "eu-west-1",
+			account: "111111111111",
+			wantErr: true,
+		},
+		{
+			name:    "other account",
+			entries: entries,
+			region:  "us-east-1",
+			account: "222222222222",
+			wantErr: true,
+		},
+		{
+			name:    "plain names in any account and region",
+			entries: []stdinName{{name: "api"}},
+			region:  "eu-west-1",
+			want:    []string{"api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups := 0
+			got, err := selectNames(tt.entries, tt.region, func() (string, error) {
+				lookups++
+				return tt.account, nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selectNames() = %v, want %v", got, tt.want)
+			}
+			if lookups > 1 {
+				t.Errorf("selectNames() looked up the account %d times, want at most once", lookups)
+			}
+		})
+	}
+}
+
+func TestReadLogGroupNames(t *testing.T) {
+	input := "/aws/lambda/api\n" + `{"logGroup":"/aws/lambda/us-east-1.edge","function":"edge","account":"111111111111","region":"us-east-1","storedBytes":10}` + "\n"
+	got, err := readLogGroupNames(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readLogGroupNames() error = %v", err)
+	}
+	want := []stdinName{{name: "/aws/lambda/api"}, {name: "/aws/lambda/us-east-1.edge", account: "111111111111", region: "us-east-1"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("readLogGroupNames() = %v, want %v", got, want)
+	}
+
+	if _, err := readLogGroupNames(strings.NewReader(`{"name":"api"}` + "\n")); err == nil {
+		t.Error("readLogGroupNames() accepted JSON without a logGroup")
+	}
+}
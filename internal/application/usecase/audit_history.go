@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+// AuditHistoryUseCase handles querying the audit log
+type AuditHistoryUseCase struct {
+	auditRepo audit.Repository
+}
+
+// NewAuditHistoryUseCase creates a new AuditHistoryUseCase
+func NewAuditHistoryUseCase(auditRepo audit.Repository) *AuditHistoryUseCase {
+	return &AuditHistoryUseCase{
+		auditRepo: auditRepo,
+	}
+}
+
+// Execute returns the audit records matching filter, oldest first
+func (uc *AuditHistoryUseCase) Execute(ctx context.Context, filter *audit.Filter) ([]*audit.Record, error) {
+	records, err := uc.auditRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return filter.Apply(records), nil
+}
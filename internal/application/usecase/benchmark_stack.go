@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/eni"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/report"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+const (
+	// BenchmarkModeDelambda detaches VPC from the functions before deleting the stack
+	BenchmarkModeDelambda = "delambda"
+	// BenchmarkModeBaseline deletes the stack directly, as without delambda
+	BenchmarkModeBaseline = "baseline"
+
+	// DefaultENITimeout is how long to wait for the network interfaces of the
+	// functions to be released if BenchmarkStackInput.ENITimeout is not set
+	DefaultENITimeout = 45 * time.Minute
+)
+
+// BenchmarkStackUseCase handles measuring the deletion of a stack end to end
+type BenchmarkStackUseCase struct {
+	deleteStack *DeleteStackUseCase
+	eniRepo     eni.Repository
+	output      io.Writer
+}
+
+// BenchmarkStackInput contains the input parameters for benchmarking a stack deletion
+type BenchmarkStackInput struct {
+	StackName string
+
+	// Baseline deletes the stack without detaching VPC first, to measure what delambda saves
+	Baseline bool
+
+	DisableIPv6  bool
+	PollInterval time.Duration
+
+	// Timeout is the maximum time to wait for the stack deletion
+	Timeout time.Duration
+
+	// ENITimeout is the maximum time to wait for the network interfaces of the
+	// functions to be released, counted from the start of the run
+	ENITimeout time.Duration
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
+}
+
+// BenchmarkResult is the outcome of a benchmark run
+type BenchmarkResult struct {
+	Stack        string
+	Mode         string
+	StartedAt    time.Time
+	Functions    int
+	VPCFunctions int
+
+	// Detach is the time spent detaching VPC, zero for a baseline run
+	Detach        time.Duration
+	StackDeletion time.Duration
+	Total         time.Duration
+
+	// ENIRelease is the time until the network interfaces of each function in
+	// a VPC were released, counted from the end of the VPC detach phase, or
+	// from the start of the stack deletion for a baseline run
+	ENIRelease report.Percentiles
+
+	// ENIsRemaining is how many functions still had network interfaces when
+	// the wait for their release timed out
+	ENIsRemaining int
+}
+
+// NewBenchmarkStackUseCase creates a new BenchmarkStackUseCase
+func NewBenchmarkStackUseCase(functionRepo function.Repository, stackRepo stack.Repository, eniRepo eni.Repository, output io.Writer) *BenchmarkStackUseCase {
+	return &BenchmarkStackUseCase{
+		deleteStack: NewDeleteStackUseCase(functionRepo, stackRepo, output),
+		eniRepo:     eniRepo,
+		output:      output,
+	}
+}
+
+// Execute deletes the stack, detaching VPC first unless it is a baseline run,
+// and measures every phase including the release of the network interfaces.
+// A dry run returns a nil result.
+func (uc *BenchmarkStackUseCase) Execute(ctx context.Context, input *BenchmarkStackInput) (*BenchmarkResult, error) {
+	result := &BenchmarkResult{
+		Stack:     input.StackName,
+		Mode:      BenchmarkModeDelambda,
+		StartedAt: time.Now(),
+	}
+	if input.Baseline {
+		result.Mode = BenchmarkModeBaseline
+	}
+	eniTimeout := input.ENITimeout
+	if eniTimeout <= 0 {
+		eniTimeout = DefaultENITimeout
+	}
+
+	deleteInput := &DeleteStackInput{
+		StackName:    input.StackName,
+		DisableIPv6:  input.DisableIPv6,
+		PollInterval: input.PollInterval,
+		Timeout:      input.Timeout,
+		Guard:        input.Guard,
+		DryRun:       input.DryRun,
+	}
+	st, functions, err := uc.deleteStack.prepare(ctx, deleteInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var vpcFunctions []*function.Function
+	for _, r := range functions {
+		if r.function != nil && r.function.IsAttachedToVPC() {
+			vpcFunctions = append(vpcFunctions, r.function)
+		}
+	}
+	result.Functions = len(functions)
+	result.VPCFunctions = len(vpcFunctions)
+
+	fmt.Fprintf(uc.output, "Benchmarking %s deletion of stack %s: %d function(s), %d in a VPC\n", result.Mode, st.Name(), len(functions), len(vpcFunctions))
+
+	if input.Baseline {
+		fmt.Fprintf(uc.output, "\n=== Phase 1: Detach VPC (skipped for the baseline) ===\n")
+	} else {
+		fmt.Fprintf(uc.output, "\n=== Phase 1: Detach VPC ===\n")
+		detachStart := time.Now()
+		if err := uc.deleteStack.detachFunctions(ctx, deleteInput, functions); err != nil {
+			return nil, err
+		}
+		result.Detach = time.Since(detachStart)
+	}
+
+	if input.DryRun {
+		fmt.Fprintf(uc.output, "\n=== Phase 2: Delete stack ===\n")
+		fmt.Fprintf(uc.output, "  [dry-run] Would delete stack %s, wait up to %s for completion and up to %s for the network interfaces to be released\n",
+			st.Name(), stackWaitTimeout(deleteInput), eniTimeout)
+		printDryRunFooter(uc.output)
+		return nil, nil
+	}
+
+	// The network interfaces are released in the background while the stack is deleted
+	watchCtx, cancel := context.WithDeadline(ctx, result.StartedAt.Add(eniTimeout))
+	defer cancel()
+	released := make(chan eniReleaseResult, 1)
+	go func() {
+		released <- uc.watchENIs(watchCtx, vpcFunctions, time.Now(), deleteInput.PollInterval)
+	}()
+
+	fmt.Fprintf(uc.output, "\n=== Phase 2: Delete stack ===\n")
+	deleteStart := time.Now()
+	if err := uc.deleteStack.deleteStack(ctx, deleteInput, st); err != nil {
+		return nil, err
+	}
+	result.StackDeletion = time.Since(deleteStart)
+
+	if len(vpcFunctions) > 0 {
+		fmt.Fprintf(uc.output, "\n=== Phase 3: Wait for network interface release ===\n")
+	}
+	eniResult := <-released
+	var durations []time.Duration
+	for _, r := range eniResult.released {
+		fmt.Fprintf(uc.output, "  Network interfaces of %s released after %s\n", r.functionName, r.duration.Round(time.Second))
+		durations = append(durations, r.duration)
+	}
+	if eniResult.err != nil {
+		fmt.Fprintf(uc.output, "Warning: failed to check network interfaces: %v\n", eniResult.err)
+	}
+	result.ENIRelease = report.NewPercentiles(durations)
+	result.ENIsRemaining = eniResult.remaining
+	result.Total = time.Since(result.StartedAt)
+
+	printBenchmarkResult(uc.output, result)
+	return result, nil
+}
+
+// eniRelease is when the network interfaces of a function were found to be released
+type eniRelease struct {
+	functionName string
+	duration     time.Duration
+}
+
+// eniReleaseResult is the outcome of waiting for network interfaces to be released
+type eniReleaseResult struct {
+	released  []eniRelease
+	remaining int
+	err       error
+}
+
+// watchENIs polls the network interfaces of the functions until every function
+// has none left or ctx is done, and returns how long each took from start.
+// Lambda shares a network interface between the functions with the same subnet
+// and security groups, so they are looked up once per VPC configuration and a
+// shared one holds up every function using it.
+// It runs alongside the stack deletion, so it does not write to the output.
+func (uc *BenchmarkStackUseCase) watchENIs(ctx context.Context, functions []*function.Function, start time.Time, pollInterval time.Duration) eniReleaseResult {
+	if pollInterval <= 0 {
+		pollInterval = defaultStackPollInterval
+	}
+
+	pending := make(map[string]bool, len(functions))
+	for _, fn := range functions {
+		pending[fn.Name()] = true
+	}
+
+	var result eniReleaseResult
+	for len(pending) > 0 {
+		// Every function released in a poll is counted as released when it started
+		elapsed := time.Since(start)
+		inUse := make(map[string]bool)
+		for _, fn := range functions {
+			if !pending[fn.Name()] {
+				continue
+			}
+			vpcConfig := fn.VPCConfig()
+			network := networkKey(vpcConfig)
+			used, checked := inUse[network]
+			if !checked {
+				interfaces, err := uc.eniRepo.FindByNetwork(ctx, vpcConfig.SubnetIds, vpcConfig.SecurityGroupIds)
+				if err != nil {
+					if ctx.Err() == nil {
+						result.err = err
+					}
+					result.remaining = len(pending)
+					return result
+				}
+				used = len(interfaces) > 0
+				inUse[network] = used
+			}
+			if !used {
+				delete(pending, fn.Name())
+				result.released = append(result.released, eniRelease{functionName: fn.Name(), duration: elapsed})
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			result.remaining = len(pending)
+			return result
+		case <-time.After(pollInterval):
+		}
+	}
+	return result
+}
+
+// networkKey identifies the subnets and security groups of a VPC configuration, in any order
+func networkKey(vpcConfig *function.VPCConfig) string {
+	subnetIDs := slices.Sorted(slices.Values(vpcConfig.SubnetIds))
+	securityGroupIDs := slices.Sorted(slices.Values(vpcConfig.SecurityGroupIds))
+	return strings.Join(subnetIDs, ",") + "/" + strings.Join(securityGroupIDs, ",")
+}
+
+// printBenchmarkResult writes the summary of a benchmark run
+func printBenchmarkResult(output io.Writer, result *BenchmarkResult) {
+	fmt.Fprintf(output, "\n=== Benchmark (%s) ===\n", result.Mode)
+	fmt.Fprintf(output, "Stack:          %s\n", result.Stack)
+	fmt.Fprintf(output, "Functions:      %d (%d in a VPC)\n", result.Functions, result.VPCFunctions)
+	if result.Mode != BenchmarkModeBaseline {
+		fmt.Fprintf(output, "VPC detach:     %s\n", result.Detach.Round(time.Second))
+	}
+	fmt.Fprintf(output, "Stack deletion: %s\n", result.StackDeletion.Round(time.Second))
+	fmt.Fprintf(output, "Total:          %s\n", result.Total.Round(time.Second))
+	if result.ENIRelease.Count > 0 {
+		fmt.Fprintf(output, "ENI release:    p50 %s, p90 %s, p99 %s, max %s (%d function(s))\n",
+			result.ENIRelease.P50.Round(time.Second), result.ENIRelease.P90.Round(time.Second),
+			result.ENIRelease.P99.Round(time.Second), result.ENIRelease.Max.Round(time.Second), result.ENIRelease.Count)
+	}
+	if result.ENIsRemaining > 0 {
+		fmt.Fprintf(output, "ENI release:    %d function(s) still had network interfaces when the wait timed out\n", result.ENIsRemaining)
+	}
+}
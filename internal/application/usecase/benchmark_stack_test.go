@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/shirasu/delambda/internal/domain/eni"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// deletingStackRepository lists the given functions and completes a deletion on the next poll
+type deletingStackRepository struct {
+	fakeStackRepository
+	deleted bool
+}
+
+func (r *deletingStackRepository) FindByName(ctx context.Context, stackName string) (*stack.Stack, error) {
+	status := types.StackStatusCreateComplete
+	if r.deleted {
+		status = types.StackStatusDeleteComplete
+	}
+	return stack.NewStack("arn:aws:cloudformation:us-east-1:123456789012:stack/app/1", "app", status, "", nil), nil
+}
+
+func (r *deletingStackRepository) Delete(ctx context.Context, stackName string) error {
+	r.deleted = true
+	return nil
+}
+
+func (r *deletingStackRepository) ListEvents(ctx context.Context, stackName string, since time.Time) ([]*stack.Event, error) {
+	return nil, nil
+}
+
+// fakeENIRepository reports a network interface in a subnet until it has been polled the given number of times
+type fakeENIRepository struct {
+	mu    sync.Mutex
+	polls map[string]int
+}
+
+func (r *fakeENIRepository) FindByNetwork(ctx context.Context, subnetIDs, securityGroupIDs []string) ([]*eni.NetworkInterface, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subnetID := subnetIDs[0]
+	if r.polls[subnetID] == 0 {
+		return nil, nil
+	}
+	r.polls[subnetID]--
+	return []*eni.NetworkInterface{eni.NewNetworkInterface("eni-"+subnetID, "in-use", subnetID, securityGroupIDs)}, nil
+}
+
+func TestBenchmarkStack(t *testing.T) {
+	// api and worker share the network interfaces of their subnet and security group
+	shared := &function.VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-1"}, SecurityGroupIds: []string{"sg-1"}}
+	other := &function.VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-2"}, SecurityGroupIds: []string{"sg-1"}}
+
+	tests := []struct {
+		name          string
+		baseline      bool
+		eniTimeout    time.Duration
+		wantCalls     []string
+		wantReleased  int
+		wantRemaining int
+	}{
+		{name: "delambda", wantCalls: []string{"detach-vpc api", "detach-vpc worker", "detach-vpc jobs"}, wantReleased: 3},
+		{name: "baseline", baseline: true, wantReleased: 3},
+		{name: "ENI release times out", eniTimeout: 20 * time.Millisecond, wantCalls: []string{"detach-vpc api", "detach-vpc worker", "detach-vpc jobs"}, wantReleased: 1, wantRemaining: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+				"api":    function.NewFunction("api", "", "", shared, nil),
+				"worker": function.NewFunction("worker", "", "", shared, nil),
+				"jobs":   function.NewFunction("jobs", "", "", other, nil),
+				"cron":   function.NewFunction("cron", "", "", nil, nil),
+			}}
+			stackRepo := &deletingStackRepository{fakeStackRepository: fakeStackRepository{functionNames: []string{"api", "worker", "jobs", "cron"}}}
+			polls := map[string]int{"subnet-1": 2, "subnet-2": 1}
+			if tt.eniTimeout > 0 {
+				polls["subnet-1"] = 1000
+			}
+			eniRepo := &fakeENIRepository{polls: polls}
+
+			uc := NewBenchmarkStackUseCase(functionRepo, stackRepo, eniRepo, io.Discard)
+			result, err := uc.Execute(context.Background(), &BenchmarkStackInput{
+				StackName:    "app",
+				Baseline:     tt.baseline,
+				DisableIPv6:  true,
+				PollInterval: time.Millisecond,
+				ENITimeout:   tt.eniTimeout,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if !slices.Equal(functionRepo.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", functionRepo.calls, tt.wantCalls)
+			}
+			if !stackRepo.deleted {
+				t.Error("stack was not deleted")
+			}
+			if result.Functions != 4 || result.VPCFunctions != 3 {
+				t.Errorf("result has %d function(s), %d in a VPC, want 4 and 3", result.Functions, result.VPCFunctions)
+			}
+			if result.ENIRelease.Count != tt.wantReleased || result.ENIsRemaining != tt.wantRemaining {
+				t.Errorf("ENI release of %d function(s), %d remaining, want %d and %d", result.ENIRelease.Count, result.ENIsRemaining, tt.wantReleased, tt.wantRemaining)
+			}
+			// The functions sharing network interfaces are released together, after jobs
+			if tt.wantRemaining == 0 && result.ENIRelease.P90 != result.ENIRelease.Max {
+				t.Errorf("ENI release p90 %s, max %s, want api and worker released together", result.ENIRelease.P90, result.ENIRelease.Max)
+			}
+			if tt.baseline != (result.Mode == BenchmarkModeBaseline) {
+				t.Errorf("Mode = %s, baseline %v", result.Mode, tt.baseline)
+			}
+		})
+	}
+}
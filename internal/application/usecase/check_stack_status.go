@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// CheckStackStatusUseCase verifies that a stack is in a stable state before its
+// functions are modified, optionally waiting for in-progress operations to finish
+type CheckStackStatusUseCase struct {
+	stackRepo stack.Repository
+	output    io.Writer
+}
+
+// CheckStackStatusInput contains the input parameters for checking a stack's status
+type CheckStackStatusInput struct {
+	StackName    string
+	WaitStable   bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// NewCheckStackStatusUseCase creates a new CheckStackStatusUseCase
+func NewCheckStackStatusUseCase(stackRepo stack.Repository, output io.Writer) *CheckStackStatusUseCase {
+	return &CheckStackStatusUseCase{
+		stackRepo: stackRepo,
+		output:    output,
+	}
+}
+
+// Execute returns the stack if it is stable. Stacks with an operation in progress
+// are waited for when WaitStable is set and refused otherwise; stacks stuck in a
+// failed rollback are always refused. Stacks whose last operation was rolled back
+// can be modified, with a warning, since deleting them is how they are cleaned up.
+func (uc *CheckStackStatusUseCase) Execute(ctx context.Context, input *CheckStackStatusInput) (*stack.Stack, error) {
+	pollInterval := input.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultStackPollInterval
+	}
+	timeout := input.Timeout
+	if timeout <= 0 {
+		timeout = defaultStackWaitTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := uc.stackRepo.FindByName(ctx, input.StackName)
+		if err != nil {
+			return nil, err
+		}
+
+		if st.IsRollbackFailed() {
+			return nil, fmt.Errorf("stack %s is in %s and must be recovered before its functions can be modified", st.Name(), st.Status())
+		}
+
+		if st.IsStable() {
+			if st.IsRolledBack() {
+				fmt.Fprintf(uc.output, "Warning: stack %s is in %s: its last operation failed and was rolled back", st.Name(), st.Status())
+				if st.StatusReason() != "" {
+					fmt.Fprintf(uc.output, " (%s)", st.StatusReason())
+				}
+				fmt.Fprintln(uc.output)
+			}
+			return st, nil
+		}
+
+		if !input.WaitStable {
+			return nil, fmt.Errorf("stack %s is in %s; retry once CloudFormation has finished or use --wait-stable", st.Name(), st.Status())
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for stack %s to become stable (status: %s)", st.Name(), st.Status())
+		}
+
+		fmt.Fprintf(uc.output, "Stack %s is in %s, waiting...\n", st.Name(), st.Status())
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
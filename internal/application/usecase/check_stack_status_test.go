@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+func TestCheckStackStatus(t *testing.T) {
+	tests := []struct {
+		status      types.StackStatus
+		wantErr     bool
+		wantWarning bool
+	}{
+		{status: types.StackStatusCreateComplete},
+		{status: types.StackStatusRollbackComplete, wantWarning: true},
+		{status: types.StackStatusUpdateRollbackComplete, wantWarning: true},
+		{status: types.StackStatusUpdateInProgress, wantErr: true},
+		{status: types.StackStatusUpdateRollbackFailed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			stackRepo := &fakeStackRepository{stack: stack.NewStack("", "app", tt.status, "Resource creation cancelled", nil)}
+			var out bytes.Buffer
+
+			_, err := NewCheckStackStatusUseCase(stackRepo, &out).Execute(context.Background(), &CheckStackStatusInput{StackName: "app"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Contains(out.String(), "Warning: stack app is in "+string(tt.status)); got != tt.wantWarning {
+				t.Errorf("warning printed = %v, want %v:\n%s", got, tt.wantWarning, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/shirasu/delambda/internal/domain/checkpoint"
+)
+
+// CheckpointTracker records the progress of each step in a checkpoint, so an
+// interrupted run can be resumed. A nil tracker runs every step without recording.
+type CheckpointTracker struct {
+	repo    checkpoint.Repository
+	account string
+	region  string
+}
+
+// NewCheckpointTracker creates a new CheckpointTracker for the given account and region
+func NewCheckpointTracker(repo checkpoint.Repository, account, region string) *CheckpointTracker {
+	return &CheckpointTracker{
+		repo:    repo,
+		account: account,
+		region:  region,
+	}
+}
+
+// key returns the checkpoint key of a function of a stack
+func (t *CheckpointTracker) key(stackName, functionName string) checkpoint.Key {
+	return checkpoint.Key{
+		Account:  t.account,
+		Region:   t.region,
+		Stack:    stackName,
+		Function: functionName,
+	}
+}
+
+// status returns the recorded status of a step
+func (t *CheckpointTracker) status(ctx context.Context, stackName, functionName string, step checkpoint.Step) (checkpoint.Status, error) {
+	if t == nil {
+		return checkpoint.StatusNone, nil
+	}
+	status, err := t.repo.Status(ctx, t.key(stackName, functionName), step)
+	if err != nil {
+		return checkpoint.StatusNone, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return status, nil
+}
+
+// run runs op for a step of a function and records its progress. A step the
+// checkpoint records as completed is skipped. A step that was in progress when
+// an earlier run stopped is re-verified: if done reports that the live state
+// already reflects it, it is recorded as completed without running op again.
+func (t *CheckpointTracker) run(ctx context.Context, output io.Writer, stackName, functionName string, step checkpoint.Step, done func() bool, op func() error) error {
+	if t == nil {
+		return op()
+	}
+
+	key := t.key(stackName, functionName)
+	status, err := t.repo.Status(ctx, key, step)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	switch status {
+	case checkpoint.StatusCompleted:
+		fmt.Fprintf(output, "⏭️  Skipping %s, completed in an earlier run\n", step.Description())
+		return nil
+	case checkpoint.StatusInProgress:
+		if done() {
+			fmt.Fprintf(output, "✓ Verified %s, which was in progress when the earlier run stopped\n", step.Description())
+			return t.save(ctx, key, step, checkpoint.StatusCompleted)
+		}
+		fmt.Fprintf(output, "Retrying %s, which was in progress when the earlier run stopped\n", step.Description())
+	}
+
+	if err := t.save(ctx, key, step, checkpoint.StatusInProgress); err != nil {
+		return err
+	}
+	if err := op(); err != nil {
+		// The step stays in progress, so a resumed run verifies it against the live state
+		return err
+	}
+	return t.save(ctx, key, step, checkpoint.StatusCompleted)
+}
+
+// save records the status of a step
+func (t *CheckpointTracker) save(ctx context.Context, key checkpoint.Key, step checkpoint.Step, status checkpoint.Status) error {
+	if err := t.repo.Save(ctx, key, step, status); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/shirasu/delambda/internal/domain/function"
 	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/protection"
 )
 
 // DeleteFunctionUseCase handles the deletion of Lambda functions
@@ -22,6 +23,16 @@ type DeleteFunctionInput struct {
 	DetachVPC    bool
 	DisableIPv6  bool
 	DeleteLogs   bool
+
+	// LogsRetentionDays keeps the log group and sets its retention to this
+	// many days instead of deleting it, if positive
+	LogsRetentionDays int
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
 }
 
 // NewDeleteFunctionUseCase creates a new DeleteFunctionUseCase
@@ -39,6 +50,25 @@ func NewDeleteFunctionUseCase(
 
 // Execute executes the delete function use case
 func (uc *DeleteFunctionUseCase) Execute(ctx context.Context, input *DeleteFunctionInput) error {
+	// The function is looked up before it is deleted, since its logging
+	// configuration names the log group whose retention is set, or that is
+	// kept when logs are deleted
+	var fn *function.Function
+	if input.Guard != nil || input.DryRun || input.LogsRetentionDays > 0 || input.DeleteLogs {
+		var err error
+		fn, err = uc.functionRepo.FindByName(ctx, input.FunctionName)
+		if err != nil {
+			return err
+		}
+		if err := input.Guard.Check(uc.output, []*protection.Target{functionTarget(fn)}, input.DryRun); err != nil {
+			return err
+		}
+		if input.DryRun {
+			printDeletePlan(uc.output, fn, input.DetachVPC, input.DisableIPv6, input.DeleteLogs, input.LogsRetentionDays)
+			return nil
+		}
+	}
+
 	// Detach VPC if requested
 	if input.DetachVPC {
 		// Disable IPv6 if requested
@@ -75,14 +105,21 @@ func (uc *DeleteFunctionUseCase) Execute(ctx context.Context, input *DeleteFunct
 	}
 	fmt.Fprintf(uc.output, "Deleted function %s\n", input.FunctionName)
 
-	// Delete log group if requested
-	if input.DeleteLogs {
+	// Keep the log group for a while, or delete it, if requested
+	if input.LogsRetentionDays > 0 {
+		if err := setLogRetention(ctx, uc.logGroupRepo, uc.output, functionLogGroup(fn), input.LogsRetentionDays); err != nil {
+			return err
+		}
+	} else if input.DeleteLogs {
+		// Unlike the retention, deletion never touches the log group of the
+		// logging configuration, which other functions may share
 		logGroup := loggroup.NewLogGroupForFunction(input.FunctionName)
 		fmt.Fprintf(uc.output, "Deleting CloudWatch Logs log group %s...\n", logGroup.Name())
 		if err := uc.logGroupRepo.Delete(ctx, logGroup); err != nil {
 			return fmt.Errorf("failed to delete log group: %w", err)
 		}
 		fmt.Fprintf(uc.output, "Deleted CloudWatch Logs log group %s\n", logGroup.Name())
+		printKeptLogGroup(uc.output, fn, "Keeping CloudWatch Logs log group %s of the logging configuration, which other functions may share\n")
 	} else {
 		fmt.Fprintf(uc.output, "Skipping log deletion (--without-logs specified)\n")
 	}
@@ -2,24 +2,75 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/protection"
 )
 
 // DeleteLogGroupUseCase handles deleting log groups
 type DeleteLogGroupUseCase struct {
 	logGroupRepo loggroup.Repository
+	output       io.Writer
+}
+
+// DeleteLogGroupInput contains the input parameters for deleting log groups
+type DeleteLogGroupInput struct {
+	// LogGroups are the log groups to delete, by their full names
+	LogGroups []*loggroup.LogGroup
+
+	// Guard evaluates the protection rules against the functions the log groups are named after, if set
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
 }
 
 // NewDeleteLogGroupUseCase creates a new DeleteLogGroupUseCase
-func NewDeleteLogGroupUseCase(logGroupRepo loggroup.Repository) *DeleteLogGroupUseCase {
+func NewDeleteLogGroupUseCase(logGroupRepo loggroup.Repository, output io.Writer) *DeleteLogGroupUseCase {
 	return &DeleteLogGroupUseCase{
 		logGroupRepo: logGroupRepo,
+		output:       output,
 	}
 }
 
-// Execute executes the delete log group use case
-func (uc *DeleteLogGroupUseCase) Execute(ctx context.Context, functionName string) error {
-	logGroup := loggroup.NewLogGroupForFunction(functionName)
-	return uc.logGroupRepo.Delete(ctx, logGroup)
+// Execute deletes the log groups, continuing past failures, unless a
+// protection rule covers the function any of them is named after
+func (uc *DeleteLogGroupUseCase) Execute(ctx context.Context, input *DeleteLogGroupInput) error {
+	var targets []*protection.Target
+	for _, lg := range input.LogGroups {
+		if functionName, _, ok := lg.Function(); ok {
+			targets = append(targets, protection.NewFunctionTarget(functionName, nil))
+		}
+	}
+	if err := input.Guard.Check(uc.output, targets, input.DryRun); err != nil {
+		return err
+	}
+
+	if input.DryRun {
+		for _, lg := range input.LogGroups {
+			fmt.Fprintf(uc.output, "  [dry-run] Would delete CloudWatch Logs log group %s\n", lg.Name())
+		}
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	failed := 0
+	for _, lg := range input.LogGroups {
+		if err := uc.logGroupRepo.Delete(ctx, lg); err != nil {
+			fmt.Fprintf(uc.output, "❌ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(uc.output, "Deleted CloudWatch Logs log group %s\n", lg.Name())
+	}
+
+	if len(input.LogGroups) > 1 {
+		fmt.Fprintf(uc.output, "\nTotal log groups: %d, deleted: %d, failed: %d\n", len(input.LogGroups), len(input.LogGroups)-failed, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d log group(s) failed", failed, len(input.LogGroups))
+	}
+	return nil
 }
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+func TestDeleteLogGroup(t *testing.T) {
+	logGroups := []*loggroup.LogGroup{
+		loggroup.NewLogGroup("/aws/lambda/my-function"),
+		loggroup.NewLogGroup("/aws/lambda/us-east-1.edge"),
+	}
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		wantCalls []string
+	}{
+		{
+			// The names are full names, not wrapped again as names of functions
+			name:      "deletes every log group by its full name",
+			wantCalls: []string{"delete-logs /aws/lambda/my-function", "delete-logs /aws/lambda/us-east-1.edge"},
+		},
+		{
+			name:   "dry run deletes nothing",
+			dryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logGroupRepo := &fakeLogGroupRepository{}
+			err := NewDeleteLogGroupUseCase(logGroupRepo, io.Discard).Execute(context.Background(), &DeleteLogGroupInput{
+				LogGroups: logGroups,
+				DryRun:    tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !reflect.DeepEqual(logGroupRepo.calls, tt.wantCalls) {
+				t.Errorf("Execute() calls = %v, want %v", logGroupRepo.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFindLogGroups(t *testing.T) {
+	logGroupRepo := &listingLogGroupRepository{logGroups: []*loggroup.LogGroup{
+		loggroup.NewLogGroup("/aws/lambda/api"),
+		loggroup.NewLogGroup("/aws/lambda/api-v2"),
+		loggroup.NewLogGroup("/aws/lambda/worker"),
+		loggroup.NewLogGroup("/ecs/api"),
+	}}
+
+	tests := []struct {
+		name        string
+		input       *FindLogGroupsInput
+		want        []string
+		wantMissing []string
+		wantErr     bool
+	}{
+		{
+			name:        "names match only themselves",
+			input:       &FindLogGroupsInput{Names: []string{"/aws/lambda/worker", "/aws/lambda/api", "/aws/lambda/gone"}},
+			want:        []string{"/aws/lambda/worker", "/aws/lambda/api"},
+			wantMissing: []string{"/aws/lambda/gone"},
+		},
+		{
+			name:  "prefix",
+			input: &FindLogGroupsInput{Prefix: "/aws/lambda/api"},
+			want:  []string{"/aws/lambda/api", "/aws/lambda/api-v2"},
+		},
+		{
+			name:    "prefix of every function",
+			input:   &FindLogGroupsInput{Prefix: "/aws/lambda/"},
+			wantErr: true,
+		},
+		{
+			name:    "prefix outside Lambda",
+			input:   &FindLogGroupsInput{Prefix: "/ecs/"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := NewFindLogGroupsUseCase(logGroupRepo).Execute(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, lg := range found.LogGroups {
+				got = append(got, lg.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(found.Missing, tt.wantMissing) {
+				t.Errorf("Execute() missing = %v, want %v", found.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
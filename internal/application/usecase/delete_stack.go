@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/protection"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+const (
+	defaultStackPollInterval = 5 * time.Second
+	defaultStackWaitTimeout  = time.Hour
+
+	// eventClockSkew allows for differences between the local clock and CloudFormation event timestamps
+	eventClockSkew = time.Minute
+)
+
+// DeleteStackUseCase handles detaching VPC from all Lambda functions in a stack
+// and then deleting the CloudFormation stack itself
+type DeleteStackUseCase struct {
+	functionRepo function.Repository
+	stackRepo    stack.Repository
+	output       io.Writer
+}
+
+// DeleteStackInput contains the input parameters for deleting a stack
+type DeleteStackInput struct {
+	StackName    string
+	DisableIPv6  bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
+}
+
+// NewDeleteStackUseCase creates a new DeleteStackUseCase
+func NewDeleteStackUseCase(functionRepo function.Repository, stackRepo stack.Repository, output io.Writer) *DeleteStackUseCase {
+	return &DeleteStackUseCase{
+		functionRepo: functionRepo,
+		stackRepo:    stackRepo,
+		output:       output,
+	}
+}
+
+// Execute detaches VPC from the stack's Lambda functions, deletes the stack and
+// streams stack events until the deletion completes or fails
+func (uc *DeleteStackUseCase) Execute(ctx context.Context, input *DeleteStackInput) error {
+	start := time.Now()
+
+	st, functions, err := uc.prepare(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	// Phase 1: detach VPC from every function in the stack
+	fmt.Fprintf(uc.output, "=== Phase 1: Detach VPC ===\n")
+	detachStart := time.Now()
+	if err := uc.detachFunctions(ctx, input, functions); err != nil {
+		return err
+	}
+	detachDuration := time.Since(detachStart)
+
+	if input.DryRun {
+		fmt.Fprintf(uc.output, "\n=== Phase 2: Delete stack ===\n")
+		fmt.Fprintf(uc.output, "  [dry-run] Would delete stack %s and wait up to %s for completion\n", st.Name(), stackWaitTimeout(input))
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	// Phase 2: delete the stack
+	fmt.Fprintf(uc.output, "\n=== Phase 2: Delete stack ===\n")
+	deleteStart := time.Now()
+	if err := uc.deleteStack(ctx, input, st); err != nil {
+		return err
+	}
+	deleteDuration := time.Since(deleteStart)
+
+	fmt.Fprintf(uc.output, "\n=== Timings ===\n")
+	fmt.Fprintf(uc.output, "VPC detach:     %s\n", detachDuration.Round(time.Second))
+	fmt.Fprintf(uc.output, "Stack deletion: %s\n", deleteDuration.Round(time.Second))
+	fmt.Fprintf(uc.output, "Total:          %s\n", time.Since(start).Round(time.Second))
+
+	return nil
+}
+
+// prepare resolves the stack and looks up every function in it before any is
+// modified, so the protection rules can be checked
+func (uc *DeleteStackUseCase) prepare(ctx context.Context, input *DeleteStackInput) (*stack.Stack, []*resolvedFunction, error) {
+	// Resolve the stack ID so the stack can still be described once it has been deleted
+	st, err := uc.stackRepo.FindByName(ctx, input.StackName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resources, err := uc.stackRepo.ListLambdaFunctions(ctx, input.StackName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list Lambda functions in stack: %w", err)
+	}
+	functions := resolveStackFunctions(ctx, uc.functionRepo, resources)
+
+	targets := append([]*protection.Target{protection.NewStackTarget(st.Name(), st.Tags())}, stackFunctionTargets(functions, st.Name())...)
+	if err := input.Guard.Check(uc.output, targets, input.DryRun); err != nil {
+		return nil, nil, err
+	}
+	return st, functions, nil
+}
+
+// stackWaitTimeout returns how long to wait for the stack deletion to complete
+func stackWaitTimeout(input *DeleteStackInput) time.Duration {
+	if input.Timeout <= 0 {
+		return defaultStackWaitTimeout
+	}
+	return input.Timeout
+}
+
+// deleteStack deletes the stack and streams its events until the deletion
+// completes or fails
+func (uc *DeleteStackUseCase) deleteStack(ctx context.Context, input *DeleteStackInput, st *stack.Stack) error {
+	pollInterval := input.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultStackPollInterval
+	}
+
+	deleteStart := time.Now()
+	since := deleteStart.Add(-eventClockSkew)
+
+	// Mark events that happened before the deletion as seen so only deletion events are streamed
+	seen := make(map[string]bool)
+	previous, err := uc.stackRepo.ListEvents(ctx, st.ID(), since)
+	if err != nil {
+		return err
+	}
+	for _, e := range previous {
+		seen[e.ID()] = true
+	}
+
+	if err := uc.stackRepo.Delete(ctx, st.ID()); err != nil {
+		return err
+	}
+	fmt.Fprintf(uc.output, "Deletion of stack %s started\n", st.Name())
+
+	// Stream events until the stack reaches a final state
+	deadline := deleteStart.Add(stackWaitTimeout(input))
+	for {
+		events, err := uc.stackRepo.ListEvents(ctx, st.ID(), since)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if seen[e.ID()] {
+				continue
+			}
+			seen[e.ID()] = true
+			printStackEvent(uc.output, e)
+		}
+
+		current, err := uc.stackRepo.FindByName(ctx, st.ID())
+		if err != nil {
+			return err
+		}
+
+		if current.IsDeleteComplete() {
+			return nil
+		}
+		if current.IsDeleteFailed() {
+			return fmt.Errorf("stack %s deletion failed: %s", st.Name(), current.StatusReason())
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for stack %s to be deleted (status: %s)", st.Name(), current.Status())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// detachFunctions detaches VPC from every Lambda function in the stack.
+// The stack is not deleted if any function fails, since CloudFormation would
+// then fall back to the slow ENI cleanup path.
+func (uc *DeleteStackUseCase) detachFunctions(ctx context.Context, input *DeleteStackInput, functions []*resolvedFunction) error {
+	fmt.Fprintf(uc.output, "Found %d Lambda function(s) in stack %s\n", len(functions), input.StackName)
+
+	failureCount := 0
+	for _, r := range functions {
+		fmt.Fprintf(uc.output, "\nProcessing function: %s\n", describeFunctionResource(r.resource, input.StackName))
+
+		if r.err != nil {
+			fmt.Fprintf(uc.output, "  ❌ Failed to get function: %v\n", r.err)
+			failureCount++
+			continue
+		}
+
+		if input.DryRun {
+			printDetachPlan(uc.output, r.function, input.DisableIPv6)
+			continue
+		}
+
+		if err := detachFunctionVPC(ctx, uc.functionRepo, r.function, input.DisableIPv6, uc.output); err != nil {
+			failureCount++
+		}
+	}
+
+	if failureCount > 0 {
+		return fmt.Errorf("failed to detach VPC from %d function(s), stack %s was not deleted", failureCount, input.StackName)
+	}
+
+	return nil
+}
+
+// printStackEvent writes a single stack event line to output
+func printStackEvent(output io.Writer, e *stack.Event) {
+	line := fmt.Sprintf("  %s  %-20s %-40s %s",
+		e.Timestamp().Local().Format("15:04:05"),
+		e.ResourceStatus(),
+		e.LogicalResourceID(),
+		e.ResourceType(),
+	)
+	if e.ResourceStatusReason() != "" {
+		line += fmt.Sprintf(" (%s)", e.ResourceStatusReason())
+	}
+	fmt.Fprintln(output, line)
+}
@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
+	"github.com/shirasu/delambda/internal/domain/checkpoint"
 	"github.com/shirasu/delambda/internal/domain/function"
 	"github.com/shirasu/delambda/internal/domain/loggroup"
 	"github.com/shirasu/delambda/internal/domain/stack"
@@ -24,6 +26,20 @@ type DeleteStackFunctionsInput struct {
 	DetachVPC   bool
 	DisableIPv6 bool
 	DeleteLogs  bool
+
+	// LogsRetentionDays keeps the log groups and sets their retention to this
+	// many days instead of deleting them, if positive
+	LogsRetentionDays int
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
+
+	// Checkpoint records the progress of every function and skips the steps
+	// completed by an earlier, interrupted run
+	Checkpoint *CheckpointTracker
 }
 
 // NewDeleteStackFunctionsUseCase creates a new DeleteStackFunctionsUseCase
@@ -44,96 +60,177 @@ func NewDeleteStackFunctionsUseCase(
 // Execute deletes all Lambda functions in the specified stack
 func (uc *DeleteStackFunctionsUseCase) Execute(ctx context.Context, input *DeleteStackFunctionsInput) error {
 	// Get all Lambda functions in the stack
-	functionNames, err := uc.stackRepo.ListLambdaFunctions(ctx, input.StackName)
+	resources, err := uc.stackRepo.ListLambdaFunctions(ctx, input.StackName)
 	if err != nil {
 		return fmt.Errorf("failed to list Lambda functions in stack: %w", err)
 	}
 
-	if len(functionNames) == 0 {
+	if len(resources) == 0 {
 		return fmt.Errorf("no Lambda functions found in stack %s", input.StackName)
 	}
 
-	fmt.Fprintf(uc.output, "Found %d Lambda function(s) in stack %s\n", len(functionNames), input.StackName)
+	fmt.Fprintf(uc.output, "Found %d Lambda function(s) in stack %s\n", len(resources), input.StackName)
+
+	// Look up every function before modifying any, so protection rules can be checked
+	functions := resolveStackFunctions(ctx, uc.functionRepo, resources)
+	if err := input.Guard.Check(uc.output, stackFunctionTargets(functions, input.StackName), input.DryRun); err != nil {
+		return err
+	}
 
 	// Delete each function
 	successCount := 0
 	failureCount := 0
-	for _, functionName := range functionNames {
-		fmt.Fprintf(uc.output, "\n=== Processing function: %s ===\n", functionName)
+	for _, r := range functions {
+		fmt.Fprintf(uc.output, "\n=== Processing function: %s ===\n", describeFunctionResource(r.resource, input.StackName))
+
+		if input.DryRun {
+			if r.err != nil {
+				fmt.Fprintf(uc.output, "Failed to get function: %v\n", r.err)
+				failureCount++
+				continue
+			}
+			printDeletePlan(uc.output, r.function, input.DetachVPC, input.DisableIPv6, input.DeleteLogs, input.LogsRetentionDays)
+			continue
+		}
 
-		// Get the function to check VPC status
-		fn, err := uc.functionRepo.FindByName(ctx, functionName)
-		if err != nil {
-			fmt.Fprintf(uc.output, "Failed to get function: %v\n", err)
+		if err := uc.deleteFunction(ctx, input, r); err != nil {
+			fmt.Fprintf(uc.output, "Failed to process %s: %v\n", r.resource.Name(), err)
 			failureCount++
 			continue
 		}
+		fmt.Fprintf(uc.output, "Successfully processed %s\n", r.resource.Name())
+		successCount++
+	}
+
+	if input.DryRun {
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	fmt.Fprintf(uc.output, "\n=== Summary ===\n")
+	fmt.Fprintf(uc.output, "Total functions: %d\n", len(resources))
+	fmt.Fprintf(uc.output, "Successfully deleted: %d\n", successCount)
+	fmt.Fprintf(uc.output, "Failed: %d\n", failureCount)
+
+	if failureCount > 0 {
+		return fmt.Errorf("failed to delete %d function(s)", failureCount)
+	}
+
+	return nil
+}
+
+// deleteFunction runs the steps of deleting a single function of the stack,
+// skipping the steps the checkpoint records as completed
+func (uc *DeleteStackFunctionsUseCase) deleteFunction(ctx context.Context, input *DeleteStackFunctionsInput, r *resolvedFunction) error {
+	functionName := r.resource.Name()
+	fn := r.function
+
+	if r.err != nil {
+		// A function that an earlier run started deleting is expected to be gone
+		deleteStatus, err := input.Checkpoint.status(ctx, input.StackName, functionName, checkpoint.StepDeleteFunction)
+		if err != nil {
+			return err
+		}
+		if !errors.Is(r.err, function.ErrNotFound) || deleteStatus == checkpoint.StatusNone {
+			return fmt.Errorf("failed to get function: %w", r.err)
+		}
+	}
 
-		// Handle VPC detachment if requested
-		if input.DetachVPC {
-			if fn.IsAttachedToVPC() {
-				// Disable IPv6 if requested and enabled
-				if input.DisableIPv6 {
-					if fn.HasIPv6Enabled() {
+	// Handle VPC detachment if requested. A function deleted by an earlier run has nothing to detach.
+	if input.DetachVPC && fn != nil {
+		if fn.IsAttachedToVPC() {
+			// Disable IPv6 if requested and enabled
+			if input.DisableIPv6 {
+				if fn.HasIPv6Enabled() {
+					err := input.Checkpoint.run(ctx, uc.output, input.StackName, functionName, checkpoint.StepDisableIPv6, func() bool { return false }, func() error {
 						if err := uc.functionRepo.DisableIPv6(ctx, functionName); err != nil {
-							fmt.Fprintf(uc.output, "Failed to disable IPv6: %v\n", err)
-							failureCount++
-							continue
+							return fmt.Errorf("failed to disable IPv6: %w", err)
 						}
 						fmt.Fprintf(uc.output, "Disabled IPv6 for function %s\n", functionName)
-					} else {
-						fmt.Fprintf(uc.output, "IPv6 is not enabled, skipping IPv6 disable\n")
+						return nil
+					})
+					if err != nil {
+						return err
 					}
+				} else {
+					fmt.Fprintf(uc.output, "IPv6 is not enabled, skipping IPv6 disable\n")
 				}
+			}
 
-				// Detach VPC
+			// Detach VPC
+			err := input.Checkpoint.run(ctx, uc.output, input.StackName, functionName, checkpoint.StepDetachVPC, func() bool { return false }, func() error {
 				if err := uc.functionRepo.DetachVPC(ctx, functionName); err != nil {
-					fmt.Fprintf(uc.output, "Failed to detach VPC: %v\n", err)
-					failureCount++
-					continue
+					return fmt.Errorf("failed to detach VPC: %w", err)
 				}
 				fmt.Fprintf(uc.output, "Detached VPC from function %s\n", functionName)
-			} else {
-				fmt.Fprintf(uc.output, "Function is not attached to VPC, skipping VPC detach\n")
+				return nil
+			})
+			if err != nil {
+				return err
 			}
+		} else {
+			fmt.Fprintf(uc.output, "Function is not attached to VPC, skipping VPC detach\n")
 		}
+	}
 
-		// Delete the function
+	// Delete the function
+	functionGone := func() bool { return errors.Is(r.err, function.ErrNotFound) }
+	err := input.Checkpoint.run(ctx, uc.output, input.StackName, functionName, checkpoint.StepDeleteFunction, functionGone, func() error {
 		fmt.Fprintf(uc.output, "Deleting function %s...\n", functionName)
 		if err := uc.functionRepo.Delete(ctx, functionName); err != nil {
-			fmt.Fprintf(uc.output, "Failed to delete function: %v\n", err)
-			failureCount++
-			continue
+			return fmt.Errorf("failed to delete function: %w", err)
 		}
 		fmt.Fprintf(uc.output, "Deleted function %s\n", functionName)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// Delete log group if requested
-		if input.DeleteLogs {
-			logGroup := loggroup.NewLogGroupForFunction(functionName)
-			fmt.Fprintf(uc.output, "Deleting CloudWatch Logs log group %s...\n", logGroup.Name())
-
-			if err := uc.logGroupRepo.Delete(ctx, logGroup); err != nil {
-				fmt.Fprintf(uc.output, "Warning: Failed to delete log group: %v\n", err)
-				// Don't count this as a failure since the function was deleted
-			} else {
-				fmt.Fprintf(uc.output, "Deleted CloudWatch Logs log group %s\n", logGroup.Name())
-			}
-		} else {
-			fmt.Fprintf(uc.output, "Skipping log deletion (--without-logs specified)\n")
+	// Keep the log group for a while if requested; setting the retention again is harmless.
+	// A function deleted by an earlier run is assumed to have logged to /aws/lambda/<name>.
+	if input.LogsRetentionDays > 0 {
+		logGroup := loggroup.NewLogGroupForFunction(functionName)
+		if fn != nil {
+			logGroup = functionLogGroup(fn)
 		}
-
-		fmt.Fprintf(uc.output, "Successfully processed %s\n", functionName)
-		successCount++
+		retentionSet := func() bool { return false }
+		err = input.Checkpoint.run(ctx, uc.output, input.StackName, functionName, checkpoint.StepSetLogRetention, retentionSet, func() error {
+			return setLogRetention(ctx, uc.logGroupRepo, uc.output, logGroup, input.LogsRetentionDays)
+		})
+		if err != nil {
+			// Don't count this as a failure since the function was deleted
+			fmt.Fprintf(uc.output, "Warning: Failed to set log retention: %v\n", err)
+		}
+		return nil
 	}
 
-	fmt.Fprintf(uc.output, "\n=== Summary ===\n")
-	fmt.Fprintf(uc.output, "Total functions: %d\n", len(functionNames))
-	fmt.Fprintf(uc.output, "Successfully deleted: %d\n", successCount)
-	fmt.Fprintf(uc.output, "Failed: %d\n", failureCount)
+	// Delete log group if requested
+	if !input.DeleteLogs {
+		fmt.Fprintf(uc.output, "Skipping log deletion (--without-logs specified)\n")
+		return nil
+	}
 
-	if failureCount > 0 {
-		return fmt.Errorf("failed to delete %d function(s)", failureCount)
+	// Unlike the retention, deletion never touches the log group of the
+	// logging configuration, which other functions may share
+	logGroup := loggroup.NewLogGroupForFunction(functionName)
+	logGroupGone := func() bool {
+		exists, err := uc.logGroupRepo.Exists(ctx, logGroup)
+		return err == nil && !exists
+	}
+	err = input.Checkpoint.run(ctx, uc.output, input.StackName, functionName, checkpoint.StepDeleteLogs, logGroupGone, func() error {
+		fmt.Fprintf(uc.output, "Deleting CloudWatch Logs log group %s...\n", logGroup.Name())
+		if err := uc.logGroupRepo.Delete(ctx, logGroup); err != nil {
+			return err
+		}
+		fmt.Fprintf(uc.output, "Deleted CloudWatch Logs log group %s\n", logGroup.Name())
+		return nil
+	})
+	if err != nil {
+		// Don't count this as a failure since the function was deleted
+		fmt.Fprintf(uc.output, "Warning: Failed to delete log group: %v\n", err)
 	}
+	printKeptLogGroup(uc.output, fn, "Keeping CloudWatch Logs log group %s of the logging configuration, which other functions may share\n")
 
 	return nil
 }
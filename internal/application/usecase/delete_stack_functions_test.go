@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/checkpoint"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// fakeFunctionRepository serves the given functions and records the calls that modify them
+type fakeFunctionRepository struct {
+	functions map[string]*function.Function
+	calls     []string
+}
+
+// FindAll returns the functions, least recently modified first
+func (r *fakeFunctionRepository) FindAll(ctx context.Context) ([]*function.Function, error) {
+	var functions []*function.Function
+	for _, fn := range r.functions {
+		functions = append(functions, fn)
+	}
+	slices.SortFunc(functions, func(a, b *function.Function) int {
+		return a.LastModified().Compare(b.LastModified())
+	})
+	return functions, nil
+}
+
+func (r *fakeFunctionRepository) FindByName(ctx context.Context, name string) (*function.Function, error) {
+	fn, ok := r.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", function.ErrNotFound, name)
+	}
+	return fn, nil
+}
+
+func (r *fakeFunctionRepository) DisableIPv6(ctx context.Context, functionName string) error {
+	r.calls = append(r.calls, "disable-ipv6 "+functionName)
+	return nil
+}
+
+func (r *fakeFunctionRepository) DetachVPC(ctx context.Context, functionName string) error {
+	r.calls = append(r.calls, "detach-vpc "+functionName)
+	return nil
+}
+
+func (r *fakeFunctionRepository) Delete(ctx context.Context, functionName string) error {
+	r.calls = append(r.calls, "delete "+functionName)
+	delete(r.functions, functionName)
+	return nil
+}
+
+// fakeLogGroupRepository has no log groups and records deletions and
+// retention changes, failing those of the missing log groups
+type fakeLogGroupRepository struct {
+	calls   []string
+	missing []string
+}
+
+func (r *fakeLogGroupRepository) FindByPrefix(ctx context.Context, prefix string) ([]*loggroup.LogGroup, error) {
+	return nil, nil
+}
+
+func (r *fakeLogGroupRepository) LastEventTime(ctx context.Context, logGroup *loggroup.LogGroup) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (r *fakeLogGroupRepository) Exists(ctx context.Context, logGroup *loggroup.LogGroup) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeLogGroupRepository) SetRetention(ctx context.Context, logGroup *loggroup.LogGroup, days int) error {
+	if slices.Contains(r.missing, logGroup.Name()) {
+		return loggroup.ErrNotFound
+	}
+	r.calls = append(r.calls, fmt.Sprintf("set-retention %s %d", logGroup.Name(), days))
+	return nil
+}
+
+func (r *fakeLogGroupRepository) Delete(ctx context.Context, logGroup *loggroup.LogGroup) error {
+	r.calls = append(r.calls, "delete-logs "+logGroup.Name())
+	return nil
+}
+
+// fakeStackRepository lists the given functions for every stack, finds the
+// given stack, if any, and cannot delete stacks
+type fakeStackRepository struct {
+	functionNames []string
+	stack         *stack.Stack
+}
+
+func (r *fakeStackRepository) FindAll(ctx context.Context) ([]*stack.Stack, error) {
+	return nil, nil
+}
+
+func (r *fakeStackRepository) FindByName(ctx context.Context, stackName string) (*stack.Stack, error) {
+	if r.stack == nil || r.stack.Name() != stackName {
+		return nil, fmt.Errorf("%w: %s", stack.ErrNotFound, stackName)
+	}
+	return r.stack, nil
+}
+
+func (r *fakeStackRepository) Delete(ctx context.Context, stackName string) error {
+	return fmt.Errorf("stack %s cannot be deleted", stackName)
+}
+
+func (r *fakeStackRepository) ListEvents(ctx context.Context, stackName string, since time.Time) ([]*stack.Event, error) {
+	return nil, nil
+}
+
+func (r *fakeStackRepository) ListLambdaFunctions(ctx context.Context, stackName string) ([]*stack.FunctionResource, error) {
+	var resources []*stack.FunctionResource
+	for _, name := range r.functionNames {
+		resources = append(resources, stack.NewFunctionResource(name, ""))
+	}
+	return resources, nil
+}
+
+// fakeCheckpointRepository keeps the checkpoint in memory
+type fakeCheckpointRepository map[checkpoint.Key]map[checkpoint.Step]checkpoint.Status
+
+func (r fakeCheckpointRepository) Status(ctx context.Context, key checkpoint.Key, step checkpoint.Step) (checkpoint.Status, error) {
+	return r[key][step], nil
+}
+
+func (r fakeCheckpointRepository) Save(ctx context.Context, key checkpoint.Key, step checkpoint.Step, status checkpoint.Status) error {
+	if r[key] == nil {
+		r[key] = make(map[checkpoint.Step]checkpoint.Status)
+	}
+	r[key][step] = status
+	return nil
+}
+
+func TestDeleteStackFunctionsResume(t *testing.T) {
+	const stackName = "app"
+	key := func(functionName string) checkpoint.Key {
+		return checkpoint.Key{Account: "123456789012", Region: "us-east-1", Stack: stackName, Function: functionName}
+	}
+	vpc := &function.VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-1"}, SecurityGroupIds: []string{"sg-1"}}
+
+	tests := []struct {
+		name      string
+		function  *function.Function
+		progress  map[checkpoint.Step]checkpoint.Status
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			name:      "no checkpoint runs every step",
+			function:  function.NewFunction("fn", "", "", vpc, nil),
+			wantCalls: []string{"detach-vpc fn", "delete fn", "delete-logs /aws/lambda/fn"},
+		},
+		{
+			name: "completed steps are skipped",
+			progress: map[checkpoint.Step]checkpoint.Status{
+				checkpoint.StepDetachVPC:      checkpoint.StatusCompleted,
+				checkpoint.StepDeleteFunction: checkpoint.StatusCompleted,
+				checkpoint.StepDeleteLogs:     checkpoint.StatusCompleted,
+			},
+		},
+		{
+			name: "in-progress deletion verified against the live state",
+			progress: map[checkpoint.Step]checkpoint.Status{
+				checkpoint.StepDetachVPC:      checkpoint.StatusCompleted,
+				checkpoint.StepDeleteFunction: checkpoint.StatusInProgress,
+			},
+			wantCalls: []string{"delete-logs /aws/lambda/fn"},
+		},
+		{
+			name:     "in-progress deletion of a function that still exists is retried",
+			function: function.NewFunction("fn", "", "", nil, nil),
+			progress: map[checkpoint.Step]checkpoint.Status{
+				checkpoint.StepDeleteFunction: checkpoint.StatusInProgress,
+			},
+			wantCalls: []string{"delete fn", "delete-logs /aws/lambda/fn"},
+		},
+		{
+			name:    "missing function without progress fails",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{}}
+			if tt.function != nil {
+				functionRepo.functions["fn"] = tt.function
+			}
+			logGroupRepo := &fakeLogGroupRepository{}
+			stackRepo := &fakeStackRepository{functionNames: []string{"fn"}}
+			checkpoints := fakeCheckpointRepository{}
+			if tt.progress != nil {
+				checkpoints[key("fn")] = tt.progress
+			}
+
+			uc := NewDeleteStackFunctionsUseCase(functionRepo, logGroupRepo, stackRepo, io.Discard)
+			err := uc.Execute(context.Background(), &DeleteStackFunctionsInput{
+				StackName:   stackName,
+				DetachVPC:   true,
+				DisableIPv6: true,
+				DeleteLogs:  true,
+				Checkpoint:  NewCheckpointTracker(checkpoints, "123456789012", "us-east-1"),
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			calls := append(functionRepo.calls, logGroupRepo.calls...)
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, step := range []checkpoint.Step{checkpoint.StepDeleteFunction, checkpoint.StepDeleteLogs} {
+				if got := checkpoints[key("fn")][step]; got != checkpoint.StatusCompleted {
+					t.Errorf("checkpoint %s = %q, want completed", step, got)
+				}
+			}
+		})
+	}
+}
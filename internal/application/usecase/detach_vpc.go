@@ -3,30 +3,54 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/protection"
 )
 
 // DetachVPCUseCase handles detaching VPC from Lambda functions
 type DetachVPCUseCase struct {
 	functionRepo function.Repository
+	output       io.Writer
 }
 
 // DetachVPCInput represents the input for detaching VPC
 type DetachVPCInput struct {
 	FunctionName string
 	DisableIPv6  bool
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
 }
 
 // NewDetachVPCUseCase creates a new DetachVPCUseCase
-func NewDetachVPCUseCase(functionRepo function.Repository) *DetachVPCUseCase {
+func NewDetachVPCUseCase(functionRepo function.Repository, output io.Writer) *DetachVPCUseCase {
 	return &DetachVPCUseCase{
 		functionRepo: functionRepo,
+		output:       output,
 	}
 }
 
 // Execute executes the detach VPC use case
 func (uc *DetachVPCUseCase) Execute(ctx context.Context, input *DetachVPCInput) error {
+	if input.Guard != nil || input.DryRun {
+		fn, err := uc.functionRepo.FindByName(ctx, input.FunctionName)
+		if err != nil {
+			return err
+		}
+		if err := input.Guard.Check(uc.output, []*protection.Target{functionTarget(fn)}, input.DryRun); err != nil {
+			return err
+		}
+		if input.DryRun {
+			printDetachPlan(uc.output, fn, input.DisableIPv6)
+			return nil
+		}
+	}
+
 	// Disable IPv6 if requested
 	if input.DisableIPv6 {
 		if err := uc.functionRepo.DisableIPv6(ctx, input.FunctionName); err != nil {
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/protection"
+)
+
+// DetachVPCNetworkUseCase handles detaching VPC from all Lambda functions that
+// reference a given VPC, subnet or security group
+type DetachVPCNetworkUseCase struct {
+	functionRepo function.Repository
+	output       io.Writer
+}
+
+// DetachVPCNetworkInput contains the input parameters for detaching VPC by network resource.
+// Every non-empty field must match for a function to be selected.
+type DetachVPCNetworkInput struct {
+	VPCId           string
+	SubnetId        string
+	SecurityGroupId string
+	DisableIPv6     bool
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
+}
+
+// NewDetachVPCNetworkUseCase creates a new DetachVPCNetworkUseCase
+func NewDetachVPCNetworkUseCase(functionRepo function.Repository, output io.Writer) *DetachVPCNetworkUseCase {
+	return &DetachVPCNetworkUseCase{
+		functionRepo: functionRepo,
+		output:       output,
+	}
+}
+
+// Execute detaches VPC from all Lambda functions that reference the specified network resource
+func (uc *DetachVPCNetworkUseCase) Execute(ctx context.Context, input *DetachVPCNetworkInput) error {
+	if input.VPCId == "" && input.SubnetId == "" && input.SecurityGroupId == "" {
+		return fmt.Errorf("a VPC, subnet or security group must be specified")
+	}
+
+	// Get all Lambda functions, whether or not they belong to a stack
+	allFunctions, err := uc.functionRepo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list Lambda functions: %w", err)
+	}
+
+	var functions []*function.Function
+	for _, fn := range allFunctions {
+		if input.matches(fn) {
+			functions = append(functions, fn)
+		}
+	}
+
+	if len(functions) == 0 {
+		return fmt.Errorf("no Lambda functions found referencing %s", input.describe())
+	}
+
+	fmt.Fprintf(uc.output, "Found %d Lambda function(s) referencing %s\n", len(functions), input.describe())
+
+	// ListFunctions does not return tags, so look the functions up again for the protection rules
+	if input.Guard != nil {
+		targets := make([]*protection.Target, 0, len(functions))
+		for i, fn := range functions {
+			tagged, err := uc.functionRepo.FindByName(ctx, fn.Name())
+			if err != nil {
+				return fmt.Errorf("failed to get function for protection check: %w", err)
+			}
+			functions[i] = tagged
+			targets = append(targets, functionTarget(tagged))
+		}
+		if err := input.Guard.Check(uc.output, targets, input.DryRun); err != nil {
+			return err
+		}
+	}
+
+	// Detach VPC from each function
+	successCount := 0
+	failureCount := 0
+	for _, fn := range functions {
+		fmt.Fprintf(uc.output, "\nProcessing function: %s\n", fn.Name())
+
+		if input.DryRun {
+			printDetachPlan(uc.output, fn, input.DisableIPv6)
+			continue
+		}
+
+		if err := detachFunctionVPC(ctx, uc.functionRepo, fn, input.DisableIPv6, uc.output); err != nil {
+			failureCount++
+			continue
+		}
+		successCount++
+	}
+
+	if input.DryRun {
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	fmt.Fprintf(uc.output, "\n=== Summary ===\n")
+	fmt.Fprintf(uc.output, "Total functions: %d\n", len(functions))
+	fmt.Fprintf(uc.output, "Successfully processed: %d\n", successCount)
+	fmt.Fprintf(uc.output, "Failed: %d\n", failureCount)
+
+	if failureCount > 0 {
+		return fmt.Errorf("failed to process %d function(s)", failureCount)
+	}
+
+	return nil
+}
+
+// matches checks if the function references every network resource in the input
+func (in *DetachVPCNetworkInput) matches(fn *function.Function) bool {
+	if in.VPCId != "" && !fn.UsesVPC(in.VPCId) {
+		return false
+	}
+	if in.SubnetId != "" && !fn.UsesSubnet(in.SubnetId) {
+		return false
+	}
+	if in.SecurityGroupId != "" && !fn.UsesSecurityGroup(in.SecurityGroupId) {
+		return false
+	}
+	return true
+}
+
+// describe returns a human readable description of the network resources in the input
+func (in *DetachVPCNetworkInput) describe() string {
+	var parts []string
+	if in.VPCId != "" {
+		parts = append(parts, "VPC "+in.VPCId)
+	}
+	if in.SubnetId != "" {
+		parts = append(parts, "subnet "+in.SubnetId)
+	}
+	if in.SecurityGroupId != "" {
+		parts = append(parts, "security group "+in.SecurityGroupId)
+	}
+	return strings.Join(parts, " and ")
+}
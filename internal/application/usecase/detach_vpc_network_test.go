@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+)
+
+func TestDetachVPCNetworkInputMatches(t *testing.T) {
+	fn := function.NewFunction("api", "python3.12", "Active", &function.VPCConfig{
+		VPCId:            "vpc-1",
+		SubnetIds:        []string{"subnet-1", "subnet-2"},
+		SecurityGroupIds: []string{"sg-1"},
+	}, nil)
+
+	tests := []struct {
+		name  string
+		input DetachVPCNetworkInput
+		fn    *function.Function
+		want  bool
+	}{
+		{name: "VPC match", input: DetachVPCNetworkInput{VPCId: "vpc-1"}, fn: fn, want: true},
+		{name: "subnet match", input: DetachVPCNetworkInput{SubnetId: "subnet-2"}, fn: fn, want: true},
+		{name: "security group match", input: DetachVPCNetworkInput{SecurityGroupId: "sg-1"}, fn: fn, want: true},
+		{name: "every resource matches", input: DetachVPCNetworkInput{VPCId: "vpc-1", SubnetId: "subnet-1", SecurityGroupId: "sg-1"}, fn: fn, want: true},
+		{name: "no match", input: DetachVPCNetworkInput{VPCId: "vpc-2"}, fn: fn},
+		{name: "one resource does not match", input: DetachVPCNetworkInput{VPCId: "vpc-1", SecurityGroupId: "sg-2"}, fn: fn},
+		{name: "function without VPC", input: DetachVPCNetworkInput{SubnetId: "subnet-1"}, fn: function.NewFunction("worker", "python3.12", "Active", nil, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.input.matches(tt.fn); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetachVPCNetwork(t *testing.T) {
+	vpc := &function.VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-1"}, SecurityGroupIds: []string{"sg-1"}}
+	functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+		"api":    function.NewFunction("api", "python3.12", "Active", vpc, nil),
+		"worker": function.NewFunction("worker", "python3.12", "Active", nil, nil),
+	}}
+
+	err := NewDetachVPCNetworkUseCase(functionRepo, io.Discard).Execute(context.Background(), &DetachVPCNetworkInput{SecurityGroupId: "sg-1"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := []string{"detach-vpc api"}; !slices.Equal(functionRepo.calls, want) {
+		t.Errorf("calls = %v, want %v", functionRepo.calls, want)
+	}
+
+	if err := NewDetachVPCNetworkUseCase(functionRepo, io.Discard).Execute(context.Background(), &DetachVPCNetworkInput{SecurityGroupId: "sg-2"}); err == nil {
+		t.Error("Execute() without a matching function succeeded")
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/shirasu/delambda/internal/domain/function"
 	"github.com/shirasu/delambda/internal/domain/stack"
@@ -12,84 +13,83 @@ import (
 type DetachVPCStackUseCase struct {
 	functionRepo function.Repository
 	stackRepo    stack.Repository
+	output       io.Writer
 }
 
 // DetachVPCStackInput contains the input parameters for detaching VPC from stack functions
 type DetachVPCStackInput struct {
 	StackName   string
 	DisableIPv6 bool
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
 }
 
 // NewDetachVPCStackUseCase creates a new DetachVPCStackUseCase
-func NewDetachVPCStackUseCase(functionRepo function.Repository, stackRepo stack.Repository) *DetachVPCStackUseCase {
+func NewDetachVPCStackUseCase(functionRepo function.Repository, stackRepo stack.Repository, output io.Writer) *DetachVPCStackUseCase {
 	return &DetachVPCStackUseCase{
 		functionRepo: functionRepo,
 		stackRepo:    stackRepo,
+		output:       output,
 	}
 }
 
 // Execute detaches VPC from all Lambda functions in the specified stack
 func (uc *DetachVPCStackUseCase) Execute(ctx context.Context, input *DetachVPCStackInput) error {
 	// Get all Lambda functions in the stack
-	functionNames, err := uc.stackRepo.ListLambdaFunctions(ctx, input.StackName)
+	resources, err := uc.stackRepo.ListLambdaFunctions(ctx, input.StackName)
 	if err != nil {
 		return fmt.Errorf("failed to list Lambda functions in stack: %w", err)
 	}
 
-	if len(functionNames) == 0 {
+	if len(resources) == 0 {
 		return fmt.Errorf("no Lambda functions found in stack %s", input.StackName)
 	}
 
-	fmt.Printf("Found %d Lambda function(s) in stack %s\n", len(functionNames), input.StackName)
+	fmt.Fprintf(uc.output, "Found %d Lambda function(s) in stack %s\n", len(resources), input.StackName)
+
+	// Look up every function before modifying any, so protection rules can be checked
+	functions := resolveStackFunctions(ctx, uc.functionRepo, resources)
+	if err := input.Guard.Check(uc.output, stackFunctionTargets(functions, input.StackName), input.DryRun); err != nil {
+		return err
+	}
 
 	// Detach VPC from each function
 	successCount := 0
 	failureCount := 0
-	for _, functionName := range functionNames {
-		fmt.Printf("\nProcessing function: %s\n", functionName)
+	for _, r := range functions {
+		fmt.Fprintf(uc.output, "\nProcessing function: %s\n", describeFunctionResource(r.resource, input.StackName))
 
-		// Get the function
-		fn, err := uc.functionRepo.FindByName(ctx, functionName)
-		if err != nil {
-			fmt.Printf("  ❌ Failed to get function: %v\n", err)
+		if r.err != nil {
+			fmt.Fprintf(uc.output, "  ❌ Failed to get function: %v\n", r.err)
 			failureCount++
 			continue
 		}
 
-		// Check if function has VPC
-		if !fn.IsAttachedToVPC() {
-			fmt.Printf("  ⏭️  Function is not attached to VPC, skipping\n")
-			successCount++
+		if input.DryRun {
+			printDetachPlan(uc.output, r.function, input.DisableIPv6)
 			continue
 		}
 
-		// Disable IPv6 if requested and enabled
-		if input.DisableIPv6 && fn.HasIPv6Enabled() {
-			fmt.Printf("  Disabling IPv6...\n")
-			if err := uc.functionRepo.DisableIPv6(ctx, functionName); err != nil {
-				fmt.Printf("  ❌ Failed to disable IPv6: %v\n", err)
-				failureCount++
-				continue
-			}
-			fmt.Printf("  ✓ IPv6 disabled\n")
-		}
-
-		// Detach VPC
-		fmt.Printf("  Detaching VPC...\n")
-		if err := uc.functionRepo.DetachVPC(ctx, functionName); err != nil {
-			fmt.Printf("  ❌ Failed to detach VPC: %v\n", err)
+		if err := detachFunctionVPC(ctx, uc.functionRepo, r.function, input.DisableIPv6, uc.output); err != nil {
 			failureCount++
 			continue
 		}
-
-		fmt.Printf("  ✓ VPC detached successfully\n")
 		successCount++
 	}
 
-	fmt.Printf("\n=== Summary ===\n")
-	fmt.Printf("Total functions: %d\n", len(functionNames))
-	fmt.Printf("Successfully processed: %d\n", successCount)
-	fmt.Printf("Failed: %d\n", failureCount)
+	if input.DryRun {
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	fmt.Fprintf(uc.output, "\n=== Summary ===\n")
+	fmt.Fprintf(uc.output, "Total functions: %d\n", len(resources))
+	fmt.Fprintf(uc.output, "Successfully processed: %d\n", successCount)
+	fmt.Fprintf(uc.output, "Failed: %d\n", failureCount)
 
 	if failureCount > 0 {
 		return fmt.Errorf("failed to process %d function(s)", failureCount)
@@ -97,3 +97,42 @@ func (uc *DetachVPCStackUseCase) Execute(ctx context.Context, input *DetachVPCSt
 
 	return nil
 }
+
+// detachFunctionVPC runs the IPv6 disable and VPC detach sequence for a single function,
+// reporting progress to output. Functions that are not attached to a VPC are skipped.
+func detachFunctionVPC(ctx context.Context, functionRepo function.Repository, fn *function.Function, disableIPv6 bool, output io.Writer) error {
+	// Check if function has VPC
+	if !fn.IsAttachedToVPC() {
+		fmt.Fprintf(output, "  ⏭️  Function is not attached to VPC, skipping\n")
+		return nil
+	}
+
+	// Disable IPv6 if requested and enabled
+	if disableIPv6 && fn.HasIPv6Enabled() {
+		fmt.Fprintf(output, "  Disabling IPv6...\n")
+		if err := functionRepo.DisableIPv6(ctx, fn.Name()); err != nil {
+			fmt.Fprintf(output, "  ❌ Failed to disable IPv6: %v\n", err)
+			return err
+		}
+		fmt.Fprintf(output, "  ✓ IPv6 disabled\n")
+	}
+
+	// Detach VPC
+	fmt.Fprintf(output, "  Detaching VPC...\n")
+	if err := functionRepo.DetachVPC(ctx, fn.Name()); err != nil {
+		fmt.Fprintf(output, "  ❌ Failed to detach VPC: %v\n", err)
+		return err
+	}
+
+	fmt.Fprintf(output, "  ✓ VPC detached successfully\n")
+	return nil
+}
+
+// describeFunctionResource returns the function name, followed by the owning
+// stack path when the function lives in a nested stack
+func describeFunctionResource(resource *stack.FunctionResource, stackName string) string {
+	if resource.StackPath() == stackName {
+		return resource.Name()
+	}
+	return fmt.Sprintf("%s (%s)", resource.Name(), resource.StackPath())
+}
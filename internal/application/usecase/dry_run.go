@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/protection"
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// printDetachPlan reports what detaching VPC from fn would do, without doing it
+func printDetachPlan(output io.Writer, fn *function.Function, disableIPv6 bool) {
+	if !fn.IsAttachedToVPC() {
+		fmt.Fprintf(output, "  [dry-run] Not attached to VPC, nothing to detach\n")
+		return
+	}
+	if disableIPv6 && fn.HasIPv6Enabled() {
+		fmt.Fprintf(output, "  [dry-run] Would disable IPv6\n")
+	}
+	vpcConfig := fn.VPCConfig()
+	fmt.Fprintf(output, "  [dry-run] Would detach VPC %s (%d subnet(s), %d security group(s))\n",
+		vpcConfig.VPCId, len(vpcConfig.SubnetIds), len(vpcConfig.SecurityGroupIds))
+}
+
+// printDeletePlan reports what deleting fn would do, without doing it
+func printDeletePlan(output io.Writer, fn *function.Function, detachVPC, disableIPv6, deleteLogs bool, logsRetentionDays int) {
+	if detachVPC {
+		printDetachPlan(output, fn, disableIPv6)
+	}
+	fmt.Fprintf(output, "  [dry-run] Would delete function %s\n", fn.Name())
+	switch {
+	case logsRetentionDays > 0:
+		fmt.Fprintf(output, "  [dry-run] Would set the retention of CloudWatch Logs log group %s to %d days\n", functionLogGroup(fn).Name(), logsRetentionDays)
+	case deleteLogs:
+		fmt.Fprintf(output, "  [dry-run] Would delete CloudWatch Logs log group %s\n", loggroup.NewLogGroupForFunction(fn.Name()).Name())
+		printKeptLogGroup(output, fn, "  [dry-run] Would keep CloudWatch Logs log group %s of the logging configuration, which other functions may share\n")
+	}
+}
+
+// printDryRunFooter ends the output of a dry run
+func printDryRunFooter(output io.Writer) {
+	fmt.Fprintf(output, "\n=== Dry run: no changes were made ===\n")
+}
+
+// resolvedFunction is a function resource of a stack together with the
+// function, or the error from looking it up
+type resolvedFunction struct {
+	resource *stack.FunctionResource
+	function *function.Function
+	err      error
+}
+
+// resolveStackFunctions looks up every function resource of a stack up front,
+// so the protection rules can be evaluated before anything is modified
+func resolveStackFunctions(ctx context.Context, functionRepo function.Repository, resources []*stack.FunctionResource) []*resolvedFunction {
+	resolved := make([]*resolvedFunction, 0, len(resources))
+	for _, resource := range resources {
+		fn, err := functionRepo.FindByName(ctx, resource.Name())
+		resolved = append(resolved, &resolvedFunction{
+			resource: resource,
+			function: fn,
+			err:      err,
+		})
+	}
+	return resolved
+}
+
+// stackFunctionTargets returns the protection targets of the functions that
+// could be looked up. Functions that could not be looked up are not modified.
+func stackFunctionTargets(resolved []*resolvedFunction, stackName string) []*protection.Target {
+	var targets []*protection.Target
+	for _, r := range resolved {
+		if r.err == nil {
+			targets = append(targets, functionTarget(r.function, stackName))
+		}
+	}
+	return targets
+}
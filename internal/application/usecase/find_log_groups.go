@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+// FindLogGroupsUseCase handles finding log groups by name or name prefix,
+// so they can be shown before they are deleted
+type FindLogGroupsUseCase struct {
+	logGroupRepo loggroup.Repository
+}
+
+// FindLogGroupsInput contains the input parameters for finding log groups.
+// Either Names or Prefix is set.
+type FindLogGroupsInput struct {
+	// Names are full log group names, each matching only itself
+	Names []string
+
+	// Prefix matches every log group whose name starts with it. It must start
+	// with /aws/lambda/ followed by the start of a function name.
+	Prefix string
+}
+
+// FoundLogGroups are the log groups found by FindLogGroupsUseCase
+type FoundLogGroups struct {
+	// LogGroups are the matching log groups, with their usage
+	LogGroups []*loggroup.LogGroup
+
+	// Missing are the names of FindLogGroupsInput.Names that do not exist
+	Missing []string
+}
+
+// NewFindLogGroupsUseCase creates a new FindLogGroupsUseCase
+func NewFindLogGroupsUseCase(logGroupRepo loggroup.Repository) *FindLogGroupsUseCase {
+	return &FindLogGroupsUseCase{
+		logGroupRepo: logGroupRepo,
+	}
+}
+
+// Execute returns the log groups with the given names, in the order given,
+// or those with the given prefix, sorted by name
+func (uc *FindLogGroupsUseCase) Execute(ctx context.Context, input *FindLogGroupsInput) (*FoundLogGroups, error) {
+	found := &FoundLogGroups{}
+	if input.Prefix != "" {
+		if err := loggroup.ValidatePrefix(input.Prefix); err != nil {
+			return nil, err
+		}
+		logGroups, err := uc.logGroupRepo.FindByPrefix(ctx, input.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		found.LogGroups = logGroups
+		return found, nil
+	}
+
+	for _, name := range input.Names {
+		// A name is its own prefix, which also matches longer names
+		logGroups, err := uc.logGroupRepo.FindByPrefix(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		exists := false
+		for _, lg := range logGroups {
+			if lg.Name() == name {
+				found.LogGroups = append(found.LogGroups, lg)
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			found.Missing = append(found.Missing, name)
+		}
+	}
+	return found, nil
+}
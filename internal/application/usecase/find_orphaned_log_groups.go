@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+// OrphanedLogGroup is a log group named after a Lambda function that no longer exists
+type OrphanedLogGroup struct {
+	LogGroup *loggroup.LogGroup
+
+	// FunctionName is the function the log group was created for
+	FunctionName string
+
+	// Region is the region of the function of a Lambda@Edge replica log group, empty for others
+	Region string
+
+	// LastEventTime is when the latest event was ingested, the zero time if it has none
+	LastEventTime time.Time
+}
+
+// FindOrphanedLogGroupsUseCase handles finding the log groups of deleted
+// Lambda functions, which Lambda leaves behind when a function is deleted
+type FindOrphanedLogGroupsUseCase struct {
+	logGroupRepo  loggroup.Repository
+	functionRepo  function.Repository
+	functionRepos func(region string) (function.Repository, error)
+}
+
+// FindOrphanedLogGroupsInput contains the input parameters for finding orphaned log groups
+type FindOrphanedLogGroupsInput struct {
+	// Region is the region of the log groups and of the function repository
+	Region string
+}
+
+// NewFindOrphanedLogGroupsUseCase creates a new FindOrphanedLogGroupsUseCase.
+// functionRepo lists the functions of the region of the log groups and
+// functionRepos returns the repository of another region, where the functions
+// of Lambda@Edge replicas live.
+func NewFindOrphanedLogGroupsUseCase(logGroupRepo loggroup.Repository, functionRepo function.Repository, functionRepos func(region string) (function.Repository, error)) *FindOrphanedLogGroupsUseCase {
+	return &FindOrphanedLogGroupsUseCase{
+		logGroupRepo:  logGroupRepo,
+		functionRepo:  functionRepo,
+		functionRepos: functionRepos,
+	}
+}
+
+// Execute returns the log groups under /aws/lambda/ whose function does not
+// exist, sorted by name. A log group a function is configured to log to is
+// kept whatever its name, and so are log groups not named after a function.
+func (uc *FindOrphanedLogGroupsUseCase) Execute(ctx context.Context, input *FindOrphanedLogGroupsInput) ([]*OrphanedLogGroup, error) {
+	logGroups, err := uc.logGroupRepo.FindByPrefix(ctx, loggroup.LambdaPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	functions, err := uc.functionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	inUse := make(map[string]bool, len(functions))
+	for _, fn := range functions {
+		if fn.LogGroup() != "" {
+			inUse[fn.LogGroup()] = true
+		}
+	}
+	existing := map[string]map[string]bool{input.Region: functionNames(functions)}
+
+	orphans := []*OrphanedLogGroup{}
+	for _, lg := range logGroups {
+		functionName, region, ok := lg.Function()
+		if !ok || inUse[lg.Name()] {
+			continue
+		}
+
+		functionRegion := region
+		if functionRegion == "" {
+			functionRegion = input.Region
+		}
+		names, listed := existing[functionRegion]
+		if !listed {
+			names, err = uc.regionFunctionNames(ctx, functionRegion)
+			if err != nil {
+				return nil, err
+			}
+			existing[functionRegion] = names
+		}
+		if names[functionName] {
+			continue
+		}
+
+		lastEventTime, err := uc.logGroupRepo.LastEventTime(ctx, lg)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, &OrphanedLogGroup{
+			LogGroup:      lg,
+			FunctionName:  functionName,
+			Region:        region,
+			LastEventTime: lastEventTime,
+		})
+	}
+
+	slices.SortFunc(orphans, func(a, b *OrphanedLogGroup) int {
+		return strings.Compare(a.LogGroup.Name(), b.LogGroup.Name())
+	})
+	return orphans, nil
+}
+
+// regionFunctionNames returns the names of the functions of another region
+func (uc *FindOrphanedLogGroupsUseCase) regionFunctionNames(ctx context.Context, region string) (map[string]bool, error) {
+	if uc.functionRepos == nil {
+		return nil, fmt.Errorf("cannot list functions in %s", region)
+	}
+	functionRepo, err := uc.functionRepos(region)
+	if err != nil {
+		return nil, err
+	}
+	functions, err := functionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions in %s: %w", region, err)
+	}
+	return functionNames(functions), nil
+}
+
+// functionNames returns the set of the names of functions
+func functionNames(functions []*function.Function) map[string]bool {
+	names := make(map[string]bool, len(functions))
+	for _, fn := range functions {
+		names[fn.Name()] = true
+	}
+	return names
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+// listingLogGroupRepository lists the given log groups and records deletions
+type listingLogGroupRepository struct {
+	fakeLogGroupRepository
+	logGroups  []*loggroup.LogGroup
+	lastEvents map[string]time.Time
+}
+
+func (r *listingLogGroupRepository) FindByPrefix(ctx context.Context, prefix string) ([]*loggroup.LogGroup, error) {
+	var logGroups []*loggroup.LogGroup
+	for _, lg := range r.logGroups {
+		if strings.HasPrefix(lg.Name(), prefix) {
+			logGroups = append(logGroups, lg)
+		}
+	}
+	return logGroups, nil
+}
+
+func (r *listingLogGroupRepository) LastEventTime(ctx context.Context, logGroup *loggroup.LogGroup) (time.Time, error) {
+	return r.lastEvents[logGroup.Name()], nil
+}
+
+func TestFindOrphanedLogGroups(t *testing.T) {
+	lastEvent := time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC)
+	logGroupRepo := &listingLogGroupRepository{
+		logGroups: []*loggroup.LogGroup{
+			loggroup.NewLogGroup("/aws/lambda/api"),
+			loggroup.NewLogGroup("/aws/lambda/deleted").WithUsage(2048, lastEvent.AddDate(-1, 0, 0)),
+			loggroup.NewLogGroup("/aws/lambda/shared"),
+			loggroup.NewLogGroup("/aws/lambda/custom/path"),
+			loggroup.NewLogGroup("/aws/lambda/us-east-1.edge"),
+			loggroup.NewLogGroup("/aws/lambda/us-east-1.deleted-edge"),
+			loggroup.NewLogGroup("/aws/lambda/eu-west-1.deleted-local-edge"),
+			loggroup.NewLogGroup("/aws/apigateway/deleted"),
+		},
+		lastEvents: map[string]time.Time{"/aws/lambda/deleted": lastEvent},
+	}
+	functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+		"api":    function.NewFunction("api", "", "", nil, nil),
+		"worker": function.NewFunction("worker", "", "", nil, nil).WithLogGroup("/aws/lambda/shared"),
+	}}
+	edgeRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+		"edge": function.NewFunction("edge", "", "", nil, nil),
+	}}
+	var regions []string
+	functionRepos := func(region string) (function.Repository, error) {
+		regions = append(regions, region)
+		if region != "us-east-1" {
+			return nil, errors.New("unexpected region " + region)
+		}
+		return edgeRepo, nil
+	}
+
+	orphans, err := NewFindOrphanedLogGroupsUseCase(logGroupRepo, functionRepo, functionRepos).Execute(context.Background(), &FindOrphanedLogGroupsInput{Region: "eu-west-1"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var got []string
+	for _, orphan := range orphans {
+		got = append(got, orphan.Region+"|"+orphan.FunctionName+"|"+orphan.LogGroup.Name())
+	}
+	want := []string{
+		"|deleted|/aws/lambda/deleted",
+		"eu-west-1|deleted-local-edge|/aws/lambda/eu-west-1.deleted-local-edge",
+		"us-east-1|deleted-edge|/aws/lambda/us-east-1.deleted-edge",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(regions, []string{"us-east-1"}) {
+		t.Errorf("Execute() listed functions in %v, want only us-east-1 besides the own region", regions)
+	}
+	if orphans[0].LogGroup.StoredBytes() != 2048 || !orphans[0].LastEventTime.Equal(lastEvent) {
+		t.Errorf("Execute() usage = %d bytes, last event %v", orphans[0].LogGroup.StoredBytes(), orphans[0].LastEventTime)
+	}
+}
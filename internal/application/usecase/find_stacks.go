@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirasu/delambda/internal/domain/stack"
+)
+
+// FindStacksUseCase handles selecting stacks by name pattern and tags
+type FindStacksUseCase struct {
+	stackRepo stack.Repository
+}
+
+// FindStacksInput contains the criteria for selecting stacks.
+// Every criterion that is set must match for a stack to be selected.
+type FindStacksInput struct {
+	NamePattern string
+	Tags        map[string]string
+}
+
+// NewFindStacksUseCase creates a new FindStacksUseCase
+func NewFindStacksUseCase(stackRepo stack.Repository) *FindStacksUseCase {
+	return &FindStacksUseCase{
+		stackRepo: stackRepo,
+	}
+}
+
+// Execute returns all stacks matching the input criteria
+func (uc *FindStacksUseCase) Execute(ctx context.Context, input *FindStacksInput) ([]*stack.Stack, error) {
+	stacks, err := uc.stackRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	var matched []*stack.Stack
+	for _, st := range stacks {
+		if input.NamePattern != "" && !st.MatchesName(input.NamePattern) {
+			continue
+		}
+		if !st.HasTags(input.Tags) {
+			continue
+		}
+		matched = append(matched, st)
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/invocation"
+)
+
+// FindStaleFunctionsUseCase handles finding Lambda functions that were neither
+// changed nor invoked for a number of days, as candidates for deletion
+type FindStaleFunctionsUseCase struct {
+	functionRepo   function.Repository
+	invocationRepo invocation.Repository
+}
+
+// FindStaleFunctionsInput contains the input parameters for finding stale functions
+type FindStaleFunctionsInput struct {
+	// Days is how long a function must have gone without changes and invocations
+	Days int
+
+	// Now is the end of the window, the current time if zero
+	Now time.Time
+}
+
+// NewFindStaleFunctionsUseCase creates a new FindStaleFunctionsUseCase
+func NewFindStaleFunctionsUseCase(functionRepo function.Repository, invocationRepo invocation.Repository) *FindStaleFunctionsUseCase {
+	return &FindStaleFunctionsUseCase{
+		functionRepo:   functionRepo,
+		invocationRepo: invocationRepo,
+	}
+}
+
+// Execute returns the functions last modified before the window that have no
+// invocations during it, least recently modified first and with their tags, so
+// their stacks are known. A function whose last modification is unknown is
+// judged by its invocations alone.
+func (uc *FindStaleFunctionsUseCase) Execute(ctx context.Context, input *FindStaleFunctionsInput) ([]*function.Function, error) {
+	if input.Days <= 0 {
+		return nil, fmt.Errorf("days must be positive, got %d", input.Days)
+	}
+	end := input.Now
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := end.AddDate(0, 0, -input.Days)
+
+	functions, err := uc.functionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+
+	// Functions changed during the window are in use, whatever their invocations
+	var names []string
+	for _, fn := range functions {
+		if fn.LastModified().Before(start) {
+			names = append(names, fn.Name())
+		}
+	}
+	if len(names) == 0 {
+		return []*function.Function{}, nil
+	}
+
+	invocations, err := uc.invocationRepo.SumByFunction(ctx, names, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make([]*function.Function, 0, len(names))
+	for _, name := range names {
+		if invocations[name] > 0 {
+			continue
+		}
+		// Listed functions have no tags, so fetch each candidate for its stack
+		fn, err := uc.functionRepo.FindByName(ctx, name)
+		if errors.Is(err, function.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stale = append(stale, fn)
+	}
+
+	slices.SortStableFunc(stale, func(a, b *function.Function) int {
+		return a.LastModified().Compare(b.LastModified())
+	})
+	return stale, nil
+}
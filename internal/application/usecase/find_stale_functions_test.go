@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+)
+
+// fakeInvocationRepository returns the given invocation counts
+type fakeInvocationRepository struct {
+	invocations map[string]int64
+	queried     []string
+}
+
+func (r *fakeInvocationRepository) SumByFunction(ctx context.Context, functionNames []string, start, end time.Time) (map[string]int64, error) {
+	r.queried = append(r.queried, functionNames...)
+	sums := make(map[string]int64)
+	for _, name := range functionNames {
+		sums[name] = r.invocations[name]
+	}
+	return sums, nil
+}
+
+func TestFindStaleFunctions(t *testing.T) {
+	now := time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC)
+	newFunction := func(name string, age time.Duration) *function.Function {
+		return function.NewFunction(name, "", "", nil, map[string]string{"aws:cloudformation:stack-name": "app"}).WithLastModified(now.Add(-age))
+	}
+	day := 24 * time.Hour
+
+	functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+		"recently-deployed": newFunction("recently-deployed", 10*day),
+		"invoked":           newFunction("invoked", 200*day),
+		"stale":             newFunction("stale", 100*day),
+		"stalest":           newFunction("stalest", 400*day),
+	}}
+	invocationRepo := &fakeInvocationRepository{invocations: map[string]int64{"invoked": 3}}
+
+	stale, err := NewFindStaleFunctionsUseCase(functionRepo, invocationRepo).Execute(context.Background(), &FindStaleFunctionsInput{Days: 90, Now: now})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var names []string
+	for _, fn := range stale {
+		names = append(names, fn.Name())
+		if fn.StackName() != "app" {
+			t.Errorf("stack of %s = %q, want app", fn.Name(), fn.StackName())
+		}
+	}
+	if want := []string{"stalest", "stale"}; !slices.Equal(names, want) {
+		t.Errorf("Execute() = %v, want %v", names, want)
+	}
+	if slices.Contains(invocationRepo.queried, "recently-deployed") {
+		t.Error("Execute() queried the invocations of a function modified during the window")
+	}
+
+	if _, err := NewFindStaleFunctionsUseCase(functionRepo, invocationRepo).Execute(context.Background(), &FindStaleFunctionsInput{}); err == nil {
+		t.Error("Execute() without days succeeded")
+	}
+}
@@ -14,6 +14,12 @@ type ListStackFunctionsUseCase struct {
 	stackRepo    stack.Repository
 }
 
+// StackFunction is a Lambda function together with the path of the stack that owns it
+type StackFunction struct {
+	Function  *function.Function
+	StackPath string
+}
+
 // NewListStackFunctionsUseCase creates a new ListStackFunctionsUseCase
 func NewListStackFunctionsUseCase(functionRepo function.Repository, stackRepo stack.Repository) *ListStackFunctionsUseCase {
 	return &ListStackFunctionsUseCase{
@@ -22,26 +28,29 @@ func NewListStackFunctionsUseCase(functionRepo function.Repository, stackRepo st
 	}
 }
 
-// Execute lists all Lambda functions in the specified CloudFormation stack
-func (uc *ListStackFunctionsUseCase) Execute(ctx context.Context, stackName string) ([]*function.Function, error) {
-	// Get all Lambda function names in the stack
-	functionNames, err := uc.stackRepo.ListLambdaFunctions(ctx, stackName)
+// Execute lists all Lambda functions in the specified CloudFormation stack and its nested stacks
+func (uc *ListStackFunctionsUseCase) Execute(ctx context.Context, stackName string) ([]*StackFunction, error) {
+	// Get all Lambda functions in the stack
+	resources, err := uc.stackRepo.ListLambdaFunctions(ctx, stackName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list Lambda functions in stack: %w", err)
 	}
 
-	if len(functionNames) == 0 {
-		return []*function.Function{}, nil
+	if len(resources) == 0 {
+		return []*StackFunction{}, nil
 	}
 
 	// Fetch details for each function
-	functions := make([]*function.Function, 0, len(functionNames))
-	for _, functionName := range functionNames {
-		fn, err := uc.functionRepo.FindByName(ctx, functionName)
+	functions := make([]*StackFunction, 0, len(resources))
+	for _, resource := range resources {
+		fn, err := uc.functionRepo.FindByName(ctx, resource.Name())
 		if err != nil {
-			return nil, fmt.Errorf("failed to get function %s: %w", functionName, err)
+			return nil, fmt.Errorf("failed to get function %s: %w", resource.Name(), err)
 		}
-		functions = append(functions, fn)
+		functions = append(functions, &StackFunction{
+			Function:  fn,
+			StackPath: resource.StackPath(),
+		})
 	}
 
 	return functions, nil
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/protection"
+)
+
+// ProtectionGuard evaluates the protection rules before a use case modifies anything.
+// A nil guard allows everything.
+type ProtectionGuard struct {
+	rules      *protection.Rules
+	accountID  string
+	breakGlass bool
+}
+
+// NewProtectionGuard creates a new ProtectionGuard for the given account.
+// breakGlass overrides the production account rule only.
+func NewProtectionGuard(rules *protection.Rules, accountID string, breakGlass bool) *ProtectionGuard {
+	return &ProtectionGuard{
+		rules:      rules,
+		accountID:  accountID,
+		breakGlass: breakGlass,
+	}
+}
+
+// Check evaluates the rules for every target and reports the violations to output.
+// In a dry run the violations are only reported; otherwise an error wrapping
+// protection.ErrProtected is returned if there is any.
+func (g *ProtectionGuard) Check(output io.Writer, targets []*protection.Target, dryRun bool) error {
+	if g == nil {
+		return nil
+	}
+
+	var violations []*protection.Violation
+	if v := g.rules.EvaluateAccount(g.accountID, g.breakGlass); v != nil {
+		violations = append(violations, v)
+	}
+	for _, target := range targets {
+		violations = append(violations, g.rules.Evaluate(target)...)
+	}
+
+	if g.breakGlass && g.rules.IsProductionAccount(g.accountID) {
+		fmt.Fprintf(output, "⚠️  Break glass: allowing changes in production account %s\n", g.accountID)
+	}
+
+	if len(violations) == 0 {
+		if dryRun {
+			fmt.Fprintf(output, "🛡️  No protection rule violations\n")
+		}
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(output, "🛡️  Protection rule violations (the real run will refuse):\n")
+	} else {
+		fmt.Fprintf(output, "🛡️  Refusing to continue, protection rules block this operation:\n")
+	}
+	for _, v := range violations {
+		line := fmt.Sprintf("  ❌ %s", v)
+		if v.IsOverridable() {
+			line += " (override with --break-glass)"
+		}
+		fmt.Fprintln(output, line)
+	}
+
+	if dryRun {
+		return nil
+	}
+	fmt.Fprintf(output, "No changes were made. Remove the protection from the config file or the resource to proceed.\n")
+	return fmt.Errorf("%w: %d violation(s)", protection.ErrProtected, len(violations))
+}
+
+// functionTarget returns the protection target for fn. stackNames are the
+// stacks the function was selected through, in addition to the stack that created it.
+func functionTarget(fn *function.Function, stackNames ...string) *protection.Target {
+	return protection.NewFunctionTarget(fn.Name(), fn.Tags(), append(slices.Clone(stackNames), fn.StackName())...)
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/protection"
+)
+
+// SetLogRetentionUseCase handles setting the retention of the log groups of
+// the Lambda functions in a stack, so their logs are kept for a while but not forever
+type SetLogRetentionUseCase struct {
+	logGroupRepo loggroup.Repository
+	output       io.Writer
+}
+
+// SetLogRetentionInput contains the input parameters for setting log retention
+type SetLogRetentionInput struct {
+	// StackName is the stack the functions were selected through
+	StackName string
+
+	Functions []*StackFunction
+
+	// Days is how long CloudWatch Logs keeps the events of the log groups
+	Days int
+
+	// Guard evaluates the protection rules before anything is modified
+	Guard *ProtectionGuard
+
+	// DryRun reports what would be done and any protection rule violations without modifying anything
+	DryRun bool
+}
+
+// NewSetLogRetentionUseCase creates a new SetLogRetentionUseCase
+func NewSetLogRetentionUseCase(logGroupRepo loggroup.Repository, output io.Writer) *SetLogRetentionUseCase {
+	return &SetLogRetentionUseCase{
+		logGroupRepo: logGroupRepo,
+		output:       output,
+	}
+}
+
+// Execute sets the retention of the log group of every function, the one its
+// logging configuration names or /aws/lambda/<name>, continuing past failures.
+// A log group shared by several functions is changed once.
+func (uc *SetLogRetentionUseCase) Execute(ctx context.Context, input *SetLogRetentionInput) error {
+	if err := loggroup.ValidateRetention(input.Days); err != nil {
+		return err
+	}
+
+	targets := make([]*protection.Target, 0, len(input.Functions))
+	var logGroups []*loggroup.LogGroup
+	seen := make(map[string]bool)
+	for _, sf := range input.Functions {
+		targets = append(targets, functionTarget(sf.Function, input.StackName))
+
+		logGroup := functionLogGroup(sf.Function)
+		if !seen[logGroup.Name()] {
+			seen[logGroup.Name()] = true
+			logGroups = append(logGroups, logGroup)
+		}
+	}
+	if err := input.Guard.Check(uc.output, targets, input.DryRun); err != nil {
+		return err
+	}
+
+	if input.DryRun {
+		for _, lg := range logGroups {
+			fmt.Fprintf(uc.output, "  [dry-run] Would set the retention of CloudWatch Logs log group %s to %d days\n", lg.Name(), input.Days)
+		}
+		printDryRunFooter(uc.output)
+		return nil
+	}
+
+	failed := 0
+	for _, lg := range logGroups {
+		if err := setLogRetention(ctx, uc.logGroupRepo, uc.output, lg, input.Days); err != nil {
+			fmt.Fprintf(uc.output, "❌ %v\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d log group(s) failed", failed, len(logGroups))
+	}
+	return nil
+}
+
+// functionLogGroup returns the log group fn sends its logs to: the one its
+// logging configuration names, or /aws/lambda/<name>
+func functionLogGroup(fn *function.Function) *loggroup.LogGroup {
+	if name := fn.LogGroup(); name != "" {
+		return loggroup.NewLogGroup(name)
+	}
+	return loggroup.NewLogGroupForFunction(fn.Name())
+}
+
+// printKeptLogGroup notes that deleting fn leaves the log group its logging
+// configuration names in place. Such a log group may be shared with other
+// functions, so deleting logs only ever deletes /aws/lambda/<name>.
+func printKeptLogGroup(output io.Writer, fn *function.Function, format string) {
+	if fn == nil {
+		return
+	}
+	// Lambda reports /aws/lambda/<name> in the logging configuration of a function that does not name one
+	if logGroup := functionLogGroup(fn); logGroup.Name() != loggroup.NewLogGroupForFunction(fn.Name()).Name() {
+		fmt.Fprintf(output, format, logGroup.Name())
+	}
+}
+
+// setLogRetention sets the retention of a log group, skipping a log group
+// that does not exist, as for a function that was never invoked
+func setLogRetention(ctx context.Context, logGroupRepo loggroup.Repository, output io.Writer, logGroup *loggroup.LogGroup, days int) error {
+	fmt.Fprintf(output, "Setting the retention of CloudWatch Logs log group %s to %d days...\n", logGroup.Name(), days)
+	err := logGroupRepo.SetRetention(ctx, logGroup, days)
+	if errors.Is(err, loggroup.ErrNotFound) {
+		fmt.Fprintf(output, "Log group %s does not exist, skipping retention\n", logGroup.Name())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set log retention: %w", err)
+	}
+	fmt.Fprintf(output, "Set the retention of CloudWatch Logs log group %s to %d days\n", logGroup.Name(), days)
+	return nil
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+)
+
+func TestSetLogRetention(t *testing.T) {
+	functions := []*StackFunction{
+		{Function: function.NewFunction("api", "", "", nil, nil)},
+		{Function: function.NewFunction("worker", "", "", nil, nil).WithLogGroup("/app/shared")},
+		{Function: function.NewFunction("cron", "", "", nil, nil).WithLogGroup("/app/shared")},
+		{Function: function.NewFunction("never-invoked", "", "", nil, nil)},
+	}
+
+	tests := []struct {
+		name      string
+		days      int
+		dryRun    bool
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			// A shared log group is changed once, and a missing one is skipped
+			name:      "sets the retention of every log group",
+			days:      30,
+			wantCalls: []string{"set-retention /aws/lambda/api 30", "set-retention /app/shared 30"},
+		},
+		{
+			name:   "dry run changes nothing",
+			days:   30,
+			dryRun: true,
+		},
+		{
+			name:    "unsupported retention",
+			days:    31,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logGroupRepo := &fakeLogGroupRepository{missing: []string{"/aws/lambda/never-invoked"}}
+			err := NewSetLogRetentionUseCase(logGroupRepo, io.Discard).Execute(context.Background(), &SetLogRetentionInput{
+				StackName: "app",
+				Functions: functions,
+				Days:      tt.days,
+				DryRun:    tt.dryRun,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(logGroupRepo.calls, tt.wantCalls) {
+				t.Errorf("Execute() calls = %v, want %v", logGroupRepo.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDeleteFunctionLogsRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		function  *function.Function
+		wantCalls []string
+	}{
+		{
+			name:      "default log group",
+			function:  function.NewFunction("fn", "", "", nil, nil),
+			wantCalls: []string{"delete fn", "set-retention /aws/lambda/fn 14"},
+		},
+		{
+			name:      "log group of the logging configuration",
+			function:  function.NewFunction("fn", "", "", nil, nil).WithLogGroup("/app/shared"),
+			wantCalls: []string{"delete fn", "set-retention /app/shared 14"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{"fn": tt.function}}
+			logGroupRepo := &fakeLogGroupRepository{}
+
+			// The retention takes the place of deleting the log group
+			err := NewDeleteFunctionUseCase(functionRepo, logGroupRepo, io.Discard).Execute(context.Background(), &DeleteFunctionInput{
+				FunctionName:      "fn",
+				DeleteLogs:        true,
+				LogsRetentionDays: 14,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			calls := append(functionRepo.calls, logGroupRepo.calls...)
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDeleteStackFunctionsLogsRetention(t *testing.T) {
+	functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+		"api":    function.NewFunction("api", "", "", nil, nil),
+		"worker": function.NewFunction("worker", "", "", nil, nil).WithLogGroup("/app/shared"),
+	}}
+	logGroupRepo := &fakeLogGroupRepository{}
+	stackRepo := &fakeStackRepository{functionNames: []string{"api", "worker"}}
+
+	uc := NewDeleteStackFunctionsUseCase(functionRepo, logGroupRepo, stackRepo, io.Discard)
+	err := uc.Execute(context.Background(), &DeleteStackFunctionsInput{
+		StackName:         "app",
+		DeleteLogs:        true,
+		LogsRetentionDays: 14,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := []string{"set-retention /aws/lambda/api 14", "set-retention /app/shared 14"}
+	if !slices.Equal(logGroupRepo.calls, want) {
+		t.Errorf("calls = %v, want %v", logGroupRepo.calls, want)
+	}
+}
+
+func TestDeleteFunctionKeepsLogGroupOfLoggingConfiguration(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantCalls  []string
+		wantOutput string
+	}{
+		{
+			name:       "delete",
+			wantCalls:  []string{"delete fn", "delete-logs /aws/lambda/fn"},
+			wantOutput: "Keeping CloudWatch Logs log group /app/shared of the logging configuration",
+		},
+		{
+			name:       "dry run",
+			dryRun:     true,
+			wantOutput: "[dry-run] Would keep CloudWatch Logs log group /app/shared of the logging configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functionRepo := &fakeFunctionRepository{functions: map[string]*function.Function{
+				"fn": function.NewFunction("fn", "", "", nil, nil).WithLogGroup("/app/shared"),
+			}}
+			logGroupRepo := &fakeLogGroupRepository{}
+			var out bytes.Buffer
+
+			err := NewDeleteFunctionUseCase(functionRepo, logGroupRepo, &out).Execute(context.Background(), &DeleteFunctionInput{
+				FunctionName: "fn",
+				DeleteLogs:   true,
+				DryRun:       tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			calls := append(functionRepo.calls, logGroupRepo.calls...)
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("output does not contain %q:\n%s", tt.wantOutput, out.String())
+			}
+		})
+	}
+}
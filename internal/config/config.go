@@ -0,0 +1,374 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RepoFileName is the name of the per-repository config file
+	RepoFileName = ".delambda.yaml"
+
+	// OutputText is the default human readable output format
+	OutputText = "text"
+
+	// OutputJSON prints one JSON object per line
+	OutputJSON = "json"
+)
+
+const (
+	// RetryModeStandard retries failed API calls with exponential backoff
+	RetryModeStandard = "standard"
+
+	// RetryModeAdaptive additionally slows down requests while the API is throttling
+	RetryModeAdaptive = "adaptive"
+)
+
+// accountIDPattern matches a 12-digit AWS account ID
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// Settings holds the defaults for command flags. Zero values mean "not set".
+type Settings struct {
+	Region      string        `yaml:"region"`
+	Profile     string        `yaml:"profile"`
+	RoleARN     string        `yaml:"role-arn"`
+	ExternalID  string        `yaml:"external-id"`
+	Concurrency int           `yaml:"concurrency"`
+	DeleteLogs  *bool         `yaml:"delete-logs"`
+	WaitTimeout time.Duration `yaml:"wait-timeout"`
+	Output      string        `yaml:"output"`
+	AuditLog    string        `yaml:"audit-log"`
+	MaxRetries  *int          `yaml:"max-retries"`
+	RetryMode   string        `yaml:"retry-mode"`
+	MaxTPS      *float64      `yaml:"max-tps"`
+	Protection  Protection    `yaml:"protection"`
+}
+
+// Protection lists the functions, stacks and accounts that must not be modified.
+// Protection rules from every file and the selected context are combined, so a
+// per-repo file or context can add rules but never remove them.
+type Protection struct {
+	// Tags protects functions and stacks carrying any of these tags
+	Tags map[string]string `yaml:"tags"`
+
+	// Names protects functions whose name matches any of these glob patterns
+	Names []string `yaml:"names"`
+
+	// Stacks protects stacks matching any of these glob patterns and their functions
+	Stacks []string `yaml:"stacks"`
+
+	// ProductionAccounts blocks any action in these accounts unless --break-glass is given
+	ProductionAccounts []string `yaml:"production-accounts"`
+}
+
+// Config is the content of a config file: top-level defaults plus named contexts
+type Config struct {
+	Settings `yaml:",inline"`
+
+	// Context is the context used when none is selected explicitly
+	Context string `yaml:"context"`
+
+	// Contexts bundle settings such as profile, region and role under a name
+	Contexts map[string]Settings `yaml:"contexts"`
+}
+
+// UserPath returns the path of the user config file,
+// $XDG_CONFIG_HOME/delambda/config.yaml or ~/.config/delambda/config.yaml
+func UserPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "delambda", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "delambda", "config.yaml"), nil
+}
+
+// DefaultAuditLogPath returns the default path of the audit log,
+// $XDG_STATE_HOME/delambda/audit.jsonl or ~/.local/state/delambda/audit.jsonl
+func DefaultAuditLogPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.jsonl"), nil
+}
+
+// DefaultCheckpointDir returns the directory checkpoint files are written to,
+// $XDG_STATE_HOME/delambda/checkpoints or ~/.local/state/delambda/checkpoints
+func DefaultCheckpointDir() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "checkpoints"), nil
+}
+
+// DefaultCompletionCacheDir returns the directory shell completion caches names in,
+// $XDG_CACHE_HOME/delambda/completion or ~/.cache/delambda/completion
+func DefaultCompletionCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "delambda", "completion"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "delambda", "completion"), nil
+}
+
+// stateDir returns the directory delambda keeps its state in
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "delambda"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "delambda"), nil
+}
+
+// FindRepoPath looks for a .delambda.yaml file in dir and its parents, stopping
+// at the repository root (the directory containing .git). It returns an empty
+// string if there is none.
+func FindRepoPath(dir string) string {
+	for {
+		path := filepath.Join(dir, RepoFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the given config files and merges them, later files overriding
+// earlier ones. Missing files are skipped.
+func Load(paths ...string) (*Config, error) {
+	merged := &Config{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		cfg, err := loadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(cfg)
+	}
+	return merged, nil
+}
+
+// loadFile reads a single config file, rejecting unknown keys
+func loadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// merge overlays other onto c. Contexts with the same name are merged field by field.
+func (c *Config) merge(other *Config) {
+	c.Settings.merge(other.Settings)
+	if other.Context != "" {
+		c.Context = other.Context
+	}
+	for name, settings := range other.Contexts {
+		if c.Contexts == nil {
+			c.Contexts = make(map[string]Settings)
+		}
+		existing := c.Contexts[name]
+		existing.merge(settings)
+		c.Contexts[name] = existing
+	}
+}
+
+// validate checks the top-level settings and every context
+func (c *Config) validate() error {
+	if err := c.Settings.Validate(); err != nil {
+		return err
+	}
+	for name, settings := range c.Contexts {
+		if err := settings.Validate(); err != nil {
+			return fmt.Errorf("context %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the top-level settings overlaid with the named context.
+// An empty name selects the default context, if any.
+func (c *Config) Resolve(contextName string) (Settings, error) {
+	if contextName == "" {
+		contextName = c.Context
+	}
+	settings := c.Settings
+	if contextName == "" {
+		return settings, nil
+	}
+
+	ctxSettings, ok := c.Contexts[contextName]
+	if !ok {
+		return Settings{}, fmt.Errorf("unknown context %q (available: %s)", contextName, c.contextNames())
+	}
+	settings.merge(ctxSettings)
+	return settings, nil
+}
+
+// contextNames returns the sorted names of the configured contexts
+func (c *Config) contextNames() string {
+	if len(c.Contexts) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// merge overlays the non-zero fields of other onto s
+func (s *Settings) merge(other Settings) {
+	if other.Region != "" {
+		s.Region = other.Region
+	}
+	if other.Profile != "" {
+		s.Profile = other.Profile
+	}
+	if other.RoleARN != "" {
+		s.RoleARN = other.RoleARN
+	}
+	if other.ExternalID != "" {
+		s.ExternalID = other.ExternalID
+	}
+	if other.Concurrency != 0 {
+		s.Concurrency = other.Concurrency
+	}
+	if other.DeleteLogs != nil {
+		s.DeleteLogs = other.DeleteLogs
+	}
+	if other.WaitTimeout != 0 {
+		s.WaitTimeout = other.WaitTimeout
+	}
+	if other.Output != "" {
+		s.Output = other.Output
+	}
+	if other.AuditLog != "" {
+		s.AuditLog = other.AuditLog
+	}
+	if other.MaxRetries != nil {
+		s.MaxRetries = other.MaxRetries
+	}
+	if other.RetryMode != "" {
+		s.RetryMode = other.RetryMode
+	}
+	if other.MaxTPS != nil {
+		s.MaxTPS = other.MaxTPS
+	}
+	s.Protection.merge(other.Protection)
+}
+
+// merge adds the rules of other to p. The maps and slices are copied, since
+// resolved settings share them with the config they were resolved from.
+func (p *Protection) merge(other Protection) {
+	if len(other.Tags) > 0 {
+		tags := maps.Clone(p.Tags)
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		maps.Copy(tags, other.Tags)
+		p.Tags = tags
+	}
+	p.Names = appendUnique(p.Names, other.Names)
+	p.Stacks = appendUnique(p.Stacks, other.Stacks)
+	p.ProductionAccounts = appendUnique(p.ProductionAccounts, other.ProductionAccounts)
+}
+
+// validate checks that the patterns and account IDs are well formed
+func (p *Protection) validate() error {
+	for _, pattern := range append(slices.Clone(p.Names), p.Stacks...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid protection pattern %q: %w", pattern, err)
+		}
+	}
+	for _, id := range p.ProductionAccounts {
+		if !accountIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid production account ID %q: must be 12 digits", id)
+		}
+	}
+	return nil
+}
+
+// appendUnique returns a copy of list with the values that are not in it yet appended
+func appendUnique(list, values []string) []string {
+	list = slices.Clone(list)
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// Validate checks that the settings have allowed values
+func (s *Settings) Validate() error {
+	if s.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative, got %d", s.Concurrency)
+	}
+	if s.WaitTimeout < 0 {
+		return fmt.Errorf("wait-timeout must not be negative, got %s", s.WaitTimeout)
+	}
+	switch s.Output {
+	case "", OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("output must be %q or %q, got %q", OutputText, OutputJSON, s.Output)
+	}
+	if s.MaxRetries != nil && *s.MaxRetries < 0 {
+		return fmt.Errorf("max-retries must not be negative, got %d", *s.MaxRetries)
+	}
+	if s.MaxTPS != nil && *s.MaxTPS < 0 {
+		return fmt.Errorf("max-tps must not be negative, got %g", *s.MaxTPS)
+	}
+	switch s.RetryMode {
+	case "", RetryModeStandard, RetryModeAdaptive:
+	default:
+		return fmt.Errorf("retry-mode must be %q or %q, got %q", RetryModeStandard, RetryModeAdaptive, s.RetryMode)
+	}
+	return s.Protection.validate()
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAndResolve(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "user.yaml", `
+region: us-east-1
+profile: default
+concurrency: 4
+wait-timeout: 30m
+contexts:
+  prod-eu:
+    profile: prod
+    region: eu-west-1
+    role-arn: arn:aws:iam::123456789012:role/Cleanup
+`)
+	repo := writeFile(t, dir, "repo.yaml", `
+delete-logs: false
+output: json
+contexts:
+  prod-eu:
+    region: eu-central-1
+`)
+	empty := writeFile(t, dir, "empty.yaml", "")
+
+	cfg, err := Load(user, repo, empty, filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		context     string
+		wantRegion  string
+		wantProfile string
+		wantRole    string
+		wantErr     string
+	}{
+		{
+			name:        "top-level settings",
+			wantRegion:  "us-east-1",
+			wantProfile: "default",
+		},
+		{
+			name:        "context merged across files",
+			context:     "prod-eu",
+			wantRegion:  "eu-central-1",
+			wantProfile: "prod",
+			wantRole:    "arn:aws:iam::123456789012:role/Cleanup",
+		},
+		{
+			name:    "unknown context",
+			context: "staging",
+			wantErr: `unknown context "staging" (available: prod-eu)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.Resolve(tt.context)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got.Region != tt.wantRegion || got.Profile != tt.wantProfile || got.RoleARN != tt.wantRole {
+				t.Errorf("Resolve() = region %q, profile %q, role %q, want %q, %q, %q",
+					got.Region, got.Profile, got.RoleARN, tt.wantRegion, tt.wantProfile, tt.wantRole)
+			}
+			// Settings that are only set at the top level apply to every context
+			if got.Concurrency != 4 || got.WaitTimeout != 30*time.Minute || got.Output != OutputJSON {
+				t.Errorf("Resolve() = concurrency %d, wait-timeout %s, output %q", got.Concurrency, got.WaitTimeout, got.Output)
+			}
+			if got.DeleteLogs == nil || *got.DeleteLogs {
+				t.Errorf("Resolve() delete-logs = %v, want false", got.DeleteLogs)
+			}
+		})
+	}
+}
+
+func TestDefaultContext(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", `
+region: us-east-1
+context: dev
+contexts:
+  dev:
+    region: ap-northeast-1
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got, err := cfg.Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got.Region != "ap-northeast-1" {
+		t.Errorf("Resolve() region = %q, want ap-northeast-1", got.Region)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown key",
+			content: "regoin: us-east-1\n",
+			wantErr: "field regoin not found",
+		},
+		{
+			name:    "invalid output",
+			content: "output: yaml\n",
+			wantErr: `output must be "text" or "json"`,
+		},
+		{
+			name:    "negative concurrency in context",
+			content: "contexts:\n  dev:\n    concurrency: -1\n",
+			wantErr: "context dev: concurrency must not be negative",
+		},
+		{
+			name:    "invalid production account",
+			content: "protection:\n  production-accounts: [\"12345\"]\n",
+			wantErr: `invalid production account ID "12345"`,
+		},
+		{
+			name:    "invalid protection pattern",
+			content: "protection:\n  names: [\"prod-[\"]\n",
+			wantErr: `invalid protection pattern "prod-["`,
+		},
+		{
+			name:    "invalid retry mode",
+			content: "retry-mode: legacy\n",
+			wantErr: `retry-mode must be "standard" or "adaptive"`,
+		},
+		{
+			name:    "invalid duration",
+			content: "wait-timeout: soon\n",
+			wantErr: "failed to parse config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yaml", tt.content)
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindRepoPath(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".git/HEAD", "ref: refs/heads/main\n")
+	nested := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindRepoPath(nested); got != "" {
+		t.Errorf("FindRepoPath() = %q, want none", got)
+	}
+
+	want := writeFile(t, root, RepoFileName, "region: us-east-1\n")
+	if got := FindRepoPath(nested); got != want {
+		t.Errorf("FindRepoPath() = %q, want %q", got, want)
+	}
+}
+
+func TestProtectionMerge(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "user.yaml", `
+protection:
+  tags:
+    env: prod
+  names: ["billing-*"]
+  production-accounts: ["111111111111"]
+contexts:
+  prod-eu:
+    protection:
+      stacks: ["core-*"]
+`)
+	repo := writeFile(t, dir, "repo.yaml", `
+protection:
+  names: ["billing-*", "auth-*"]
+`)
+
+	cfg, err := Load(user, repo)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got, err := cfg.Resolve("prod-eu")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := Protection{
+		Tags:               map[string]string{"env": "prod"},
+		Names:              []string{"billing-*", "auth-*"},
+		Stacks:             []string{"core-*"},
+		ProductionAccounts: []string{"111111111111"},
+	}
+	if !reflect.DeepEqual(got.Protection, want) {
+		t.Errorf("Resolve() protection = %+v, want %+v", got.Protection, want)
+	}
+
+	// Resolving a context must not leak its rules into the top-level settings
+	if base, _ := cfg.Resolve(""); len(base.Protection.Stacks) != 0 {
+		t.Errorf("Resolve() top-level stacks = %v, want none", base.Protection.Stacks)
+	}
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"path"
+	"time"
+)
+
+// Filter selects audit records by function, stack and time range.
+// Empty fields match everything; Function and Stack accept glob patterns.
+type Filter struct {
+	Function string
+	Stack    string
+	Since    time.Time
+	Until    time.Time
+}
+
+// Apply returns the records matching the filter, keeping their order. Log group
+// records carry no stack, so with a stack filter they match when their function
+// appears in a record of a matching stack.
+func (f *Filter) Apply(records []*Record) []*Record {
+	stackFunctions := make(map[string]bool)
+	if f.Stack != "" {
+		for _, r := range records {
+			if r.Function != "" && matches(f.Stack, r.Stack) {
+				stackFunctions[r.Function] = true
+			}
+		}
+	}
+
+	var result []*Record
+	for _, r := range records {
+		if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+			continue
+		}
+		if !f.Until.IsZero() && !r.Timestamp.Before(f.Until) {
+			continue
+		}
+		if f.Function != "" && !matches(f.Function, r.Function) {
+			continue
+		}
+		if f.Stack != "" && !matches(f.Stack, r.Stack) && !(r.Stack == "" && stackFunctions[r.Function]) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// matches checks if name matches pattern. Invalid patterns only match themselves.
+func matches(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	matched, err := path.Match(pattern, name)
+	if err != nil {
+		return pattern == name
+	}
+	return matched
+}
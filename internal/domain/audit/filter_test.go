@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterApply(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{Timestamp: base, Action: ActionDetachVPC, Target: "api-handler", Function: "api-handler", Stack: "api-stack"},
+		{Timestamp: base.Add(time.Minute), Action: ActionDeleteFunction, Target: "api-handler", Function: "api-handler", Stack: "api-stack"},
+		{Timestamp: base.Add(2 * time.Minute), Action: ActionDeleteLogGroup, Target: "/aws/lambda/api-handler", Function: "api-handler"},
+		{Timestamp: base.Add(24 * time.Hour), Action: ActionDeleteFunction, Target: "worker", Function: "worker"},
+		{Timestamp: base.Add(48 * time.Hour), Action: ActionDeleteLogGroup, Target: "/aws/lambda/worker", Function: "worker"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{name: "empty filter", filter: Filter{}, want: []int{0, 1, 2, 3, 4}},
+		{name: "function glob", filter: Filter{Function: "api-*"}, want: []int{0, 1, 2}},
+		{name: "stack includes its log groups", filter: Filter{Stack: "api-stack"}, want: []int{0, 1, 2}},
+		{name: "unknown stack", filter: Filter{Stack: "other-stack"}, want: nil},
+		{name: "since", filter: Filter{Since: base.Add(24 * time.Hour)}, want: []int{3, 4}},
+		{name: "until is exclusive", filter: Filter{Until: base.Add(2 * time.Minute)}, want: []int{0, 1}},
+		{name: "function and time range", filter: Filter{Function: "worker", Until: base.Add(48 * time.Hour)}, want: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Apply(records)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() returned %d record(s), want %d", len(got), len(tt.want))
+			}
+			for i, idx := range tt.want {
+				if got[i] != records[idx] {
+					t.Errorf("Apply()[%d] = %s %s, want record %d", i, got[i].Action, got[i].Target, idx)
+				}
+			}
+		})
+	}
+}
+
+func TestFunctionForLogGroup(t *testing.T) {
+	tests := []struct {
+		logGroup string
+		want     string
+	}{
+		{logGroup: "/aws/lambda/api-handler", want: "api-handler"},
+		{logGroup: "/aws/lambda/us-east-1.edge-auth", want: "edge-auth"},
+		{logGroup: "/ecs/api", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.logGroup, func(t *testing.T) {
+			if got := FunctionForLogGroup(tt.logGroup); got != tt.want {
+				t.Errorf("FunctionForLogGroup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
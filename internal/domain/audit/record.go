@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+// Action is a mutating operation recorded in the audit log
+type Action string
+
+const (
+	ActionDisableIPv6    Action = "DisableIPv6"
+	ActionDetachVPC      Action = "DetachVPC"
+	ActionDeleteFunction Action = "DeleteFunction"
+	ActionDeleteLogGroup Action = "DeleteLogGroup"
+
+	ActionPutRetentionPolicy Action = "PutRetentionPolicy"
+)
+
+// Outcome is the result of a recorded operation
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Record is a single entry of the audit log
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor
+
+	Action Action `json:"action"`
+
+	// Target is the function or log group name
+	Target string `json:"target"`
+
+	// Function is the function the target belongs to, if any
+	Function string `json:"function,omitempty"`
+
+	// Stack is the CloudFormation stack that created the function, if any
+	Stack string `json:"stack,omitempty"`
+
+	// Before is the state of the target before the operation, if it could be read
+	Before *State `json:"before,omitempty"`
+
+	// RetentionDays is the retention set on a log group by PutRetentionPolicy
+	RetentionDays int `json:"retentionDays,omitempty"`
+
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Actor identifies who performed an operation and where
+type Actor struct {
+	// Caller is the ARN returned by STS GetCallerIdentity
+	Caller  string `json:"caller"`
+	UserID  string `json:"userId,omitempty"`
+	Account string `json:"account"`
+	Region  string `json:"region"`
+}
+
+// State is the state of a function or log group before an operation
+type State struct {
+	Exists  bool      `json:"exists"`
+	Runtime string    `json:"runtime,omitempty"`
+	State   string    `json:"state,omitempty"`
+	VPC     *VPCState `json:"vpc,omitempty"`
+}
+
+// VPCState is the VPC configuration of a function before an operation
+type VPCState struct {
+	VPCId            string   `json:"vpcId"`
+	SubnetIds        []string `json:"subnetIds"`
+	SecurityGroupIds []string `json:"securityGroupIds"`
+	IPv6             bool     `json:"ipv6"`
+}
+
+// NewFunctionState captures the state of fn. A nil fn is recorded as not existing.
+func NewFunctionState(fn *function.Function) *State {
+	if fn == nil {
+		return &State{Exists: false}
+	}
+	state := &State{
+		Exists:  true,
+		Runtime: string(fn.Runtime()),
+		State:   string(fn.State()),
+	}
+	if fn.IsAttachedToVPC() {
+		vpcConfig := fn.VPCConfig()
+		state.VPC = &VPCState{
+			VPCId:            vpcConfig.VPCId,
+			SubnetIds:        vpcConfig.SubnetIds,
+			SecurityGroupIds: vpcConfig.SecurityGroupIds,
+			IPv6:             vpcConfig.IPv6AllowedForDualStack,
+		}
+	}
+	return state
+}
+
+// FunctionForLogGroup returns the function a Lambda log group belongs to,
+// including that of a Lambda@Edge replica, or an empty string for other log groups
+func FunctionForLogGroup(logGroupName string) string {
+	if name, _, ok := loggroup.NewLogGroup(logGroupName).Function(); ok {
+		return name
+	}
+	name, ok := strings.CutPrefix(logGroupName, loggroup.LambdaPrefix)
+	if !ok {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,12 @@
+package audit
+
+import "context"
+
+// Repository defines the interface for audit log persistence
+type Repository interface {
+	// Append adds a record to the end of the audit log
+	Append(ctx context.Context, record *Record) error
+
+	// FindAll returns every record in the audit log, oldest first
+	FindAll(ctx context.Context) ([]*Record, error)
+}
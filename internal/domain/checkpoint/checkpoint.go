@@ -0,0 +1,56 @@
+package checkpoint
+
+// Step is one of the steps of deleting a function that a checkpoint records
+type Step string
+
+const (
+	// StepDisableIPv6 disables IPv6 for dual-stack subnets
+	StepDisableIPv6 Step = "disable-ipv6"
+	// StepDetachVPC detaches the function from its VPC
+	StepDetachVPC Step = "detach-vpc"
+	// StepDeleteFunction deletes the function
+	StepDeleteFunction Step = "delete-function"
+	// StepDeleteLogs deletes the function's CloudWatch Logs log group
+	StepDeleteLogs Step = "delete-logs"
+	// StepSetLogRetention sets the retention of the function's log group instead of deleting it
+	StepSetLogRetention Step = "set-log-retention"
+)
+
+// Description returns a human readable description of the step
+func (s Step) Description() string {
+	switch s {
+	case StepDisableIPv6:
+		return "IPv6 disable"
+	case StepDetachVPC:
+		return "VPC detach"
+	case StepDeleteFunction:
+		return "function deletion"
+	case StepDeleteLogs:
+		return "log group deletion"
+	case StepSetLogRetention:
+		return "log retention"
+	default:
+		return string(s)
+	}
+}
+
+// Status is the recorded progress of a step
+type Status string
+
+const (
+	// StatusNone means the step has not been started
+	StatusNone Status = ""
+	// StatusInProgress means the step was started but not known to have finished,
+	// for example because the process stopped while waiting for it
+	StatusInProgress Status = "in-progress"
+	// StatusCompleted means the step finished successfully
+	StatusCompleted Status = "completed"
+)
+
+// Key identifies a function of a stack in an account and region
+type Key struct {
+	Account  string `json:"account"`
+	Region   string `json:"region"`
+	Stack    string `json:"stack"`
+	Function string `json:"function"`
+}
@@ -0,0 +1,12 @@
+package checkpoint
+
+import "context"
+
+// Repository defines the interface for checkpoint persistence
+type Repository interface {
+	// Status returns the recorded status of step for the function, or StatusNone
+	Status(ctx context.Context, key Key, step Step) (Status, error)
+
+	// Save records the status of step for the function
+	Save(ctx context.Context, key Key, step Step, status Status) error
+}
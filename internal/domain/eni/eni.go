@@ -0,0 +1,58 @@
+package eni
+
+import "slices"
+
+// NetworkInterface represents an elastic network interface Lambda created for
+// the VPC access of functions. Lambda shares a network interface between the
+// functions of an account that use the same subnet and security groups, so it
+// belongs to every one of them rather than to the function that created it.
+type NetworkInterface struct {
+	id               string
+	status           string
+	subnetID         string
+	securityGroupIDs []string
+}
+
+// NewNetworkInterface creates a new NetworkInterface entity
+func NewNetworkInterface(id, status, subnetID string, securityGroupIDs []string) *NetworkInterface {
+	return &NetworkInterface{
+		id:               id,
+		status:           status,
+		subnetID:         subnetID,
+		securityGroupIDs: securityGroupIDs,
+	}
+}
+
+// ID returns the network interface ID
+func (n *NetworkInterface) ID() string {
+	return n.id
+}
+
+// Status returns the status of the network interface, such as in-use or available
+func (n *NetworkInterface) Status() string {
+	return n.status
+}
+
+// SubnetID returns the subnet the network interface is in
+func (n *NetworkInterface) SubnetID() string {
+	return n.subnetID
+}
+
+// SecurityGroupIDs returns the security groups of the network interface
+func (n *NetworkInterface) SecurityGroupIDs() []string {
+	return n.securityGroupIDs
+}
+
+// UsedBy checks if a function in the given subnets with exactly the given
+// security groups uses the network interface
+func (n *NetworkInterface) UsedBy(subnetIDs, securityGroupIDs []string) bool {
+	if !slices.Contains(subnetIDs, n.subnetID) || len(n.securityGroupIDs) != len(securityGroupIDs) {
+		return false
+	}
+	for _, id := range securityGroupIDs {
+		if !slices.Contains(n.securityGroupIDs, id) {
+			return false
+		}
+	}
+	return true
+}
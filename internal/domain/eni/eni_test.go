@@ -0,0 +1,27 @@
+package eni
+
+import "testing"
+
+func TestUsedBy(t *testing.T) {
+	ni := NewNetworkInterface("eni-1", "in-use", "subnet-1", []string{"sg-1", "sg-2"})
+
+	tests := []struct {
+		name             string
+		subnetIDs        []string
+		securityGroupIDs []string
+		want             bool
+	}{
+		{name: "same subnet and security groups", subnetIDs: []string{"subnet-2", "subnet-1"}, securityGroupIDs: []string{"sg-2", "sg-1"}, want: true},
+		{name: "other subnets", subnetIDs: []string{"subnet-2"}, securityGroupIDs: []string{"sg-1", "sg-2"}},
+		{name: "fewer security groups", subnetIDs: []string{"subnet-1"}, securityGroupIDs: []string{"sg-1"}},
+		{name: "other security groups", subnetIDs: []string{"subnet-1"}, securityGroupIDs: []string{"sg-1", "sg-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ni.UsedBy(tt.subnetIDs, tt.securityGroupIDs); got != tt.want {
+				t.Errorf("UsedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
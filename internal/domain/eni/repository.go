@@ -0,0 +1,11 @@
+package eni
+
+import "context"
+
+// Repository defines the interface for network interface lookups
+type Repository interface {
+	// FindByNetwork returns the network interfaces Lambda created in the given
+	// subnets with exactly the given security groups, which every function with
+	// that VPC configuration uses
+	FindByNetwork(ctx context.Context, subnetIDs, securityGroupIDs []string) ([]*NetworkInterface, error)
+}
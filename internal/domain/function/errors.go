@@ -0,0 +1,6 @@
+package function
+
+import "errors"
+
+// ErrNotFound is returned when a function does not exist
+var ErrNotFound = errors.New("function not found")
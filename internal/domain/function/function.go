@@ -1,6 +1,14 @@
 package function
 
-import "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+import (
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// stackNameTagKey is the tag CloudFormation adds to the resources it creates
+const stackNameTagKey = "aws:cloudformation:stack-name"
 
 // Function represents a Lambda function domain entity
 type Function struct {
@@ -8,6 +16,10 @@ type Function struct {
 	runtime   types.Runtime
 	state     types.State
 	vpcConfig *VPCConfig
+	tags      map[string]string
+
+	lastModified time.Time
+	logGroup     string
 }
 
 // VPCConfig represents the VPC configuration of a Lambda function
@@ -18,16 +30,29 @@ type VPCConfig struct {
 	IPv6AllowedForDualStack bool
 }
 
-// NewFunction creates a new Function entity
-func NewFunction(name string, runtime types.Runtime, state types.State, vpcConfig *VPCConfig) *Function {
+// NewFunction creates a new Function entity. tags may be nil when they were not fetched.
+func NewFunction(name string, runtime types.Runtime, state types.State, vpcConfig *VPCConfig, tags map[string]string) *Function {
 	return &Function{
 		name:      name,
 		runtime:   runtime,
 		state:     state,
 		vpcConfig: vpcConfig,
+		tags:      tags,
 	}
 }
 
+// WithLastModified sets when the function code or configuration was last changed and returns the function
+func (f *Function) WithLastModified(lastModified time.Time) *Function {
+	f.lastModified = lastModified
+	return f
+}
+
+// WithLogGroup sets the log group the function sends its logs to and returns the function
+func (f *Function) WithLogGroup(logGroup string) *Function {
+	f.logGroup = logGroup
+	return f
+}
+
 // Name returns the function name
 func (f *Function) Name() string {
 	return f.name
@@ -48,6 +73,30 @@ func (f *Function) VPCConfig() *VPCConfig {
 	return f.vpcConfig
 }
 
+// Tags returns the function tags
+func (f *Function) Tags() map[string]string {
+	return f.tags
+}
+
+// LastModified returns when the function code or configuration was last
+// changed, or the zero time if it is not known
+func (f *Function) LastModified() time.Time {
+	return f.lastModified
+}
+
+// LogGroup returns the log group the function sends its logs to, which a
+// logging configuration may set to one other than /aws/lambda/<name>, or an
+// empty string if it is not known
+func (f *Function) LogGroup() string {
+	return f.logGroup
+}
+
+// StackName returns the name of the CloudFormation stack that created the
+// function, or an empty string if it was not created by CloudFormation
+func (f *Function) StackName() string {
+	return f.tags[stackNameTagKey]
+}
+
 // IsAttachedToVPC checks if the function is attached to a VPC
 func (f *Function) IsAttachedToVPC() bool {
 	return f.vpcConfig != nil && len(f.vpcConfig.SubnetIds) > 0
@@ -57,3 +106,18 @@ func (f *Function) IsAttachedToVPC() bool {
 func (f *Function) HasIPv6Enabled() bool {
 	return f.vpcConfig != nil && f.vpcConfig.IPv6AllowedForDualStack
 }
+
+// UsesVPC checks if the function is attached to the specified VPC
+func (f *Function) UsesVPC(vpcID string) bool {
+	return f.IsAttachedToVPC() && f.vpcConfig.VPCId == vpcID
+}
+
+// UsesSubnet checks if the function is attached to the specified subnet
+func (f *Function) UsesSubnet(subnetID string) bool {
+	return f.IsAttachedToVPC() && slices.Contains(f.vpcConfig.SubnetIds, subnetID)
+}
+
+// UsesSecurityGroup checks if the function is attached to the specified security group
+func (f *Function) UsesSecurityGroup(securityGroupID string) bool {
+	return f.IsAttachedToVPC() && slices.Contains(f.vpcConfig.SecurityGroupIds, securityGroupID)
+}
@@ -0,0 +1,63 @@
+package function
+
+import "testing"
+
+func TestUsesNetwork(t *testing.T) {
+	vpc := &VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-1", "subnet-2"}, SecurityGroupIds: []string{"sg-1"}}
+
+	tests := []struct {
+		name              string
+		vpcConfig         *VPCConfig
+		vpcID             string
+		subnetID          string
+		securityGroupID   string
+		wantVPC           bool
+		wantSubnet        bool
+		wantSecurityGroup bool
+	}{
+		{
+			name:              "same network",
+			vpcConfig:         vpc,
+			vpcID:             "vpc-1",
+			subnetID:          "subnet-2",
+			securityGroupID:   "sg-1",
+			wantVPC:           true,
+			wantSubnet:        true,
+			wantSecurityGroup: true,
+		},
+		{
+			name:            "other network",
+			vpcConfig:       vpc,
+			vpcID:           "vpc-2",
+			subnetID:        "subnet-3",
+			securityGroupID: "sg-2",
+		},
+		{
+			name:            "no VPC",
+			vpcID:           "vpc-1",
+			subnetID:        "subnet-1",
+			securityGroupID: "sg-1",
+		},
+		{
+			name:            "VPC configuration without subnets",
+			vpcConfig:       &VPCConfig{VPCId: "vpc-1", SecurityGroupIds: []string{"sg-1"}},
+			vpcID:           "vpc-1",
+			securityGroupID: "sg-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := NewFunction("api", "python3.12", "Active", tt.vpcConfig, nil)
+			if got := fn.UsesVPC(tt.vpcID); got != tt.wantVPC {
+				t.Errorf("UsesVPC(%q) = %v, want %v", tt.vpcID, got, tt.wantVPC)
+			}
+			if got := fn.UsesSubnet(tt.subnetID); got != tt.wantSubnet {
+				t.Errorf("UsesSubnet(%q) = %v, want %v", tt.subnetID, got, tt.wantSubnet)
+			}
+			if got := fn.UsesSecurityGroup(tt.securityGroupID); got != tt.wantSecurityGroup {
+				t.Errorf("UsesSecurityGroup(%q) = %v, want %v", tt.securityGroupID, got, tt.wantSecurityGroup)
+			}
+		})
+	}
+}
@@ -7,7 +7,8 @@ type Repository interface {
 	// FindAll returns all Lambda functions
 	FindAll(ctx context.Context) ([]*Function, error)
 
-	// FindByName finds a Lambda function by name
+	// FindByName finds a Lambda function by name.
+	// It returns ErrNotFound if the function does not exist.
 	FindByName(ctx context.Context, name string) (*Function, error)
 
 	// DisableIPv6 disables IPv6 for a Lambda function
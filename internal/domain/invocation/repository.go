@@ -0,0 +1,13 @@
+package invocation
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for reading the invocation metrics of Lambda functions
+type Repository interface {
+	// SumByFunction returns how often each named function was invoked between
+	// start and end. A function without invocations has a count of zero.
+	SumByFunction(ctx context.Context, functionNames []string, start, end time.Time) (map[string]int64, error)
+}
@@ -0,0 +1,6 @@
+package loggroup
+
+import "errors"
+
+// ErrNotFound is returned when a log group does not exist
+var ErrNotFound = errors.New("log group not found")
@@ -1,10 +1,29 @@
 package loggroup
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LambdaPrefix is the prefix of the log groups Lambda creates for functions
+const LambdaPrefix = "/aws/lambda/"
+
+// ValidatePrefix checks that prefix only matches the log groups of Lambda
+// functions, so a bulk deletion by prefix never reaches other log groups
+func ValidatePrefix(prefix string) error {
+	if rest, ok := strings.CutPrefix(prefix, LambdaPrefix); !ok || rest == "" {
+		return fmt.Errorf("prefix %q must start with %s followed by the start of a function name", prefix, LambdaPrefix)
+	}
+	return nil
+}
 
 // LogGroup represents a CloudWatch Logs log group domain entity
 type LogGroup struct {
 	name string
+
+	storedBytes  int64
+	creationTime time.Time
 }
 
 // NewLogGroup creates a new LogGroup entity
@@ -17,11 +36,65 @@ func NewLogGroup(name string) *LogGroup {
 // NewLogGroupForFunction creates a LogGroup for a Lambda function
 func NewLogGroupForFunction(functionName string) *LogGroup {
 	return &LogGroup{
-		name: fmt.Sprintf("/aws/lambda/%s", functionName),
+		name: fmt.Sprintf("%s%s", LambdaPrefix, functionName),
 	}
 }
 
+// WithUsage sets the bytes the log group stores and when it was created and returns the log group
+func (lg *LogGroup) WithUsage(storedBytes int64, creationTime time.Time) *LogGroup {
+	lg.storedBytes = storedBytes
+	lg.creationTime = creationTime
+	return lg
+}
+
 // Name returns the log group name
 func (lg *LogGroup) Name() string {
 	return lg.name
 }
+
+// StoredBytes returns the bytes the log group stores, zero if not known
+func (lg *LogGroup) StoredBytes() int64 {
+	return lg.storedBytes
+}
+
+// CreationTime returns when the log group was created, or the zero time if it is not known
+func (lg *LogGroup) CreationTime() time.Time {
+	return lg.creationTime
+}
+
+// Function returns the Lambda function the log group was created for, from
+// its name. Lambda@Edge replicas log to /aws/lambda/<region>.<name> in the
+// region they ran in, where region is the one the function lives in; the
+// region is empty for other log groups. ok is false if the log group is not
+// named after a function.
+func (lg *LogGroup) Function() (functionName, region string, ok bool) {
+	name, ok := strings.CutPrefix(lg.name, LambdaPrefix)
+	if !ok {
+		return "", "", false
+	}
+	// Function names cannot contain dots, so a dot separates the region of a replica
+	region, functionName, edge := strings.Cut(name, ".")
+	if !edge {
+		region, functionName = "", name
+	} else if !validName(region) {
+		return "", "", false
+	}
+	if !validName(functionName) {
+		return "", "", false
+	}
+	return functionName, region, true
+}
+
+// validName checks if s is a non-empty name of letters, digits, hyphens and
+// underscores, as function names and regions are
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
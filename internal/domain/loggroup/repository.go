@@ -1,12 +1,26 @@
 package loggroup
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for LogGroup persistence
 type Repository interface {
+	// FindByPrefix finds the log groups whose names start with prefix, with their usage
+	FindByPrefix(ctx context.Context, prefix string) ([]*LogGroup, error)
+
+	// LastEventTime returns when the latest event of a log group was
+	// ingested, or the zero time if it has none
+	LastEventTime(ctx context.Context, logGroup *LogGroup) (time.Time, error)
+
 	// Exists checks if a log group exists
 	Exists(ctx context.Context, logGroup *LogGroup) (bool, error)
 
+	// SetRetention makes CloudWatch Logs delete the events of a log group after
+	// days, returning ErrNotFound if the log group does not exist
+	SetRetention(ctx context.Context, logGroup *LogGroup, days int) error
+
 	// Delete deletes a log group
 	Delete(ctx context.Context, logGroup *LogGroup) error
 }
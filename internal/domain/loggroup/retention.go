@@ -0,0 +1,17 @@
+package loggroup
+
+import (
+	"fmt"
+	"slices"
+)
+
+// retentionDays are the retention periods CloudWatch Logs accepts, in days
+var retentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// ValidateRetention checks that days is a retention period CloudWatch Logs accepts
+func ValidateRetention(days int) error {
+	if !slices.Contains(retentionDays, days) {
+		return fmt.Errorf("CloudWatch Logs cannot keep logs for %d days, valid retention periods are %v", days, retentionDays)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package protection
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+)
+
+const (
+	// ProtectTagKey and ProtectTagValue mark a function or stack as protected
+	// regardless of the configured rules
+	ProtectTagKey   = "delambda:protect"
+	ProtectTagValue = "true"
+)
+
+// Rules decides which functions and stacks must not be modified
+type Rules struct {
+	tags               map[string]string
+	namePatterns       []string
+	stackPatterns      []string
+	productionAccounts []string
+}
+
+// NewRules creates a new Rules. Targets carrying any of tags, functions whose
+// name matches one of namePatterns, and targets in a stack matching one of
+// stackPatterns are protected. Any action in productionAccounts is protected
+// unless break glass is requested. Patterns use path.Match syntax.
+func NewRules(tags map[string]string, namePatterns, stackPatterns, productionAccounts []string) *Rules {
+	allTags := map[string]string{ProtectTagKey: ProtectTagValue}
+	for key, value := range tags {
+		allTags[key] = value
+	}
+	return &Rules{
+		tags:               allTags,
+		namePatterns:       namePatterns,
+		stackPatterns:      stackPatterns,
+		productionAccounts: productionAccounts,
+	}
+}
+
+// HasProductionAccounts checks if any production account is configured,
+// in which case the caller's account must be known to evaluate the rules
+func (r *Rules) HasProductionAccounts() bool {
+	return len(r.productionAccounts) > 0
+}
+
+// Evaluate returns the rules the target violates
+func (r *Rules) Evaluate(target *Target) []*Violation {
+	var violations []*Violation
+
+	keys := make([]string, 0, len(r.tags))
+	for key := range r.tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, ok := target.tags[key]; ok && value == r.tags[key] {
+			violations = append(violations, newViolation(target, fmt.Sprintf("tagged %s=%s", key, value)))
+		}
+	}
+
+	if target.IsFunction() {
+		if pattern, ok := matchAny(r.namePatterns, target.name); ok {
+			violations = append(violations, newViolation(target, fmt.Sprintf("name matches protected pattern %q", pattern)))
+		}
+	}
+
+	for _, stackName := range target.stackNames {
+		if pattern, ok := matchAny(r.stackPatterns, stackName); ok {
+			violations = append(violations, newViolation(target, fmt.Sprintf("stack %s matches protected pattern %q", stackName, pattern)))
+			break
+		}
+	}
+
+	return violations
+}
+
+// EvaluateAccount returns a violation if accountID is a production account
+// and break glass was not requested
+func (r *Rules) EvaluateAccount(accountID string, breakGlass bool) *Violation {
+	if breakGlass || !r.IsProductionAccount(accountID) {
+		return nil
+	}
+	return &Violation{
+		target:      "account " + accountID,
+		reason:      "is a production account",
+		overridable: true,
+	}
+}
+
+// IsProductionAccount checks if accountID is a configured production account
+func (r *Rules) IsProductionAccount(accountID string) bool {
+	return accountID != "" && slices.Contains(r.productionAccounts, accountID)
+}
+
+// matchAny returns the first pattern that matches name
+func matchAny(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package protection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRulesEvaluate(t *testing.T) {
+	rules := NewRules(
+		map[string]string{"env": "prod"},
+		[]string{"billing-*"},
+		[]string{"payments", "core-*"},
+		[]string{"111111111111"},
+	)
+
+	tests := []struct {
+		name   string
+		target *Target
+		want   []string
+	}{
+		{
+			name:   "unprotected function",
+			target: NewFunctionTarget("preview-api", map[string]string{"env": "dev"}, "pr-123"),
+			want:   nil,
+		},
+		{
+			name:   "built-in protect tag",
+			target: NewFunctionTarget("preview-api", map[string]string{ProtectTagKey: "true"}),
+			want:   []string{"function preview-api: tagged delambda:protect=true"},
+		},
+		{
+			name:   "protect tag with another value",
+			target: NewFunctionTarget("preview-api", map[string]string{ProtectTagKey: "false"}),
+			want:   nil,
+		},
+		{
+			name:   "configured tag and name pattern",
+			target: NewFunctionTarget("billing-worker", map[string]string{"env": "prod"}),
+			want: []string{
+				"function billing-worker: tagged env=prod",
+				`function billing-worker: name matches protected pattern "billing-*"`,
+			},
+		},
+		{
+			name:   "function in nested stack of protected stack",
+			target: NewFunctionTarget("handler", nil, "core-api", "core-api-Nested-ABC"),
+			want:   []string{`function handler: stack core-api matches protected pattern "core-*"`},
+		},
+		{
+			name:   "stack itself",
+			target: NewStackTarget("payments", nil),
+			want:   []string{`stack payments: stack payments matches protected pattern "payments"`},
+		},
+		{
+			name:   "name patterns do not apply to stacks",
+			target: NewStackTarget("billing-stack", nil),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range rules.Evaluate(tt.target) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRulesEvaluateAccount(t *testing.T) {
+	rules := NewRules(nil, nil, nil, []string{"111111111111"})
+
+	tests := []struct {
+		name       string
+		accountID  string
+		breakGlass bool
+		want       bool
+	}{
+		{name: "production account", accountID: "111111111111", want: true},
+		{name: "production account with break glass", accountID: "111111111111", breakGlass: true, want: false},
+		{name: "other account", accountID: "222222222222", want: false},
+		{name: "unknown account", accountID: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules.EvaluateAccount(tt.accountID, tt.breakGlass)
+			if (got != nil) != tt.want {
+				t.Errorf("EvaluateAccount() = %v, want violation %v", got, tt.want)
+			}
+			if got != nil && !got.IsOverridable() {
+				t.Error("production account violation must be overridable")
+			}
+		})
+	}
+}
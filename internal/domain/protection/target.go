@@ -0,0 +1,61 @@
+package protection
+
+// Target is a function or stack that is about to be modified
+type Target struct {
+	name       string
+	function   bool
+	stackNames []string
+	tags       map[string]string
+}
+
+// NewFunctionTarget creates a Target for a function with its tags and the
+// stacks it belongs to
+func NewFunctionTarget(name string, tags map[string]string, stackNames ...string) *Target {
+	return &Target{
+		name:       name,
+		function:   true,
+		stackNames: compact(stackNames),
+		tags:       tags,
+	}
+}
+
+// NewStackTarget creates a Target for a stack with its tags
+func NewStackTarget(name string, tags map[string]string) *Target {
+	return &Target{
+		name:       name,
+		stackNames: []string{name},
+		tags:       tags,
+	}
+}
+
+// Name returns the function or stack name
+func (t *Target) Name() string {
+	return t.name
+}
+
+// IsFunction checks if the target is a function rather than a stack
+func (t *Target) IsFunction() bool {
+	return t.function
+}
+
+// String returns a human readable description of the target
+func (t *Target) String() string {
+	if t.function {
+		return "function " + t.name
+	}
+	return "stack " + t.name
+}
+
+// compact removes empty and duplicate stack names
+func compact(names []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
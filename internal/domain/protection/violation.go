@@ -0,0 +1,41 @@
+package protection
+
+import "errors"
+
+// ErrProtected is returned when an operation is refused because of protection rules
+var ErrProtected = errors.New("operation blocked by protection rules")
+
+// Violation describes why a target is protected
+type Violation struct {
+	target      string
+	reason      string
+	overridable bool
+}
+
+// newViolation creates a Violation for target
+func newViolation(target *Target, reason string) *Violation {
+	return &Violation{
+		target: target.String(),
+		reason: reason,
+	}
+}
+
+// Target returns a description of the protected target
+func (v *Violation) Target() string {
+	return v.target
+}
+
+// Reason returns why the target is protected
+func (v *Violation) Reason() string {
+	return v.reason
+}
+
+// IsOverridable checks if the violation can be overridden with break glass
+func (v *Violation) IsOverridable() bool {
+	return v.overridable
+}
+
+// String returns the violation as a single line
+func (v *Violation) String() string {
+	return v.target + ": " + v.reason
+}
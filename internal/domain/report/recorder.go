@@ -0,0 +1,111 @@
+package report
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+// Recorder collects the functions a run looks up and the steps it performs
+// against a single account and region. It is safe for concurrent use.
+type Recorder struct {
+	mu         sync.Mutex
+	target     *Target
+	index      map[string]*Function
+	lookupErrs map[string]string
+}
+
+// NewRecorder creates a Recorder for the run against account and region by operator
+func NewRecorder(account, region, operator string) *Recorder {
+	return &Recorder{
+		target: &Target{
+			Account:  account,
+			Region:   region,
+			Operator: operator,
+		},
+		index:      make(map[string]*Function),
+		lookupErrs: make(map[string]string),
+	}
+}
+
+// function returns the named function, adding it on first use. The caller must hold mu.
+func (rec *Recorder) function(name string) *Function {
+	fn, ok := rec.index[name]
+	if !ok {
+		fn = &Function{Name: name}
+		rec.index[name] = fn
+		rec.target.Functions = append(rec.target.Functions, fn)
+	}
+	return fn
+}
+
+// LookedUp records that the run looked up a function of stack, or failed to
+func (rec *Recorder) LookedUp(name, stack string, err error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	fn := rec.function(name)
+	if err != nil {
+		rec.lookupErrs[name] = err.Error()
+		return
+	}
+	if stack != "" {
+		fn.Stack = stack
+	}
+}
+
+// Step records a change made to the named function, or to its log group
+func (rec *Recorder) Step(functionName string, action audit.Action, target string, start time.Time, err error) {
+	step := &Step{
+		Action:   action,
+		Target:   target,
+		Start:    start,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	fn := rec.function(functionName)
+	fn.Steps = append(fn.Steps, step)
+	if step.Error != "" && fn.Error == "" {
+		fn.Error = step.Error
+	}
+}
+
+// StepTimings returns the percentiles of the steps recorded so far
+func (rec *Recorder) StepTimings() []StepTiming {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.target.StepTimings()
+}
+
+// Finish completes the target once the run against it has returned err and returns it.
+// A function that could not be looked up and was not changed has failed; a
+// function that was looked up but not changed, as in a dry run, is skipped.
+func (rec *Recorder) Finish(err error) *Target {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if err != nil {
+		rec.target.Error = err.Error()
+	}
+	for _, fn := range rec.target.Functions {
+		if lookupErr, ok := rec.lookupErrs[fn.Name]; ok && len(fn.Steps) == 0 && fn.Error == "" {
+			fn.Error = lookupErr
+		}
+		switch {
+		case fn.Error != "":
+			fn.Status = StatusFailed
+		case len(fn.Steps) == 0:
+			fn.Status = StatusSkipped
+		default:
+			fn.Status = StatusSucceeded
+		}
+	}
+	return rec.target
+}
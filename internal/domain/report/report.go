@@ -0,0 +1,127 @@
+package report
+
+import (
+	"slices"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+// Status is the final status of a function in a run report
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+// Report is the record of a detach or delete run, written for change tickets
+type Report struct {
+	// Command is the command line the run was started with
+	Command string
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// DryRun is set when the run did not change anything
+	DryRun bool
+
+	// Targets are the accounts and regions the run was performed against
+	Targets []*Target
+}
+
+// Target is the part of a run performed against a single account and region
+type Target struct {
+	Account string
+	Region  string
+
+	// Operator is the ARN of the caller that performed the run
+	Operator string
+
+	Functions []*Function
+
+	// Error is the error that ended the run against the target, if any
+	Error string
+}
+
+// Function is what a run did to a single function
+type Function struct {
+	Name string
+
+	// Stack is the CloudFormation stack that created the function, if any
+	Stack string
+
+	Steps  []*Step
+	Status Status
+
+	// Error is the first error of the function, if any
+	Error string
+}
+
+// Step is a single change made to a function or its log group
+type Step struct {
+	Action audit.Action
+
+	// Target is the function or log group name
+	Target string
+
+	Start    time.Time
+	Duration time.Duration
+	Error    string
+}
+
+// Duration returns how long the run took
+func (r *Report) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// Stacks returns the stacks of the functions of every target, sorted and without duplicates
+func (r *Report) Stacks() []string {
+	var stacks []string
+	for _, t := range r.Targets {
+		stacks = append(stacks, t.Stacks()...)
+	}
+	slices.Sort(stacks)
+	return slices.Compact(stacks)
+}
+
+// Stacks returns the stacks of the target's functions, sorted and without duplicates
+func (t *Target) Stacks() []string {
+	var stacks []string
+	for _, fn := range t.Functions {
+		if fn.Stack != "" {
+			stacks = append(stacks, fn.Stack)
+		}
+	}
+	slices.Sort(stacks)
+	return slices.Compact(stacks)
+}
+
+// Count returns how many of the target's functions have status
+func (t *Target) Count(status Status) int {
+	n := 0
+	for _, fn := range t.Functions {
+		if fn.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Duration returns the time spent on the function's steps
+func (f *Function) Duration() time.Duration {
+	var d time.Duration
+	for _, step := range f.Steps {
+		d += step.Duration
+	}
+	return d
+}
+
+// Status returns the status of the step
+func (s *Step) Status() Status {
+	if s.Error != "" {
+		return StatusFailed
+	}
+	return StatusSucceeded
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+func TestRecorderFinish(t *testing.T) {
+	rec := NewRecorder("123456789012", "us-east-1", "arn:aws:iam::123456789012:user/alice")
+	start := time.Now()
+
+	rec.LookedUp("api", "app", nil)
+	rec.Step("api", audit.ActionDetachVPC, "api", start, nil)
+	rec.Step("api", audit.ActionDeleteFunction, "api", start, nil)
+
+	rec.LookedUp("worker", "app", nil)
+	rec.Step("worker", audit.ActionDeleteFunction, "worker", start, nil)
+	rec.Step("worker", audit.ActionDeleteLogGroup, "/aws/lambda/worker", start, errors.New("access denied"))
+
+	rec.LookedUp("missing", "", errors.New("function not found"))
+	rec.LookedUp("unchanged", "app", nil)
+
+	target := rec.Finish(errors.New("failed to delete 2 function(s)"))
+
+	tests := []struct {
+		name      string
+		status    Status
+		stack     string
+		steps     int
+		wantError string
+	}{
+		{name: "api", status: StatusSucceeded, stack: "app", steps: 2},
+		{name: "worker", status: StatusFailed, stack: "app", steps: 2, wantError: "access denied"},
+		{name: "missing", status: StatusFailed, wantError: "function not found"},
+		{name: "unchanged", status: StatusSkipped, stack: "app"},
+	}
+
+	if len(target.Functions) != len(tests) {
+		t.Fatalf("Finish() recorded %d function(s), want %d", len(target.Functions), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := target.Functions[i]
+			if fn.Name != tt.name || fn.Status != tt.status || fn.Stack != tt.stack || len(fn.Steps) != tt.steps || fn.Error != tt.wantError {
+				t.Errorf("function = %s %s in %q with %d step(s) and error %q, want %s %s in %q with %d step(s) and error %q",
+					fn.Name, fn.Status, fn.Stack, len(fn.Steps), fn.Error, tt.name, tt.status, tt.stack, tt.steps, tt.wantError)
+			}
+		})
+	}
+
+	if target.Error != "failed to delete 2 function(s)" {
+		t.Errorf("Error = %q, want the error of the run", target.Error)
+	}
+	if got := target.Stacks(); len(got) != 1 || got[0] != "app" {
+		t.Errorf("Stacks() = %v, want [app]", got)
+	}
+}
+
+func TestNewPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      Percentiles
+	}{
+		{name: "none", want: Percentiles{}},
+		{name: "one", durations: []time.Duration{time.Second}, want: Percentiles{Count: 1, P50: time.Second, P90: time.Second, P99: time.Second, Max: time.Second}},
+		{name: "unsorted", durations: []time.Duration{3 * time.Second, time.Second, 2 * time.Second}, want: Percentiles{Count: 3, P50: 2 * time.Second, P90: 3 * time.Second, P99: 3 * time.Second, Max: 3 * time.Second}},
+		{name: "hundred", durations: durations, want: Percentiles{Count: 100, P50: 50 * time.Second, P90: 90 * time.Second, P99: 99 * time.Second, Max: 100 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPercentiles(tt.durations); got != tt.want {
+				t.Errorf("NewPercentiles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package report
+
+import "context"
+
+// Repository defines the interface for run report persistence
+type Repository interface {
+	// Save writes the report, replacing any earlier one
+	Save(ctx context.Context, report *Report) error
+}
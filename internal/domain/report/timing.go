@@ -0,0 +1,76 @@
+package report
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+// stepOrder is the order in which the steps of a function are performed
+var stepOrder = []audit.Action{
+	audit.ActionDisableIPv6,
+	audit.ActionDetachVPC,
+	audit.ActionDeleteFunction,
+	audit.ActionDeleteLogGroup,
+	audit.ActionPutRetentionPolicy,
+}
+
+// Percentiles summarizes a set of durations
+type Percentiles struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// NewPercentiles computes the nearest-rank percentiles of durations
+func NewPercentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return Percentiles{
+		Count: len(sorted),
+		P50:   rank(50),
+		P90:   rank(90),
+		P99:   rank(99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// StepTiming is the percentiles of the successful steps of one action
+type StepTiming struct {
+	Action audit.Action
+	Percentiles
+}
+
+// StepTimings returns the percentiles of the successful steps of the target's
+// functions per action, in the order the steps are performed. Actions that
+// were not performed are left out.
+func (t *Target) StepTimings() []StepTiming {
+	durations := make(map[audit.Action][]time.Duration)
+	for _, fn := range t.Functions {
+		for _, step := range fn.Steps {
+			if step.Error == "" {
+				durations[step.Action] = append(durations[step.Action], step.Duration)
+			}
+		}
+	}
+
+	var timings []StepTiming
+	for _, action := range stepOrder {
+		if len(durations[action]) > 0 {
+			timings = append(timings, StepTiming{Action: action, Percentiles: NewPercentiles(durations[action])})
+		}
+	}
+	return timings
+}
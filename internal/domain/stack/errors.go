@@ -0,0 +1,6 @@
+package stack
+
+import "errors"
+
+// ErrNotFound is returned when a stack does not exist
+var ErrNotFound = errors.New("stack not found")
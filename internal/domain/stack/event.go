@@ -0,0 +1,55 @@
+package stack
+
+import "time"
+
+// Event represents a CloudFormation stack event
+type Event struct {
+	id                   string
+	timestamp            time.Time
+	logicalResourceID    string
+	resourceType         string
+	resourceStatus       string
+	resourceStatusReason string
+}
+
+// NewEvent creates a new Event entity
+func NewEvent(id string, timestamp time.Time, logicalResourceID, resourceType, resourceStatus, resourceStatusReason string) *Event {
+	return &Event{
+		id:                   id,
+		timestamp:            timestamp,
+		logicalResourceID:    logicalResourceID,
+		resourceType:         resourceType,
+		resourceStatus:       resourceStatus,
+		resourceStatusReason: resourceStatusReason,
+	}
+}
+
+// ID returns the event ID
+func (e *Event) ID() string {
+	return e.id
+}
+
+// Timestamp returns the time the event occurred
+func (e *Event) Timestamp() time.Time {
+	return e.timestamp
+}
+
+// LogicalResourceID returns the logical ID of the resource the event refers to
+func (e *Event) LogicalResourceID() string {
+	return e.logicalResourceID
+}
+
+// ResourceType returns the type of the resource the event refers to
+func (e *Event) ResourceType() string {
+	return e.resourceType
+}
+
+// ResourceStatus returns the status of the resource
+func (e *Event) ResourceStatus() string {
+	return e.resourceStatus
+}
+
+// ResourceStatusReason returns the reason for the resource status
+func (e *Event) ResourceStatusReason() string {
+	return e.resourceStatusReason
+}
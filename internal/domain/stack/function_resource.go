@@ -0,0 +1,26 @@
+package stack
+
+// FunctionResource represents a Lambda function resource owned by a stack
+type FunctionResource struct {
+	name      string
+	stackPath string
+}
+
+// NewFunctionResource creates a new FunctionResource.
+// stackPath is the owning stack followed by the logical IDs of any nested stacks, separated by "/".
+func NewFunctionResource(name, stackPath string) *FunctionResource {
+	return &FunctionResource{
+		name:      name,
+		stackPath: stackPath,
+	}
+}
+
+// Name returns the physical name of the Lambda function
+func (r *FunctionResource) Name() string {
+	return r.name
+}
+
+// StackPath returns the path of the stack that owns the function
+func (r *FunctionResource) StackPath() string {
+	return r.stackPath
+}
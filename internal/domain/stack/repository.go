@@ -1,9 +1,26 @@
 package stack
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for stack operations
 type Repository interface {
-	// ListLambdaFunctions returns all Lambda function names in the specified stack
-	ListLambdaFunctions(ctx context.Context, stackName string) ([]string, error)
+	// ListLambdaFunctions returns all Lambda functions in the specified stack,
+	// including functions in nested stacks
+	ListLambdaFunctions(ctx context.Context, stackName string) ([]*FunctionResource, error)
+
+	// FindAll returns all top-level stacks that have not been deleted
+	FindAll(ctx context.Context) ([]*Stack, error)
+
+	// FindByName finds a stack by name or stack ID.
+	// It returns ErrNotFound if the stack does not exist.
+	FindByName(ctx context.Context, stackName string) (*Stack, error)
+
+	// Delete starts the deletion of a stack
+	Delete(ctx context.Context, stackName string) error
+
+	// ListEvents returns the events of a stack that occurred at or after since, oldest first
+	ListEvents(ctx context.Context, stackName string, since time.Time) ([]*Event, error)
 }
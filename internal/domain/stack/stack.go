@@ -1,18 +1,112 @@
 package stack
 
+import (
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
 // Stack represents a CloudFormation stack
 type Stack struct {
-	name string
+	id           string
+	name         string
+	status       types.StackStatus
+	statusReason string
+	tags         map[string]string
 }
 
 // NewStack creates a new Stack entity
-func NewStack(name string) *Stack {
+func NewStack(id, name string, status types.StackStatus, statusReason string, tags map[string]string) *Stack {
 	return &Stack{
-		name: name,
+		id:           id,
+		name:         name,
+		status:       status,
+		statusReason: statusReason,
+		tags:         tags,
 	}
 }
 
+// ID returns the stack ID (ARN)
+func (s *Stack) ID() string {
+	return s.id
+}
+
 // Name returns the stack name
 func (s *Stack) Name() string {
 	return s.name
 }
+
+// Status returns the stack status
+func (s *Stack) Status() types.StackStatus {
+	return s.status
+}
+
+// StatusReason returns the reason for the current stack status
+func (s *Stack) StatusReason() string {
+	return s.statusReason
+}
+
+// Tags returns the stack tags
+func (s *Stack) Tags() map[string]string {
+	return s.tags
+}
+
+// MatchesName checks if the stack name matches a glob pattern such as "pr-123-*"
+func (s *Stack) MatchesName(pattern string) bool {
+	matched, err := path.Match(pattern, s.name)
+	return err == nil && matched
+}
+
+// HasTags checks if the stack carries every one of the given tags
+func (s *Stack) HasTags(tags map[string]string) bool {
+	for key, value := range tags {
+		if v, ok := s.tags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDeleteComplete checks if the stack has been deleted
+func (s *Stack) IsDeleteComplete() bool {
+	return s.status == types.StackStatusDeleteComplete
+}
+
+// IsDeleteFailed checks if the stack deletion has failed
+func (s *Stack) IsDeleteFailed() bool {
+	return s.status == types.StackStatusDeleteFailed
+}
+
+// IsInProgress checks if CloudFormation is currently operating on the stack
+func (s *Stack) IsInProgress() bool {
+	return strings.HasSuffix(string(s.status), "_IN_PROGRESS")
+}
+
+// IsRollbackFailed checks if the stack is stuck in a failed rollback and needs manual recovery
+func (s *Stack) IsRollbackFailed() bool {
+	switch s.status {
+	case types.StackStatusRollbackFailed,
+		types.StackStatusUpdateRollbackFailed,
+		types.StackStatusImportRollbackFailed:
+		return true
+	}
+	return false
+}
+
+// IsRolledBack checks if the last operation on the stack failed and was rolled
+// back. The stack can be modified, but its resources are those from before
+// that operation, or none after a failed creation.
+func (s *Stack) IsRolledBack() bool {
+	switch s.status {
+	case types.StackStatusRollbackComplete,
+		types.StackStatusUpdateRollbackComplete:
+		return true
+	}
+	return false
+}
+
+// IsStable checks if the stack is in a state where its resources can safely be modified
+func (s *Stack) IsStable() bool {
+	return !s.IsInProgress() && !s.IsRollbackFailed()
+}
@@ -0,0 +1,151 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestMatchesName(t *testing.T) {
+	tests := []struct {
+		name      string
+		stackName string
+		pattern   string
+		want      bool
+	}{
+		{
+			name:      "exact name",
+			stackName: "pr-123-api",
+			pattern:   "pr-123-api",
+			want:      true,
+		},
+		{
+			name:      "prefix glob",
+			stackName: "pr-123-api",
+			pattern:   "pr-123-*",
+			want:      true,
+		},
+		{
+			name:      "prefix glob does not match other PR",
+			stackName: "pr-1234-api",
+			pattern:   "pr-123-*",
+			want:      false,
+		},
+		{
+			name:      "single character wildcard",
+			stackName: "pr-7-web",
+			pattern:   "pr-?-web",
+			want:      true,
+		},
+		{
+			name:      "malformed pattern",
+			stackName: "pr-123-api",
+			pattern:   "pr-[",
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStack("", tt.stackName, types.StackStatusCreateComplete, "", nil)
+			if got := s.MatchesName(tt.pattern); got != tt.want {
+				t.Errorf("MatchesName(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	stackTags := map[string]string{"env": "preview", "team": "payments"}
+
+	tests := []struct {
+		name string
+		tags map[string]string
+		want bool
+	}{
+		{
+			name: "no tags required",
+			tags: nil,
+			want: true,
+		},
+		{
+			name: "single matching tag",
+			tags: map[string]string{"env": "preview"},
+			want: true,
+		},
+		{
+			name: "all tags match",
+			tags: map[string]string{"env": "preview", "team": "payments"},
+			want: true,
+		},
+		{
+			name: "tag value differs",
+			tags: map[string]string{"env": "prod"},
+			want: false,
+		},
+		{
+			name: "tag missing",
+			tags: map[string]string{"owner": "alice"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStack("", "my-stack", types.StackStatusCreateComplete, "", stackTags)
+			if got := s.HasTags(tt.tags); got != tt.want {
+				t.Errorf("HasTags(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStable(t *testing.T) {
+	tests := []struct {
+		status types.StackStatus
+		want   bool
+	}{
+		{types.StackStatusCreateComplete, true},
+		{types.StackStatusUpdateComplete, true},
+		{types.StackStatusUpdateRollbackComplete, true},
+		{types.StackStatusRollbackComplete, true},
+		{types.StackStatusDeleteFailed, true},
+		{types.StackStatusUpdateInProgress, false},
+		{types.StackStatusUpdateCompleteCleanupInProgress, false},
+		{types.StackStatusUpdateRollbackInProgress, false},
+		{types.StackStatusUpdateRollbackFailed, false},
+		{types.StackStatusRollbackFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			s := NewStack("", "my-stack", tt.status, "", nil)
+			if got := s.IsStable(); got != tt.want {
+				t.Errorf("IsStable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRolledBack(t *testing.T) {
+	tests := []struct {
+		status types.StackStatus
+		want   bool
+	}{
+		{types.StackStatusRollbackComplete, true},
+		{types.StackStatusUpdateRollbackComplete, true},
+		{types.StackStatusCreateComplete, false},
+		{types.StackStatusUpdateComplete, false},
+		{types.StackStatusRollbackInProgress, false},
+		{types.StackStatusUpdateRollbackFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			s := NewStack("", "my-stack", tt.status, "", nil)
+			if got := s.IsRolledBack(); got != tt.want {
+				t.Errorf("IsRolledBack() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
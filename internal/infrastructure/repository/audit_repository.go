@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+)
+
+// AuditRepository implements the audit.Repository interface on a JSON Lines file
+type AuditRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAuditRepository creates a new AuditRepository writing to path.
+// The file and its directory are created on the first append.
+func NewAuditRepository(path string) *AuditRepository {
+	return &AuditRepository{
+		path: path,
+	}
+}
+
+// Path returns the path of the audit log file
+func (r *AuditRepository) Path() string {
+	return r.path
+}
+
+// Append writes record as a single line at the end of the file
+func (r *AuditRepository) Append(ctx context.Context, record *audit.Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// FindAll reads every record in the file. A missing file has no records.
+func (r *AuditRepository) FindAll(ctx context.Context) ([]*audit.Record, error) {
+	f, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []*audit.Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := &audit.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log %s line %d: %w", r.path, lineNumber, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/report"
+)
+
+// AuditedFunctionRepository wraps a function.Repository and appends an audit
+// record for every DisableIPv6, DetachVPC and Delete call. With a recorder, it
+// also records every lookup and change in the run report.
+type AuditedFunctionRepository struct {
+	function.Repository
+	journal  audit.Repository
+	actor    audit.Actor
+	recorder *report.Recorder
+}
+
+// NewAuditedFunctionRepository creates a new AuditedFunctionRepository recording as actor.
+// A nil journal appends no audit records, as in a dry run; a nil recorder records no report.
+func NewAuditedFunctionRepository(inner function.Repository, journal audit.Repository, actor audit.Actor, recorder *report.Recorder) *AuditedFunctionRepository {
+	return &AuditedFunctionRepository{
+		Repository: inner,
+		journal:    journal,
+		actor:      actor,
+		recorder:   recorder,
+	}
+}
+
+// FindByName finds a Lambda function and records it with its stack in the run report
+func (r *AuditedFunctionRepository) FindByName(ctx context.Context, name string) (*function.Function, error) {
+	fn, err := r.Repository.FindByName(ctx, name)
+	if r.recorder != nil {
+		stackName := ""
+		if fn != nil {
+			stackName = fn.StackName()
+		}
+		r.recorder.LookedUp(name, stackName, err)
+	}
+	return fn, err
+}
+
+// DisableIPv6 disables IPv6 for a Lambda function and records the operation
+func (r *AuditedFunctionRepository) DisableIPv6(ctx context.Context, functionName string) error {
+	return r.audited(ctx, audit.ActionDisableIPv6, functionName, r.Repository.DisableIPv6)
+}
+
+// DetachVPC detaches VPC from a Lambda function and records the operation
+func (r *AuditedFunctionRepository) DetachVPC(ctx context.Context, functionName string) error {
+	return r.audited(ctx, audit.ActionDetachVPC, functionName, r.Repository.DetachVPC)
+}
+
+// Delete deletes a Lambda function and records the operation
+func (r *AuditedFunctionRepository) Delete(ctx context.Context, functionName string) error {
+	return r.audited(ctx, audit.ActionDeleteFunction, functionName, r.Repository.Delete)
+}
+
+// audited captures the function's state, runs op and appends the record
+func (r *AuditedFunctionRepository) audited(ctx context.Context, action audit.Action, functionName string, op func(context.Context, string) error) error {
+	run := func() error {
+		return recordStep(r.recorder, functionName, action, functionName, func() error {
+			return op(ctx, functionName)
+		})
+	}
+	if r.journal == nil {
+		return run()
+	}
+
+	// The before-state is best effort; a function that cannot be read is recorded as such
+	before, _ := r.Repository.FindByName(ctx, functionName)
+
+	// VPC operations on a function without VPC are refused without changing anything
+	if action != audit.ActionDeleteFunction && before != nil && !before.IsAttachedToVPC() {
+		return run()
+	}
+
+	record := &audit.Record{
+		Actor:    r.actor,
+		Action:   action,
+		Target:   functionName,
+		Function: functionName,
+		Before:   audit.NewFunctionState(before),
+	}
+	if before != nil {
+		record.Stack = before.StackName()
+	}
+
+	err := run()
+	return appendRecord(ctx, r.journal, record, err)
+}
+
+// AuditedLogGroupRepository wraps a loggroup.Repository and appends an audit
+// record for every Delete and SetRetention call. With a recorder, it also
+// records every change in the run report, as a step of the function the log
+// group belongs to.
+type AuditedLogGroupRepository struct {
+	loggroup.Repository
+	journal  audit.Repository
+	actor    audit.Actor
+	recorder *report.Recorder
+}
+
+// NewAuditedLogGroupRepository creates a new AuditedLogGroupRepository recording as actor.
+// A nil journal appends no audit records, as in a dry run; a nil recorder records no report.
+func NewAuditedLogGroupRepository(inner loggroup.Repository, journal audit.Repository, actor audit.Actor, recorder *report.Recorder) *AuditedLogGroupRepository {
+	return &AuditedLogGroupRepository{
+		Repository: inner,
+		journal:    journal,
+		actor:      actor,
+		recorder:   recorder,
+	}
+}
+
+// Delete deletes a log group and records the operation
+func (r *AuditedLogGroupRepository) Delete(ctx context.Context, logGroup *loggroup.LogGroup) error {
+	run := func() error {
+		return recordStep(r.recorder, logGroupFunction(logGroup), audit.ActionDeleteLogGroup, logGroup.Name(), func() error {
+			return r.Repository.Delete(ctx, logGroup)
+		})
+	}
+	if r.journal == nil {
+		return run()
+	}
+
+	record := &audit.Record{
+		Actor:    r.actor,
+		Action:   audit.ActionDeleteLogGroup,
+		Target:   logGroup.Name(),
+		Function: audit.FunctionForLogGroup(logGroup.Name()),
+	}
+	if exists, err := r.Repository.Exists(ctx, logGroup); err == nil {
+		record.Before = &audit.State{Exists: exists}
+	}
+
+	err := run()
+	return appendRecord(ctx, r.journal, record, err)
+}
+
+// SetRetention sets the retention of a log group and records the operation
+func (r *AuditedLogGroupRepository) SetRetention(ctx context.Context, logGroup *loggroup.LogGroup, days int) error {
+	run := func() error {
+		return recordStep(r.recorder, logGroupFunction(logGroup), audit.ActionPutRetentionPolicy, logGroup.Name(), func() error {
+			return r.Repository.SetRetention(ctx, logGroup, days)
+		})
+	}
+	if r.journal == nil {
+		return run()
+	}
+
+	record := &audit.Record{
+		Actor:         r.actor,
+		Action:        audit.ActionPutRetentionPolicy,
+		Target:        logGroup.Name(),
+		Function:      audit.FunctionForLogGroup(logGroup.Name()),
+		RetentionDays: days,
+	}
+
+	err := run()
+	return appendRecord(ctx, r.journal, record, err)
+}
+
+// logGroupFunction returns the function a log group is reported under. A log
+// group of no function is reported on its own.
+func logGroupFunction(logGroup *loggroup.LogGroup) string {
+	if functionName := audit.FunctionForLogGroup(logGroup.Name()); functionName != "" {
+		return functionName
+	}
+	return logGroup.Name()
+}
+
+// recordStep runs op and records it in the run report as a step of the named
+// function, if there is a recorder
+func recordStep(recorder *report.Recorder, functionName string, action audit.Action, target string, op func() error) error {
+	if recorder == nil {
+		return op()
+	}
+	start := time.Now()
+	err := op()
+	recorder.Step(functionName, action, target, start, err)
+	return err
+}
+
+// appendRecord completes record with the outcome of opErr and appends it.
+// A failure to write the record is reported together with the operation's result.
+func appendRecord(ctx context.Context, journal audit.Repository, record *audit.Record, opErr error) error {
+	record.Timestamp = time.Now().UTC()
+	record.Outcome = audit.OutcomeSuccess
+	if opErr != nil {
+		record.Outcome = audit.OutcomeFailure
+		record.Error = opErr.Error()
+	}
+
+	if err := journal.Append(ctx, record); err != nil {
+		auditErr := fmt.Errorf("failed to write audit record for %s %s (outcome: %s): %w", record.Action, record.Target, record.Outcome, err)
+		return errors.Join(opErr, auditErr)
+	}
+	return opErr
+}
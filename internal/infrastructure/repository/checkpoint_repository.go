@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/checkpoint"
+)
+
+// checkpointVersion is the version of the checkpoint file format
+const checkpointVersion = 1
+
+// checkpointFile is the JSON representation of a checkpoint file
+type checkpointFile struct {
+	Version   int                   `json:"version"`
+	UpdatedAt time.Time             `json:"updatedAt"`
+	Functions []*checkpointFunction `json:"functions"`
+}
+
+// checkpointFunction is the progress of a single function in a checkpoint file
+type checkpointFunction struct {
+	checkpoint.Key
+	Steps map[checkpoint.Step]checkpoint.Status `json:"steps"`
+}
+
+// CheckpointRepository implements the checkpoint.Repository interface on a JSON
+// file. The whole file is rewritten atomically on every change, so it stays
+// consistent if the process stops at any point.
+type CheckpointRepository struct {
+	path string
+
+	mu        sync.Mutex
+	functions []*checkpointFunction
+	index     map[checkpoint.Key]*checkpointFunction
+}
+
+// NewCheckpointRepository creates a new, empty CheckpointRepository writing to path.
+// The file and its directory are created on the first save.
+func NewCheckpointRepository(path string) *CheckpointRepository {
+	return &CheckpointRepository{
+		path:  path,
+		index: make(map[checkpoint.Key]*checkpointFunction),
+	}
+}
+
+// LoadCheckpointRepository reads the checkpoint file at path to resume from it
+func LoadCheckpointRepository(path string) (*CheckpointRepository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %s: %w", path, err)
+	}
+	if file.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint file version %d in %s", file.Version, path)
+	}
+
+	r := NewCheckpointRepository(path)
+	for _, fn := range file.Functions {
+		if fn.Steps == nil {
+			fn.Steps = make(map[checkpoint.Step]checkpoint.Status)
+		}
+		r.functions = append(r.functions, fn)
+		r.index[fn.Key] = fn
+	}
+	return r, nil
+}
+
+// Path returns the path of the checkpoint file
+func (r *CheckpointRepository) Path() string {
+	return r.path
+}
+
+// Status returns the recorded status of step for the function
+func (r *CheckpointRepository) Status(ctx context.Context, key checkpoint.Key, step checkpoint.Step) (checkpoint.Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fn, ok := r.index[key]
+	if !ok {
+		return checkpoint.StatusNone, nil
+	}
+	return fn.Steps[step], nil
+}
+
+// Save records the status of step for the function and rewrites the file
+func (r *CheckpointRepository) Save(ctx context.Context, key checkpoint.Key, step checkpoint.Step, status checkpoint.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fn, ok := r.index[key]
+	if !ok {
+		fn = &checkpointFunction{
+			Key:   key,
+			Steps: make(map[checkpoint.Step]checkpoint.Status),
+		}
+		r.functions = append(r.functions, fn)
+		r.index[key] = fn
+	}
+	fn.Steps[step] = status
+
+	return r.write()
+}
+
+// Remove deletes the checkpoint file. A file that was never written is not an error.
+func (r *CheckpointRepository) Remove() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// write replaces the file with the current progress through a temporary file and rename
+func (r *CheckpointRepository) write() error {
+	data, err := json.MarshalIndent(&checkpointFile{
+		Version:   checkpointVersion,
+		UpdatedAt: time.Now().UTC(),
+		Functions: r.functions,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/shirasu/delambda/internal/domain/eni"
+	ec2pkg "github.com/shirasu/delambda/internal/ec2"
+)
+
+// ENIRepository implements the eni.Repository interface
+type ENIRepository struct {
+	client ec2pkg.EC2API
+}
+
+// NewENIRepository creates a new ENIRepository
+func NewENIRepository(client ec2pkg.EC2API) *ENIRepository {
+	return &ENIRepository{
+		client: client,
+	}
+}
+
+// FindByNetwork returns the network interfaces Lambda created in the given
+// subnets with exactly the given security groups
+func (r *ENIRepository) FindByNetwork(ctx context.Context, subnetIDs, securityGroupIDs []string) ([]*eni.NetworkInterface, error) {
+	var interfaces []*eni.NetworkInterface
+
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(r.client, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("interface-type"), Values: []string{string(ec2types.NetworkInterfaceTypeLambda)}},
+			{Name: aws.String("subnet-id"), Values: subnetIDs},
+		},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe network interfaces: %w", err)
+		}
+
+		for _, ni := range output.NetworkInterfaces {
+			groupIDs := make([]string, 0, len(ni.Groups))
+			for _, group := range ni.Groups {
+				groupIDs = append(groupIDs, aws.ToString(group.GroupId))
+			}
+			networkInterface := eni.NewNetworkInterface(aws.ToString(ni.NetworkInterfaceId), string(ni.Status), aws.ToString(ni.SubnetId), groupIDs)
+			// The filters cannot require exactly the given security groups
+			if networkInterface.UsedBy(subnetIDs, securityGroupIDs) {
+				interfaces = append(interfaces, networkInterface)
+			}
+		}
+	}
+
+	return interfaces, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,19 +13,62 @@ import (
 	lambdapkg "github.com/shirasu/delambda/internal/lambda"
 )
 
+// defaultMaxRetries is how often a conflicting change is retried by default
+const defaultMaxRetries = 5
+
+// lastModifiedLayout is the format of the LastModified time of a function
+const lastModifiedLayout = "2006-01-02T15:04:05.000-0700"
+
 // FunctionRepository implements the function.Repository interface
 type FunctionRepository struct {
 	client lambdapkg.LambdaAPI
+
+	// maxRetries is how often a change is retried after a conflict
+	maxRetries int
+
+	// pollInterval is the interval at which a function is polled while waiting for
+	// an update, for up to waitTimeout
+	pollInterval time.Duration
+	waitTimeout  time.Duration
+}
+
+// FunctionRepositoryOption configures optional behaviour of NewFunctionRepository
+type FunctionRepositoryOption func(*FunctionRepository)
+
+// WithMaxRetries sets how often a change is retried when it conflicts with an
+// update in progress. Zero disables retrying. Throttling is retried by the SDK
+// client only.
+func WithMaxRetries(maxRetries int) FunctionRepositoryOption {
+	return func(r *FunctionRepository) {
+		r.maxRetries = maxRetries
+	}
+}
+
+// WithWaiter sets the interval at which a function is polled while waiting for
+// an update to complete, and how long to wait at most
+func WithWaiter(pollInterval, timeout time.Duration) FunctionRepositoryOption {
+	return func(r *FunctionRepository) {
+		r.pollInterval = pollInterval
+		r.waitTimeout = timeout
+	}
 }
 
 // NewFunctionRepository creates a new FunctionRepository
-func NewFunctionRepository(client lambdapkg.LambdaAPI) *FunctionRepository {
-	return &FunctionRepository{
-		client: client,
+func NewFunctionRepository(client lambdapkg.LambdaAPI, opts ...FunctionRepositoryOption) *FunctionRepository {
+	r := &FunctionRepository{
+		client:       client,
+		maxRetries:   defaultMaxRetries,
+		pollInterval: 5 * time.Second,
+		waitTimeout:  5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
-// FindAll returns all Lambda functions
+// FindAll returns all Lambda functions. ListFunctions does not return tags,
+// so the functions have none; use FindByName to get them.
 func (r *FunctionRepository) FindAll(ctx context.Context) ([]*function.Function, error) {
 	var functions []*function.Function
 	var nextMarker *string
@@ -54,7 +98,8 @@ func (r *FunctionRepository) FindAll(ctx context.Context) ([]*function.Function,
 				fn.Runtime,
 				fn.State,
 				vpcConfig,
-			))
+				nil,
+			).WithLastModified(parseLastModified(fn.LastModified)).WithLogGroup(logGroupName(fn.LoggingConfig)))
 		}
 
 		if output.NextMarker == nil {
@@ -66,12 +111,16 @@ func (r *FunctionRepository) FindAll(ctx context.Context) ([]*function.Function,
 	return functions, nil
 }
 
-// FindByName finds a Lambda function by name
+// FindByName finds a Lambda function by name, including its tags
 func (r *FunctionRepository) FindByName(ctx context.Context, name string) (*function.Function, error) {
 	output, err := r.client.GetFunction(ctx, &lambda.GetFunctionInput{
 		FunctionName: aws.String(name),
 	})
 	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %s", function.ErrNotFound, name)
+		}
 		return nil, fmt.Errorf("failed to get function %s: %w", name, err)
 	}
 
@@ -90,7 +139,18 @@ func (r *FunctionRepository) FindByName(ctx context.Context, name string) (*func
 		output.Configuration.Runtime,
 		output.Configuration.State,
 		vpcConfig,
-	), nil
+		output.Tags,
+	).WithLastModified(parseLastModified(output.Configuration.LastModified)).WithLogGroup(logGroupName(output.Configuration.LoggingConfig)), nil
+}
+
+// parseLastModified parses the LastModified time of a function, returning the
+// zero time if it is missing or malformed
+func parseLastModified(value *string) time.Time {
+	t, err := time.Parse(lastModifiedLayout, aws.ToString(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 // DisableIPv6 disables IPv6 for a Lambda function
@@ -108,13 +168,16 @@ func (r *FunctionRepository) DisableIPv6(ctx context.Context, functionName strin
 	vpcConfig := fn.VPCConfig()
 
 	// Update VPC configuration to disable IPv6
-	_, err = r.client.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
-		FunctionName: aws.String(functionName),
-		VpcConfig: &types.VpcConfig{
-			SubnetIds:               vpcConfig.SubnetIds,
-			SecurityGroupIds:        vpcConfig.SecurityGroupIds,
-			Ipv6AllowedForDualStack: aws.Bool(false),
-		},
+	err = r.retry(ctx, functionName, func() error {
+		_, err := r.client.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+			FunctionName: aws.String(functionName),
+			VpcConfig: &types.VpcConfig{
+				SubnetIds:               vpcConfig.SubnetIds,
+				SecurityGroupIds:        vpcConfig.SecurityGroupIds,
+				Ipv6AllowedForDualStack: aws.Bool(false),
+			},
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to disable IPv6 for function %s: %w", functionName, err)
@@ -137,12 +200,15 @@ func (r *FunctionRepository) DetachVPC(ctx context.Context, functionName string)
 	}
 
 	// Update function configuration to remove VPC
-	_, err = r.client.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
-		FunctionName: aws.String(functionName),
-		VpcConfig: &types.VpcConfig{
-			SubnetIds:        []string{},
-			SecurityGroupIds: []string{},
-		},
+	err = r.retry(ctx, functionName, func() error {
+		_, err := r.client.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+			FunctionName: aws.String(functionName),
+			VpcConfig: &types.VpcConfig{
+				SubnetIds:        []string{},
+				SecurityGroupIds: []string{},
+			},
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to detach VPC from function %s: %w", functionName, err)
@@ -154,8 +220,11 @@ func (r *FunctionRepository) DetachVPC(ctx context.Context, functionName string)
 
 // Delete deletes a Lambda function
 func (r *FunctionRepository) Delete(ctx context.Context, functionName string) error {
-	_, err := r.client.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
-		FunctionName: aws.String(functionName),
+	err := r.retry(ctx, functionName, func() error {
+		_, err := r.client.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+			FunctionName: aws.String(functionName),
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete function %s: %w", functionName, err)
@@ -165,8 +234,7 @@ func (r *FunctionRepository) Delete(ctx context.Context, functionName string) er
 
 // waitForFunctionUpdate waits for the function to be in Active state
 func (r *FunctionRepository) waitForFunctionUpdate(ctx context.Context, functionName string) error {
-	maxAttempts := 60
-	interval := 5 * time.Second
+	maxAttempts := r.maxPolls()
 
 	for i := 0; i < maxAttempts; i++ {
 		output, err := r.client.GetFunction(ctx, &lambda.GetFunctionInput{
@@ -191,8 +259,72 @@ func (r *FunctionRepository) waitForFunctionUpdate(ctx context.Context, function
 				state, lastUpdateStatus, reason)
 		}
 
-		time.Sleep(interval)
+		if err := sleep(ctx, r.pollInterval); err != nil {
+			return err
+		}
 	}
 
 	return fmt.Errorf("timeout waiting for function %s to be ready", functionName)
 }
+
+// retry runs op, which changes the function, and retries it up to maxRetries
+// times if it conflicted with an update in progress, once that has finished
+func (r *FunctionRepository) retry(ctx context.Context, functionName string, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+
+		if classifyError(err) == errorFatal || attempt >= r.maxRetries {
+			return err
+		}
+
+		if waitErr := r.waitForUpdateToFinish(ctx, functionName); waitErr != nil {
+			return fmt.Errorf("%w (gave up waiting for the update in progress: %v)", err, waitErr)
+		}
+	}
+}
+
+// waitForUpdateToFinish waits until the function is no longer pending and no
+// update is in progress. Unlike waitForFunctionUpdate, a failed update is fine,
+// since the change that conflicted with it is about to be retried.
+func (r *FunctionRepository) waitForUpdateToFinish(ctx context.Context, functionName string) error {
+	maxAttempts := r.maxPolls()
+
+	for i := 0; i < maxAttempts; i++ {
+		output, err := r.client.GetFunction(ctx, &lambda.GetFunctionInput{
+			FunctionName: aws.String(functionName),
+		})
+		if err != nil {
+			return err
+		}
+
+		if output.Configuration.State != types.StatePending && output.Configuration.LastUpdateStatus != types.LastUpdateStatusInProgress {
+			return nil
+		}
+
+		if err := sleep(ctx, r.pollInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("timeout waiting for the update of function %s to finish", functionName)
+}
+
+// maxPolls returns how often a function is polled before giving up waiting
+func (r *FunctionRepository) maxPolls() int {
+	if r.pollInterval <= 0 {
+		return 1
+	}
+	return max(1, int(r.waitTimeout/r.pollInterval))
+}
+
+// logGroupName returns the log group of a logging configuration, or an empty
+// string if the function has none
+func logGroupName(config *types.LoggingConfig) string {
+	if config == nil {
+		return ""
+	}
+	return aws.ToString(config.LogGroup)
+}
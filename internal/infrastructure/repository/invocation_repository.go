@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	cloudwatchpkg "github.com/shirasu/delambda/internal/cloudwatch"
+)
+
+const (
+	// invocationPeriod is the period of the invocation data points, a day
+	invocationPeriod = 24 * time.Hour
+
+	// maxMetricQueries is the number of metrics GetMetricData accepts per call
+	maxMetricQueries = 500
+
+	// maxMetricDataPoints is the number of data points GetMetricData returns per call
+	maxMetricDataPoints = 100800
+)
+
+// InvocationRepository implements the invocation.Repository interface on the
+// Invocations metric of the AWS/Lambda namespace
+type InvocationRepository struct {
+	client cloudwatchpkg.CloudWatchAPI
+}
+
+// NewInvocationRepository creates a new InvocationRepository
+func NewInvocationRepository(client cloudwatchpkg.CloudWatchAPI) *InvocationRepository {
+	return &InvocationRepository{
+		client: client,
+	}
+}
+
+// SumByFunction returns how often each named function was invoked between start
+// and end, summing daily data points. The functions are queried in batches
+// that stay within the limits of GetMetricData.
+func (r *InvocationRepository) SumByFunction(ctx context.Context, functionNames []string, start, end time.Time) (map[string]int64, error) {
+	sums := make(map[string]int64, len(functionNames))
+	for _, name := range functionNames {
+		sums[name] = 0
+	}
+
+	points := max(1, int(end.Sub(start)/invocationPeriod)+1)
+	batchSize := max(1, min(maxMetricQueries, maxMetricDataPoints/points))
+
+	for first := 0; first < len(functionNames); first += batchSize {
+		batch := functionNames[first:min(first+batchSize, len(functionNames))]
+		if err := r.sumBatch(ctx, batch, start, end, sums); err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+// sumBatch adds the invocations of a batch of functions to sums
+func (r *InvocationRepository) sumBatch(ctx context.Context, functionNames []string, start, end time.Time, sums map[string]int64) error {
+	// Query IDs must start with a lowercase letter, so they refer to the batch by position
+	queries := make([]cwtypes.MetricDataQuery, len(functionNames))
+	for i, name := range functionNames {
+		queries[i] = cwtypes.MetricDataQuery{
+			Id: aws.String("f" + strconv.Itoa(i)),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/Lambda"),
+					MetricName: aws.String("Invocations"),
+					Dimensions: []cwtypes.Dimension{
+						{Name: aws.String("FunctionName"), Value: aws.String(name)},
+					},
+				},
+				Period: aws.Int32(int32(invocationPeriod.Seconds())),
+				Stat:   aws.String("Sum"),
+			},
+		}
+	}
+
+	paginator := cloudwatch.NewGetMetricDataPaginator(r.client, &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         aws.Time(start),
+		EndTime:           aws.Time(end),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get invocation metrics: %w", err)
+		}
+
+		for _, result := range output.MetricDataResults {
+			position, ok := strings.CutPrefix(aws.ToString(result.Id), "f")
+			if !ok {
+				continue
+			}
+			i, err := strconv.Atoi(position)
+			if err != nil || i >= len(functionNames) {
+				continue
+			}
+			for _, value := range result.Values {
+				sums[functionNames[i]] += int64(value)
+			}
+		}
+	}
+	return nil
+}
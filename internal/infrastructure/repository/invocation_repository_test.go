@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	cloudwatchpkg "github.com/shirasu/delambda/internal/cloudwatch"
+)
+
+// mockCloudWatchClient returns a data point of one invocation for every
+// queried function named in invoked, split over two pages
+type mockCloudWatchClient struct {
+	cloudwatchpkg.CloudWatchAPI
+	invoked    map[string]bool
+	batchSizes []int
+}
+
+func (m *mockCloudWatchClient) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	if params.NextToken == nil {
+		m.batchSizes = append(m.batchSizes, len(params.MetricDataQueries))
+	}
+
+	output := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range params.MetricDataQueries {
+		if !m.invoked[aws.ToString(q.MetricStat.Metric.Dimensions[0].Value)] {
+			continue
+		}
+		output.MetricDataResults = append(output.MetricDataResults, cwtypes.MetricDataResult{Id: q.Id, Values: []float64{1}})
+	}
+	if params.NextToken == nil {
+		output.NextToken = aws.String("page-2")
+	}
+	return output, nil
+}
+
+func TestInvocationRepositorySumByFunction(t *testing.T) {
+	var names []string
+	invoked := map[string]bool{}
+	for i := range 1200 {
+		name := fmt.Sprintf("fn-%d", i)
+		names = append(names, name)
+		invoked[name] = i%100 == 0
+	}
+	client := &mockCloudWatchClient{invoked: invoked}
+
+	end := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+	sums, err := NewInvocationRepository(client).SumByFunction(context.Background(), names, end.AddDate(0, 0, -90), end)
+	if err != nil {
+		t.Fatalf("SumByFunction() error = %v", err)
+	}
+
+	if len(sums) != len(names) {
+		t.Errorf("SumByFunction() returned %d functions, want %d", len(sums), len(names))
+	}
+	for name, sum := range sums {
+		want := int64(0)
+		if invoked[name] {
+			want = 2
+		}
+		if sum != want {
+			t.Errorf("invocations of %s = %d, want %d", name, sum, want)
+		}
+	}
+
+	// 91 daily data points per function fit 500 functions in a call
+	if want := []int{500, 500, 200}; fmt.Sprint(client.batchSizes) != fmt.Sprint(want) {
+		t.Errorf("batch sizes = %v, want %v", client.batchSizes, want)
+	}
+}
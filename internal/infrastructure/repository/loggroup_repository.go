@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/shirasu/delambda/internal/domain/loggroup"
 	logspkg "github.com/shirasu/delambda/internal/logs"
 )
@@ -23,6 +26,51 @@ func NewLogGroupRepository(client logspkg.LogsAPI) *LogGroupRepository {
 	}
 }
 
+// FindByPrefix finds the log groups whose names start with prefix, with their usage
+func (r *LogGroupRepository) FindByPrefix(ctx context.Context, prefix string) ([]*loggroup.LogGroup, error) {
+	var logGroups []*loggroup.LogGroup
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(r.client, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe log groups: %w", err)
+		}
+
+		for _, lg := range output.LogGroups {
+			var creationTime time.Time
+			if lg.CreationTime != nil {
+				creationTime = time.UnixMilli(*lg.CreationTime)
+			}
+			logGroups = append(logGroups, loggroup.NewLogGroup(aws.ToString(lg.LogGroupName)).
+				WithUsage(aws.ToInt64(lg.StoredBytes), creationTime))
+		}
+	}
+	return logGroups, nil
+}
+
+// LastEventTime returns when the latest event of a log group was ingested, or
+// the zero time if it has none. CloudWatch Logs updates it within an hour of ingestion.
+func (r *LogGroupRepository) LastEventTime(ctx context.Context, logGroup *loggroup.LogGroup) (time.Time, error) {
+	output, err := r.client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup.Name()),
+		OrderBy:      types.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
+		Limit:        aws.Int32(1),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to describe log streams of %s: %w", logGroup.Name(), err)
+	}
+
+	for _, stream := range output.LogStreams {
+		if stream.LastEventTimestamp != nil {
+			return time.UnixMilli(*stream.LastEventTimestamp), nil
+		}
+	}
+	return time.Time{}, nil
+}
+
 // Exists checks if a log group exists
 func (r *LogGroupRepository) Exists(ctx context.Context, logGroup *loggroup.LogGroup) (bool, error) {
 	output, err := r.client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
@@ -41,6 +89,22 @@ func (r *LogGroupRepository) Exists(ctx context.Context, logGroup *loggroup.LogG
 	return false, nil
 }
 
+// SetRetention makes CloudWatch Logs delete the events of a log group after days
+func (r *LogGroupRepository) SetRetention(ctx context.Context, logGroup *loggroup.LogGroup, days int) error {
+	_, err := r.client.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    aws.String(logGroup.Name()),
+		RetentionInDays: aws.Int32(int32(days)),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("%w: %s", loggroup.ErrNotFound, logGroup.Name())
+		}
+		return fmt.Errorf("failed to set retention of log group %s: %w", logGroup.Name(), err)
+	}
+	return nil
+}
+
 // Delete deletes a log group
 func (r *LogGroupRepository) Delete(ctx context.Context, logGroup *loggroup.LogGroup) error {
 	_, err := r.client.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{
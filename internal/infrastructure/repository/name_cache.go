@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// nameCacheFile is the JSON representation of a cached list of names
+type nameCacheFile struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	Names     []string  `json:"names"`
+}
+
+// unsafeKeyChars match characters that are replaced in cache file names
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NameCache keeps lists of names, such as the functions of a profile and
+// region, in files for a short time, so shell completion does not call AWS on
+// every key press
+type NameCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewNameCache creates a NameCache keeping names in dir for ttl
+func NewNameCache(dir string, ttl time.Duration) *NameCache {
+	return &NameCache{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// Names returns the names cached under key, or loads them with load and caches
+// them if there are none or they are older than the TTL. A cache that cannot
+// be read or written is bypassed.
+func (c *NameCache) Names(key string, load func() ([]string, error)) ([]string, error) {
+	path := filepath.Join(c.dir, unsafeKeyChars.ReplaceAllString(key, "_")+".json")
+
+	if data, err := os.ReadFile(path); err == nil {
+		var cached nameCacheFile
+		if json.Unmarshal(data, &cached) == nil && c.now().Sub(cached.UpdatedAt) < c.ttl {
+			return cached.Names, nil
+		}
+	}
+
+	names, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Completion works without the cache, so a failure to write it is ignored
+	_ = c.write(path, names)
+	return names, nil
+}
+
+// write replaces the cache file through a temporary file and rename
+func (c *NameCache) write(path string, names []string) error {
+	data, err := json.Marshal(&nameCacheFile{UpdatedAt: c.now(), Names: names})
+	if err != nil {
+		return fmt.Errorf("failed to encode names: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNameCacheNames(t *testing.T) {
+	now := time.Date(2026, 3, 18, 15, 0, 0, 0, time.UTC)
+	cache := NewNameCache(t.TempDir(), 5*time.Minute)
+	cache.now = func() time.Time { return now }
+
+	loads := 0
+	load := func(names ...string) func() ([]string, error) {
+		return func() ([]string, error) {
+			loads++
+			return names, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		after     time.Duration
+		load      func() ([]string, error)
+		want      []string
+		wantLoads int
+		wantErr   bool
+	}{
+		{name: "loads when empty", key: "lambda-default-us-east-1", load: load("a", "b"), want: []string{"a", "b"}, wantLoads: 1},
+		{name: "served from cache within the TTL", key: "lambda-default-us-east-1", after: 4 * time.Minute, load: load("c"), want: []string{"a", "b"}, wantLoads: 1},
+		{name: "keys are separate", key: "stack-default-us-east-1", after: 4 * time.Minute, load: load("s"), want: []string{"s"}, wantLoads: 2},
+		{name: "reloads after the TTL", key: "lambda-default-us-east-1", after: 10 * time.Minute, load: load("c"), want: []string{"c"}, wantLoads: 3},
+		{name: "load error", key: "region-default", after: 10 * time.Minute, load: func() ([]string, error) { return nil, errors.New("denied") }, wantLoads: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.after)
+			got, err := cache.Names(tt.key, tt.load)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Names() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Names() = %v, want %v", got, tt.want)
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("loads = %d, want %d", loads, tt.wantLoads)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/report"
+)
+
+// ReportFormat is a file format of a run report
+type ReportFormat string
+
+const (
+	ReportFormatMarkdown ReportFormat = "markdown"
+	ReportFormatHTML     ReportFormat = "html"
+	ReportFormatCSV      ReportFormat = "csv"
+)
+
+// reportFormats maps the supported file extensions to their format
+var reportFormats = map[string]ReportFormat{
+	".md":       ReportFormatMarkdown,
+	".markdown": ReportFormatMarkdown,
+	".html":     ReportFormatHTML,
+	".htm":      ReportFormatHTML,
+	".csv":      ReportFormatCSV,
+}
+
+// ReportRepository implements the report.Repository interface on a file whose
+// format is chosen by its extension
+type ReportRepository struct {
+	path   string
+	format ReportFormat
+}
+
+// NewReportRepository creates a new ReportRepository writing to path.
+// The path must end in .md, .html or .csv.
+func NewReportRepository(path string) (*ReportRepository, error) {
+	format, ok := reportFormats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported report file %s: use a .md, .html or .csv extension", path)
+	}
+	return &ReportRepository{
+		path:   path,
+		format: format,
+	}, nil
+}
+
+// Path returns the path of the report file
+func (r *ReportRepository) Path() string {
+	return r.path
+}
+
+// Save renders the report and replaces the file through a temporary file and rename
+func (r *ReportRepository) Save(ctx context.Context, rep *report.Report) error {
+	var buf bytes.Buffer
+	var err error
+	switch r.format {
+	case ReportFormatHTML:
+		err = writeHTMLReport(&buf, rep)
+	case ReportFormatCSV:
+		err = writeCSVReport(&buf, rep)
+	default:
+		err = writeMarkdownReport(&buf, rep)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace report file: %w", err)
+	}
+	return nil
+}
+
+// formatReportTime formats a time of a report in UTC
+func formatReportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatReportDuration formats a duration of a report to the millisecond
+func formatReportDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// markdownCell escapes a value for a Markdown table cell
+func markdownCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeMarkdownReport renders the report as Markdown with a table of
+// functions and a table of steps per account and region
+func writeMarkdownReport(w io.Writer, rep *report.Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# delambda run report\n\n")
+	fmt.Fprintf(&b, "| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Command | `%s` |\n", strings.ReplaceAll(rep.Command, "|", `\|`))
+	fmt.Fprintf(&b, "| Started | %s |\n", formatReportTime(rep.StartedAt))
+	fmt.Fprintf(&b, "| Finished | %s |\n", formatReportTime(rep.FinishedAt))
+	fmt.Fprintf(&b, "| Duration | %s |\n", formatReportDuration(rep.Duration()))
+	fmt.Fprintf(&b, "| Stacks | %s |\n", markdownCell(strings.Join(rep.Stacks(), ", ")))
+	if rep.DryRun {
+		fmt.Fprintf(&b, "| Dry run | yes, nothing was changed |\n")
+	}
+
+	for _, t := range rep.Targets {
+		fmt.Fprintf(&b, "\n## Account %s, region %s\n\n", markdownCell(t.Account), markdownCell(t.Region))
+		fmt.Fprintf(&b, "- Operator: %s\n", markdownCell(t.Operator))
+		fmt.Fprintf(&b, "- Stacks: %s\n", markdownCell(strings.Join(t.Stacks(), ", ")))
+		fmt.Fprintf(&b, "- Functions: %d succeeded, %d failed, %d skipped\n",
+			t.Count(report.StatusSucceeded), t.Count(report.StatusFailed), t.Count(report.StatusSkipped))
+		if t.Error != "" {
+			fmt.Fprintf(&b, "- Error: %s\n", markdownCell(t.Error))
+		}
+
+		if len(t.Functions) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n| Function | Stack | Status | Duration | Error |\n|---|---|---|---|---|\n")
+		for _, fn := range t.Functions {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(fn.Name), markdownCell(fn.Stack),
+				fn.Status, formatReportDuration(fn.Duration()), markdownCell(fn.Error))
+		}
+
+		fmt.Fprintf(&b, "\n| Function | Step | Target | Started | Duration | Status | Error |\n|---|---|---|---|---|---|---|\n")
+		for _, fn := range t.Functions {
+			for _, step := range fn.Steps {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", markdownCell(fn.Name), step.Action, markdownCell(step.Target),
+					formatReportTime(step.Start), formatReportDuration(step.Duration), step.Status(), markdownCell(step.Error))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlReportTemplate renders a report as a standalone HTML page
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":     formatReportTime,
+	"duration": formatReportDuration,
+	"join":     strings.Join,
+	"count":    func(t *report.Target, status string) int { return t.Count(report.Status(status)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>delambda run report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.failed { color: #b00020; }
+.skipped { color: #666; }
+</style>
+</head>
+<body>
+<h1>delambda run report</h1>
+<table>
+<tr><th>Command</th><td><code>{{.Command}}</code></td></tr>
+<tr><th>Started</th><td>{{time .StartedAt}}</td></tr>
+<tr><th>Finished</th><td>{{time .FinishedAt}}</td></tr>
+<tr><th>Duration</th><td>{{duration .Duration}}</td></tr>
+<tr><th>Stacks</th><td>{{join .Stacks ", "}}</td></tr>
+{{- if .DryRun}}
+<tr><th>Dry run</th><td>yes, nothing was changed</td></tr>
+{{- end}}
+</table>
+{{- range .Targets}}
+<h2>Account {{.Account}}, region {{.Region}}</h2>
+<ul>
+<li>Operator: {{.Operator}}</li>
+<li>Stacks: {{join .Stacks ", "}}</li>
+<li>Functions: {{count . "succeeded"}} succeeded, {{count . "failed"}} failed, {{count . "skipped"}} skipped</li>
+{{- if .Error}}
+<li class="failed">Error: {{.Error}}</li>
+{{- end}}
+</ul>
+{{- if .Functions}}
+<table>
+<tr><th>Function</th><th>Stack</th><th>Status</th><th>Duration</th><th>Error</th></tr>
+{{- range .Functions}}
+<tr class="{{.Status}}"><td>{{.Name}}</td><td>{{.Stack}}</td><td>{{.Status}}</td><td>{{duration .Duration}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+<table>
+<tr><th>Function</th><th>Step</th><th>Target</th><th>Started</th><th>Duration</th><th>Status</th><th>Error</th></tr>
+{{- range $fn := .Functions}}{{range .Steps}}
+<tr class="{{.Status}}"><td>{{$fn.Name}}</td><td>{{.Action}}</td><td>{{.Target}}</td><td>{{time .Start}}</td><td>{{duration .Duration}}</td><td>{{.Status}}</td><td>{{.Error}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTMLReport renders the report as a standalone HTML page
+func writeHTMLReport(w io.Writer, rep *report.Report) error {
+	return htmlReportTemplate.Execute(w, rep)
+}
+
+// csvReportHeader is the header row of a CSV report
+var csvReportHeader = []string{
+	"account", "region", "operator", "stack", "function", "function_status",
+	"step", "target", "started", "duration_seconds", "step_status", "error",
+}
+
+// writeCSVReport renders the report as CSV with a row per step. A function
+// without steps gets a row of its own, as does a target that failed before
+// reaching any function.
+func writeCSVReport(w io.Writer, rep *report.Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvReportHeader); err != nil {
+		return err
+	}
+
+	for _, t := range rep.Targets {
+		if len(t.Functions) == 0 {
+			row := []string{t.Account, t.Region, t.Operator, "", "", "", "", "", "", "", "", t.Error}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, fn := range t.Functions {
+			prefix := []string{t.Account, t.Region, t.Operator, fn.Stack, fn.Name, string(fn.Status)}
+			if len(fn.Steps) == 0 {
+				if err := cw.Write(append(prefix, "", "", "", "", "", fn.Error)); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, step := range fn.Steps {
+				row := append(prefix[:len(prefix):len(prefix)],
+					string(step.Action),
+					step.Target,
+					formatReportTime(step.Start),
+					strconv.FormatFloat(step.Duration.Seconds(), 'f', 3, 64),
+					string(step.Status()),
+					step.Error,
+				)
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+	"github.com/shirasu/delambda/internal/domain/report"
+)
+
+// newTestReport returns a report of a run that deleted one function and failed on another
+func newTestReport() *report.Report {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &report.Report{
+		Command:    "delambda delete --stack app --report report.md",
+		StartedAt:  start,
+		FinishedAt: start.Add(95 * time.Second),
+		Targets: []*report.Target{{
+			Account:  "123456789012",
+			Region:   "us-east-1",
+			Operator: "arn:aws:iam::123456789012:user/alice",
+			Error:    "failed to delete 1 function(s)",
+			Functions: []*report.Function{
+				{
+					Name:   "api",
+					Stack:  "app",
+					Status: report.StatusSucceeded,
+					Steps: []*report.Step{
+						{Action: audit.ActionDetachVPC, Target: "api", Start: start, Duration: 40 * time.Second},
+						{Action: audit.ActionDeleteFunction, Target: "api", Start: start.Add(40 * time.Second), Duration: 1500 * time.Millisecond},
+					},
+				},
+				{
+					Name:   "worker",
+					Stack:  "app",
+					Status: report.StatusFailed,
+					Error:  "<denied> | not authorized",
+					Steps: []*report.Step{
+						{Action: audit.ActionDeleteFunction, Target: "worker", Start: start.Add(42 * time.Second), Duration: 250 * time.Millisecond, Error: "<denied> | not authorized"},
+					},
+				},
+			},
+		}},
+	}
+}
+
+func TestReportRepositorySave(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{
+			file: "report.md",
+			want: []string{
+				"| Stacks | app |",
+				"## Account 123456789012, region us-east-1",
+				"- Operator: arn:aws:iam::123456789012:user/alice",
+				"- Functions: 1 succeeded, 1 failed, 0 skipped",
+				"| api | app | succeeded | 41.5s | - |",
+				"| worker | DeleteFunction | worker | 2026-03-01T12:00:42Z | 250ms | failed | <denied> \\| not authorized |",
+			},
+		},
+		{
+			file: "report.html",
+			want: []string{
+				"<h2>Account 123456789012, region us-east-1</h2>",
+				`<tr class="succeeded"><td>api</td><td>DetachVPC</td><td>api</td><td>2026-03-01T12:00:00Z</td><td>40s</td>`,
+				"&lt;denied&gt; | not authorized",
+			},
+		},
+		{
+			file: "REPORT.CSV",
+			want: []string{
+				"account,region,operator,stack,function,function_status,step,target,started,duration_seconds,step_status,error\n",
+				"123456789012,us-east-1,arn:aws:iam::123456789012:user/alice,app,api,succeeded,DeleteFunction,api,2026-03-01T12:00:40Z,1.500,succeeded,\n",
+				"app,worker,failed,DeleteFunction,worker,2026-03-01T12:00:42Z,0.250,failed,<denied> | not authorized\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			repo, err := NewReportRepository(path)
+			if err != nil {
+				t.Fatalf("NewReportRepository() error = %v", err)
+			}
+			if err := repo.Save(context.Background(), newTestReport()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("report does not contain %q:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
+
+func TestNewReportRepositoryRejectsUnknownExtension(t *testing.T) {
+	if _, err := NewReportRepository("report.pdf"); err == nil {
+		t.Error("NewReportRepository(report.pdf) error = nil, want unsupported extension")
+	}
+}
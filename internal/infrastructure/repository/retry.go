@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+// errorClass tells whether a failed call may succeed when it is retried
+type errorClass int
+
+const (
+	// errorFatal will fail again, so it is returned right away
+	errorFatal errorClass = iota
+
+	// errorConflict is raised while another update of the function is in
+	// progress or the function is pending, so the call is retried once the
+	// function has settled
+	errorConflict
+)
+
+// classifyError returns the class of an error returned by an AWS API call.
+// Throttling is fatal here: the SDK retryer, configured with the same maximum
+// retries, has already backed off and retried it, and retrying it again would
+// multiply the calls made while the account is throttled.
+func classifyError(err error) errorClass {
+	var conflict *types.ResourceConflictException
+	if errors.As(err, &conflict) {
+		return errorConflict
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.ErrorCode() == "ResourceConflictException" {
+			return errorConflict
+		}
+	}
+	return errorFatal
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/shirasu/delambda/pkg/awsfake"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorClass
+	}{
+		{name: "conflict", err: &types.ResourceConflictException{Message: aws.String("update in progress")}, want: errorConflict},
+		{name: "wrapped conflict", err: fmt.Errorf("operation error: %w", &types.ResourceConflictException{}), want: errorConflict},
+		{name: "throttling is left to the SDK", err: &types.TooManyRequestsException{}, want: errorFatal},
+		{name: "invalid parameter", err: &types.InvalidParameterValueException{}, want: errorFatal},
+		{name: "not an API error", err: errors.New("connection reset"), want: errorFatal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionRepositoryRetry(t *testing.T) {
+	conflict := &types.ResourceConflictException{Message: aws.String("update in progress")}
+	throttled := &types.TooManyRequestsException{Message: aws.String("rate exceeded")}
+	invalid := &types.InvalidParameterValueException{Message: aws.String("invalid")}
+
+	tests := []struct {
+		name        string
+		err         error
+		failures    int
+		updatingFor int
+		maxRetries  int
+		wantCalls   int
+		wantGets    int
+		wantErr     bool
+	}{
+		{name: "conflict waits for the update and retries", err: conflict, failures: 1, updatingFor: 2, maxRetries: 3, wantCalls: 2, wantGets: 3},
+		{name: "throttling is not retried again", err: throttled, failures: 1, maxRetries: 3, wantCalls: 1, wantErr: true},
+		{name: "fatal error is not retried", err: invalid, failures: 1, maxRetries: 3, wantCalls: 1, wantErr: true},
+		{name: "retries are bounded", err: conflict, failures: 3, maxRetries: 2, wantCalls: 3, wantGets: 2, wantErr: true},
+		{name: "zero retries disables retrying", err: conflict, failures: 1, maxRetries: 0, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A pending function stays pending for updatingFor GetFunction calls
+			backend := awsfake.New(awsfake.Options{TransitionPolls: tt.updatingFor})
+			backend.AddFunction(awsfake.Function{Name: "fn", Pending: tt.updatingFor > 0})
+			backend.InjectFault(awsfake.Fault{Operation: "DeleteFunction", Err: tt.err, Times: tt.failures})
+			repo := NewFunctionRepository(backend.Lambda(), WithMaxRetries(tt.maxRetries))
+			repo.pollInterval = time.Millisecond
+
+			err := repo.Delete(context.Background(), "fn")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := backend.Calls("DeleteFunction"); got != tt.wantCalls {
+				t.Errorf("DeleteFunction calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := backend.Calls("GetFunction"); got != tt.wantGets {
+				t.Errorf("GetFunction calls = %d, want %d", got, tt.wantGets)
+			}
+		})
+	}
+}
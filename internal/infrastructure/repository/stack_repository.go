@@ -2,66 +2,216 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+	cloudformationpkg "github.com/shirasu/delambda/internal/cloudformation"
+	"github.com/shirasu/delambda/internal/domain/stack"
 )
 
 // StackRepository implements the stack repository using AWS SDK
 type StackRepository struct {
-	client *cloudformation.Client
+	client cloudformationpkg.CloudFormationAPI
 }
 
 // NewStackRepository creates a new stack repository
-func NewStackRepository(client *cloudformation.Client) *StackRepository {
+func NewStackRepository(client cloudformationpkg.CloudFormationAPI) *StackRepository {
 	return &StackRepository{
 		client: client,
 	}
 }
 
-// ListLambdaFunctions returns all Lambda function names in the specified stack
-func (r *StackRepository) ListLambdaFunctions(ctx context.Context, stackName string) ([]string, error) {
+// ListLambdaFunctions returns all Lambda functions in the specified stack,
+// following nested stacks recursively
+func (r *StackRepository) ListLambdaFunctions(ctx context.Context, stackName string) ([]*stack.FunctionResource, error) {
+	return r.listLambdaFunctions(ctx, stackName, stackName)
+}
+
+// listLambdaFunctions lists the Lambda functions in a single stack and its nested stacks.
+// stackPath identifies the stack in the output, e.g. "my-stack/ApiNestedStack".
+func (r *StackRepository) listLambdaFunctions(ctx context.Context, stackName, stackPath string) ([]*stack.FunctionResource, error) {
 	// Get stack resources
 	input := &cloudformation.ListStackResourcesInput{
 		StackName: &stackName,
 	}
 
-	var functionNames []string
+	var functions []*stack.FunctionResource
+	var nestedStacks []types.StackResourceSummary
 	paginator := cloudformation.NewListStackResourcesPaginator(r.client, input)
 
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list stack resources: %w", err)
+			return nil, fmt.Errorf("failed to list stack resources of %s: %w", stackPath, err)
 		}
 
-		// Filter for Lambda functions
+		// Filter for Lambda functions and nested stacks
 		for _, resource := range output.StackResourceSummaries {
-			if resource.ResourceType != nil && *resource.ResourceType == "AWS::Lambda::Function" {
-				if resource.PhysicalResourceId != nil {
-					functionNames = append(functionNames, *resource.PhysicalResourceId)
+			if resource.PhysicalResourceId == nil {
+				continue
+			}
+			switch aws.ToString(resource.ResourceType) {
+			case "AWS::Lambda::Function":
+				functions = append(functions, stack.NewFunctionResource(*resource.PhysicalResourceId, stackPath))
+			case "AWS::CloudFormation::Stack":
+				if resource.ResourceStatus != types.ResourceStatusDeleteComplete {
+					nestedStacks = append(nestedStacks, resource)
 				}
 			}
 		}
 	}
 
-	return functionNames, nil
+	// The physical ID of a nested stack is its stack ID
+	for _, nested := range nestedStacks {
+		nestedPath := stackPath + "/" + aws.ToString(nested.LogicalResourceId)
+		nestedFunctions, err := r.listLambdaFunctions(ctx, *nested.PhysicalResourceId, nestedPath)
+		if err != nil {
+			return nil, err
+		}
+		functions = append(functions, nestedFunctions...)
+	}
+
+	return functions, nil
 }
 
-// StackExists checks if a stack exists
-func (r *StackRepository) StackExists(ctx context.Context, stackName string) (bool, error) {
-	input := &cloudformation.DescribeStacksInput{
-		StackName: &stackName,
+// FindByName finds a stack by name or stack ID
+func (r *StackRepository) FindByName(ctx context.Context, stackName string) (*stack.Stack, error) {
+	output, err := r.client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		if isStackNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", stack.ErrNotFound, stackName)
+		}
+		return nil, fmt.Errorf("failed to describe stack %s: %w", stackName, err)
+	}
+
+	if len(output.Stacks) == 0 {
+		return nil, fmt.Errorf("%w: %s", stack.ErrNotFound, stackName)
+	}
+
+	return toStack(output.Stacks[0]), nil
+}
+
+// FindAll returns all top-level stacks that have not been deleted.
+// Nested stacks are skipped since their functions are reached through the parent stack.
+func (r *StackRepository) FindAll(ctx context.Context) ([]*stack.Stack, error) {
+	var stacks []*stack.Stack
+	paginator := cloudformation.NewDescribeStacksPaginator(r.client, &cloudformation.DescribeStacksInput{})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stacks: %w", err)
+		}
+
+		for _, s := range output.Stacks {
+			if s.ParentId != nil {
+				continue
+			}
+			stacks = append(stacks, toStack(s))
+		}
 	}
 
-	_, err := r.client.DescribeStacks(ctx, input)
+	return stacks, nil
+}
+
+// Delete starts the deletion of a stack
+func (r *StackRepository) Delete(ctx context.Context, stackName string) error {
+	_, err := r.client.DeleteStack(ctx, &cloudformation.DeleteStackInput{
+		StackName: aws.String(stackName),
+	})
 	if err != nil {
-		// Check if error is because stack doesn't exist
-		if err != nil && err.Error() != "" {
+		return fmt.Errorf("failed to delete stack %s: %w", stackName, err)
+	}
+	return nil
+}
+
+// ListEvents returns the events of a stack that occurred at or after since, oldest first
+func (r *StackRepository) ListEvents(ctx context.Context, stackName string, since time.Time) ([]*stack.Event, error) {
+	input := &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	}
+
+	var events []*stack.Event
+	paginator := cloudformation.NewDescribeStackEventsPaginator(r.client, input)
+
+	// Events are returned newest first, so stop paging once we reach an event older than since
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stack events: %w", err)
+		}
+
+		reachedSince := false
+		for _, e := range output.StackEvents {
+			timestamp := aws.ToTime(e.Timestamp)
+			if timestamp.Before(since) {
+				reachedSince = true
+				break
+			}
+			events = append(events, stack.NewEvent(
+				aws.ToString(e.EventId),
+				timestamp,
+				aws.ToString(e.LogicalResourceId),
+				aws.ToString(e.ResourceType),
+				string(e.ResourceStatus),
+				aws.ToString(e.ResourceStatusReason),
+			))
+		}
+
+		if reachedSince {
+			break
+		}
+	}
+
+	slices.Reverse(events)
+	return events, nil
+}
+
+// StackExists checks if a stack exists.
+// Errors other than the stack not existing, such as access denied or throttling, are returned.
+func (r *StackRepository) StackExists(ctx context.Context, stackName string) (bool, error) {
+	_, err := r.FindByName(ctx, stackName)
+	if err != nil {
+		if errors.Is(err, stack.ErrNotFound) {
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to describe stack: %w", err)
+		return false, err
 	}
 
 	return true, nil
 }
+
+// toStack converts a CloudFormation stack description into a Stack entity
+func toStack(s types.Stack) *stack.Stack {
+	tags := make(map[string]string, len(s.Tags))
+	for _, tag := range s.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return stack.NewStack(
+		aws.ToString(s.StackId),
+		aws.ToString(s.StackName),
+		s.StackStatus,
+		aws.ToString(s.StackStatusReason),
+		tags,
+	)
+}
+
+// isStackNotFound checks if a CloudFormation error reports that the stack does not exist.
+// CloudFormation has no dedicated error code for this and returns a ValidationError instead.
+func isStackNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "does not exist")
+}
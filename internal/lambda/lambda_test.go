@@ -4,95 +4,58 @@ import (
 	"context"
 	"errors"
 	"testing"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/shirasu/delambda/pkg/awsfake"
 )
 
-// Mock Lambda Client
-type mockLambdaClient struct {
-	listFunctionsFunc               func(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error)
-	getFunctionFunc                 func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
-	updateFunctionConfigurationFunc func(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
-	deleteFunctionFunc              func(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error)
-}
-
-func (m *mockLambdaClient) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
-	if m.listFunctionsFunc != nil {
-		return m.listFunctionsFunc(ctx, params, optFns...)
-	}
-	return &lambda.ListFunctionsOutput{}, nil
-}
-
-func (m *mockLambdaClient) GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-	if m.getFunctionFunc != nil {
-		return m.getFunctionFunc(ctx, params, optFns...)
-	}
-	return &lambda.GetFunctionOutput{}, nil
-}
-
-func (m *mockLambdaClient) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
-	if m.updateFunctionConfigurationFunc != nil {
-		return m.updateFunctionConfigurationFunc(ctx, params, optFns...)
-	}
-	return &lambda.UpdateFunctionConfigurationOutput{}, nil
-}
-
-func (m *mockLambdaClient) DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
-	if m.deleteFunctionFunc != nil {
-		return m.deleteFunctionFunc(ctx, params, optFns...)
-	}
-	return &lambda.DeleteFunctionOutput{}, nil
-}
+// vpc is the VPC configuration of the functions attached to a VPC
+var vpc = &awsfake.VPC{ID: "vpc-1", Subnets: []string{"subnet-1"}, SecurityGroups: []string{"sg-1"}, IPv6: true}
 
 func TestListFunctions(t *testing.T) {
 	tests := []struct {
 		name      string
-		mockFunc  func(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error)
+		functions []string
+		pageSize  int
+		fault     error
 		wantCount int
 		wantErr   bool
 	}{
 		{
-			name: "successful list with multiple functions",
-			mockFunc: func(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
-				return &lambda.ListFunctionsOutput{
-					Functions: []types.FunctionConfiguration{
-						{FunctionName: aws.String("func1")},
-						{FunctionName: aws.String("func2")},
-					},
-				}, nil
-			},
+			name:      "successful list with multiple functions",
+			functions: []string{"func1", "func2"},
 			wantCount: 2,
-			wantErr:   false,
 		},
 		{
-			name: "empty list",
-			mockFunc: func(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
-				return &lambda.ListFunctionsOutput{
-					Functions: []types.FunctionConfiguration{},
-				}, nil
-			},
-			wantCount: 0,
-			wantErr:   false,
+			name:      "pages are followed",
+			functions: []string{"func1", "func2", "func3"},
+			pageSize:  2,
+			wantCount: 3,
 		},
 		{
-			name: "error listing functions",
-			mockFunc: func(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
-				return nil, errors.New("API error")
-			},
+			name:      "empty list",
 			wantCount: 0,
+		},
+		{
+			name:      "error listing functions",
+			functions: []string{"func1"},
+			fault:     errors.New("API error"),
 			wantErr:   true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				listFunctionsFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{PageSize: tt.pageSize})
+			for _, name := range tt.functions {
+				backend.AddFunction(awsfake.Function{Name: name})
+			}
+			if tt.fault != nil {
+				backend.InjectFault(awsfake.Fault{Operation: "ListFunctions", Err: tt.fault})
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Lambda()}
 
 			functions, err := svc.ListFunctions(context.Background())
 			if (err != nil) != tt.wantErr {
@@ -110,275 +73,190 @@ func TestGetFunction(t *testing.T) {
 	tests := []struct {
 		name         string
 		functionName string
-		mockFunc     func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
 		wantErr      bool
 	}{
 		{
 			name:         "successful get",
 			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return &lambda.GetFunctionOutput{
-					Configuration: &types.FunctionConfiguration{
-						FunctionName: aws.String("test-function"),
-					},
-				}, nil
-			},
-			wantErr: false,
 		},
 		{
 			name:         "function not found",
 			functionName: "non-existent",
-			mockFunc: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return nil, errors.New("ResourceNotFoundException")
-			},
-			wantErr: true,
+			wantErr:      true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				getFunctionFunc: tt.mockFunc,
-			}
-			svc := &Service{client: mock}
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddFunction(awsfake.Function{Name: "test-function"})
+			svc := &Service{client: backend.Lambda()}
 
 			_, err := svc.GetFunction(context.Background(), tt.functionName)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetFunction() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			var notFound *types.ResourceNotFoundException
+			if tt.wantErr && !errors.As(err, &notFound) {
+				t.Errorf("GetFunction() error = %v, want ResourceNotFoundException", err)
+			}
 		})
 	}
 }
 
 func TestDisableIPv6(t *testing.T) {
 	tests := []struct {
-		name               string
-		functionName       string
-		getFunctionMock    func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
-		updateFunctionMock func(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
-		wantErr            bool
+		name    string
+		vpc     *awsfake.VPC
+		wantErr bool
 	}{
 		{
-			name:         "successful IPv6 disable",
-			functionName: "test-function",
-			getFunctionMock: (func() func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				callCount := 0
-				return func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-					callCount++
-					state := types.StateActive
-					status := types.LastUpdateStatusSuccessful
-					if callCount == 1 {
-						status = types.LastUpdateStatusInProgress
-					}
-					return &lambda.GetFunctionOutput{
-						Configuration: &types.FunctionConfiguration{
-							FunctionName: aws.String("test-function"),
-							VpcConfig: &types.VpcConfigResponse{
-								SubnetIds:               []string{"subnet-1"},
-								SecurityGroupIds:        []string{"sg-1"},
-								Ipv6AllowedForDualStack: aws.Bool(true),
-							},
-							State:            state,
-							LastUpdateStatus: status,
-						},
-					}, nil
-				}
-			})(),
-			updateFunctionMock: func(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
-				return &lambda.UpdateFunctionConfigurationOutput{}, nil
-			},
-			wantErr: false,
+			name: "successful IPv6 disable",
+			vpc:  vpc,
 		},
 		{
-			name:         "function not attached to VPC",
-			functionName: "test-function",
-			getFunctionMock: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return &lambda.GetFunctionOutput{
-					Configuration: &types.FunctionConfiguration{
-						FunctionName: aws.String("test-function"),
-						VpcConfig:    nil,
-					},
-				}, nil
-			},
+			name:    "function not attached to VPC",
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				getFunctionFunc:                 tt.getFunctionMock,
-				updateFunctionConfigurationFunc: tt.updateFunctionMock,
-			}
-			svc := &Service{client: mock}
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddFunction(awsfake.Function{Name: "test-function", VPC: tt.vpc})
+			svc := &Service{client: backend.Lambda()}
 
-			err := svc.DisableIPv6(context.Background(), tt.functionName)
+			err := svc.DisableIPv6(context.Background(), "test-function")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DisableIPv6() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantErr {
+				return
+			}
+			config, _ := backend.Function("test-function")
+			if aws.ToBool(config.VpcConfig.Ipv6AllowedForDualStack) || len(config.VpcConfig.SubnetIds) != 1 {
+				t.Errorf("VpcConfig = %+v, want the subnets kept and IPv6 disabled", config.VpcConfig)
+			}
 		})
 	}
 }
 
 func TestDetachVPC(t *testing.T) {
 	tests := []struct {
-		name               string
-		functionName       string
-		getFunctionMock    func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
-		updateFunctionMock func(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
-		wantErr            bool
+		name       string
+		vpc        *awsfake.VPC
+		failUpdate bool
+		wantErr    bool
 	}{
 		{
-			name:         "successful VPC detach",
-			functionName: "test-function",
-			getFunctionMock: (func() func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				callCount := 0
-				return func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-					callCount++
-					state := types.StateActive
-					status := types.LastUpdateStatusSuccessful
-					if callCount == 1 {
-						status = types.LastUpdateStatusInProgress
-					}
-					return &lambda.GetFunctionOutput{
-						Configuration: &types.FunctionConfiguration{
-							FunctionName: aws.String("test-function"),
-							VpcConfig: &types.VpcConfigResponse{
-								SubnetIds:        []string{"subnet-1"},
-								SecurityGroupIds: []string{"sg-1"},
-							},
-							State:            state,
-							LastUpdateStatus: status,
-						},
-					}, nil
-				}
-			})(),
-			updateFunctionMock: func(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
-				return &lambda.UpdateFunctionConfigurationOutput{}, nil
-			},
-			wantErr: false,
+			name: "successful VPC detach",
+			vpc:  vpc,
 		},
 		{
-			name:         "function not attached to VPC",
-			functionName: "test-function",
-			getFunctionMock: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return &lambda.GetFunctionOutput{
-					Configuration: &types.FunctionConfiguration{
-						FunctionName: aws.String("test-function"),
-						VpcConfig: &types.VpcConfigResponse{
-							SubnetIds: []string{},
-						},
-					},
-				}, nil
-			},
+			name:    "function not attached to VPC",
+			vpc:     &awsfake.VPC{},
 			wantErr: true,
 		},
+		{
+			name:       "failed update",
+			vpc:        vpc,
+			failUpdate: true,
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				getFunctionFunc:                 tt.getFunctionMock,
-				updateFunctionConfigurationFunc: tt.updateFunctionMock,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddFunction(awsfake.Function{Name: "test-function", VPC: tt.vpc})
+			if tt.failUpdate {
+				backend.FailNextUpdate("test-function", types.LastUpdateStatusReasonCodeSubnetOutOfIPAddresses)
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Lambda()}
 
-			err := svc.DetachVPC(context.Background(), tt.functionName)
+			err := svc.DetachVPC(context.Background(), "test-function")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DetachVPC() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantErr {
+				return
+			}
+			config, _ := backend.Function("test-function")
+			if len(config.VpcConfig.SubnetIds) != 0 {
+				t.Errorf("VpcConfig.SubnetIds = %v, want none", config.VpcConfig.SubnetIds)
+			}
 		})
 	}
 }
 
 func TestDeleteFunction(t *testing.T) {
 	tests := []struct {
-		name         string
-		functionName string
-		mockFunc     func(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error)
-		wantErr      bool
+		name    string
+		fault   error
+		wantErr bool
 	}{
 		{
-			name:         "successful delete",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
-				return &lambda.DeleteFunctionOutput{}, nil
-			},
-			wantErr: false,
+			name: "successful delete",
 		},
 		{
-			name:         "delete error",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
-				return nil, errors.New("delete failed")
-			},
+			name:    "delete error",
+			fault:   errors.New("delete failed"),
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				deleteFunctionFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddFunction(awsfake.Function{Name: "test-function"})
+			if tt.fault != nil {
+				backend.InjectFault(awsfake.Fault{Operation: "DeleteFunction", Err: tt.fault})
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Lambda()}
 
-			err := svc.DeleteFunction(context.Background(), tt.functionName)
+			err := svc.DeleteFunction(context.Background(), "test-function")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteFunction() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if _, exists := backend.Function("test-function"); exists != tt.wantErr {
+				t.Errorf("function exists = %v, want %v", exists, tt.wantErr)
+			}
 		})
 	}
 }
 
 func TestWaitForFunctionUpdate(t *testing.T) {
 	tests := []struct {
-		name         string
-		functionName string
-		mockFunc     func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
-		wantErr      bool
+		name       string
+		failUpdate bool
+		wantErr    bool
 	}{
 		{
-			name:         "immediate success",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return &lambda.GetFunctionOutput{
-					Configuration: &types.FunctionConfiguration{
-						State:            types.StateActive,
-						LastUpdateStatus: types.LastUpdateStatusSuccessful,
-					},
-				}, nil
-			},
-			wantErr: false,
+			name: "immediate success",
 		},
 		{
-			name:         "failed state",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
-				return &lambda.GetFunctionOutput{
-					Configuration: &types.FunctionConfiguration{
-						State:            types.StateFailed,
-						LastUpdateStatus: types.LastUpdateStatusFailed,
-						StateReasonCode:  types.StateReasonCodeInternalError,
-					},
-				}, nil
-			},
-			wantErr: true,
+			name:       "failed state",
+			failUpdate: true,
+			wantErr:    true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLambdaClient{
-				getFunctionFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddFunction(awsfake.Function{Name: "test-function", VPC: vpc})
+			if tt.failUpdate {
+				backend.FailNextUpdate("test-function", types.LastUpdateStatusReasonCodeInternalError)
 			}
-			svc := &Service{client: mock}
-
-			// Override the sleep interval for testing
-			originalInterval := 5 * time.Second
-			defer func() { _ = originalInterval }()
+			// The fake settles the update right away, failed or not
+			if _, err := backend.Lambda().UpdateFunctionConfiguration(context.Background(), &lambda.UpdateFunctionConfigurationInput{
+				FunctionName: aws.String("test-function"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			svc := &Service{client: backend.Lambda()}
 
-			err := svc.waitForFunctionUpdate(context.Background(), tt.functionName)
+			err := svc.waitForFunctionUpdate(context.Background(), "test-function")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("waitForFunctionUpdate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -5,31 +5,9 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/shirasu/delambda/pkg/awsfake"
 )
 
-// Mock CloudWatch Logs Client
-type mockLogsClient struct {
-	describeLogGroupsFunc func(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
-	deleteLogGroupFunc    func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error)
-}
-
-func (m *mockLogsClient) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
-	if m.describeLogGroupsFunc != nil {
-		return m.describeLogGroupsFunc(ctx, params, optFns...)
-	}
-	return &cloudwatchlogs.DescribeLogGroupsOutput{}, nil
-}
-
-func (m *mockLogsClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-	if m.deleteLogGroupFunc != nil {
-		return m.deleteLogGroupFunc(ctx, params, optFns...)
-	}
-	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
-}
-
 func TestGetLogGroupName(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -61,53 +39,38 @@ func TestLogGroupExists(t *testing.T) {
 	tests := []struct {
 		name         string
 		logGroupName string
-		mockFunc     func(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+		fault        error
 		want         bool
 		wantErr      bool
 	}{
 		{
 			name:         "log group exists",
 			logGroupName: "/aws/lambda/test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
-				return &cloudwatchlogs.DescribeLogGroupsOutput{
-					LogGroups: []types.LogGroup{
-						{
-							LogGroupName: aws.String("/aws/lambda/test-function"),
-						},
-					},
-				}, nil
-			},
-			want:    true,
-			wantErr: false,
+			want:         true,
 		},
 		{
+			// The prefix matches /aws/lambda/test-function-v2 only
 			name:         "log group does not exist",
-			logGroupName: "/aws/lambda/non-existent",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
-				return &cloudwatchlogs.DescribeLogGroupsOutput{
-					LogGroups: []types.LogGroup{},
-				}, nil
-			},
-			want:    false,
-			wantErr: false,
+			logGroupName: "/aws/lambda/test",
+			want:         false,
 		},
 		{
 			name:         "API error",
 			logGroupName: "/aws/lambda/test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
-				return nil, errors.New("API error")
-			},
-			want:    false,
-			wantErr: true,
+			fault:        errors.New("API error"),
+			wantErr:      true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLogsClient{
-				describeLogGroupsFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/test-function"})
+			backend.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/test-function-v2"})
+			if tt.fault != nil {
+				backend.InjectFault(awsfake.Fault{Operation: "DescribeLogGroups", Err: tt.fault})
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Logs()}
 
 			got, err := svc.LogGroupExists(context.Background(), tt.logGroupName)
 			if (err != nil) != tt.wantErr {
@@ -125,90 +88,77 @@ func TestDeleteLogGroup(t *testing.T) {
 	tests := []struct {
 		name         string
 		logGroupName string
-		mockFunc     func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error)
+		fault        error
 		wantErr      bool
 	}{
 		{
 			name:         "successful delete",
 			logGroupName: "/aws/lambda/test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-				return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
-			},
-			wantErr: false,
 		},
 		{
 			name:         "log group not found (should not error)",
 			logGroupName: "/aws/lambda/non-existent",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-				return nil, errors.New("ResourceNotFoundException: log group not found")
-			},
-			wantErr: false,
 		},
 		{
 			name:         "other error",
 			logGroupName: "/aws/lambda/test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-				return nil, errors.New("InternalError")
-			},
-			wantErr: true,
+			fault:        errors.New("InternalError"),
+			wantErr:      true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLogsClient{
-				deleteLogGroupFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/test-function"})
+			if tt.fault != nil {
+				backend.InjectFault(awsfake.Fault{Operation: "DeleteLogGroup", Err: tt.fault})
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Logs()}
 
 			err := svc.DeleteLogGroup(context.Background(), tt.logGroupName)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteLogGroup() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if !tt.wantErr && backend.HasLogGroup(tt.logGroupName) {
+				t.Errorf("log group %s still exists", tt.logGroupName)
+			}
 		})
 	}
 }
 
 func TestDeleteFunctionLogGroup(t *testing.T) {
 	tests := []struct {
-		name         string
-		functionName string
-		mockFunc     func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error)
-		wantErr      bool
+		name    string
+		fault   error
+		wantErr bool
 	}{
 		{
-			name:         "successful delete",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-				expectedLogGroupName := "/aws/lambda/test-function"
-				if *params.LogGroupName != expectedLogGroupName {
-					t.Errorf("expected log group name %s, got %s", expectedLogGroupName, *params.LogGroupName)
-				}
-				return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
-			},
-			wantErr: false,
+			name: "successful delete",
 		},
 		{
-			name:         "delete error",
-			functionName: "test-function",
-			mockFunc: func(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
-				return nil, errors.New("InternalError")
-			},
+			name:    "delete error",
+			fault:   errors.New("InternalError"),
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &mockLogsClient{
-				deleteLogGroupFunc: tt.mockFunc,
+			backend := awsfake.New(awsfake.Options{})
+			backend.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/test-function"})
+			if tt.fault != nil {
+				backend.InjectFault(awsfake.Fault{Operation: "DeleteLogGroup", Err: tt.fault})
 			}
-			svc := &Service{client: mock}
+			svc := &Service{client: backend.Logs()}
 
-			err := svc.DeleteFunctionLogGroup(context.Background(), tt.functionName)
+			err := svc.DeleteFunctionLogGroup(context.Background(), "test-function")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteFunctionLogGroup() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if backend.HasLogGroup("/aws/lambda/test-function") != tt.wantErr {
+				t.Errorf("log group /aws/lambda/test-function exists = %v, want %v", !tt.wantErr, tt.wantErr)
+			}
 		})
 	}
 }
@@ -0,0 +1,416 @@
+// Package tui implements the interactive terminal picker of delambda
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrCanceled is returned when the user leaves the picker without confirming an action
+var ErrCanceled = errors.New("selection canceled")
+
+// minPreviewWidth is the terminal width below which the preview pane is hidden
+const minPreviewWidth = 80
+
+// Item is an entry of a Picker
+type Item struct {
+	// Label is the main text of the item, such as a function name
+	Label string
+
+	// Detail is shown after the label and searched along with it, such as a stack name
+	Detail string
+
+	// Badges are short indicators shown at the end of the line, such as VPC
+	Badges []string
+
+	// Preview is shown in the preview pane while the item is under the cursor
+	Preview []string
+}
+
+// Action is what the selected items can be handed to
+type Action struct {
+	// Key chooses the action when the picker offers more than one
+	Key rune
+
+	// Label describes the action in the key help, such as "detach VPC"
+	Label string
+
+	// Confirm is the question asked before the action is taken, formatted
+	// with the number of selected items
+	Confirm string
+}
+
+// Result is the action confirmed in a picker and the items it applies to
+type Result struct {
+	Action Action
+
+	// Selected are the indices of the selected items, in item order
+	Selected []int
+}
+
+// mode is what the keys of a picker currently do
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeChoose
+	modeConfirm
+)
+
+// match is an item matching the query, with how well it matches
+type match struct {
+	index int
+	score int
+}
+
+// Picker is the state of a picker: the query, the items matching it, the
+// cursor and the selection. It does not touch the terminal; Run connects it
+// to one, and tests drive it with HandleKey and Render.
+type Picker struct {
+	items    []Item
+	actions  []Action
+	query    []rune
+	matches  []match
+	cursor   int
+	offset   int
+	selected map[int]bool
+	mode     mode
+	action   Action
+	result   *Result
+	canceled bool
+
+	// notice replaces the key help until the next key press
+	notice string
+}
+
+// NewPicker creates a Picker over items whose selection can be handed to one of actions
+func NewPicker(items []Item, actions []Action) *Picker {
+	p := &Picker{
+		items:    items,
+		actions:  actions,
+		selected: make(map[int]bool),
+	}
+	p.filter()
+	return p
+}
+
+// Done checks if the user confirmed an action or left the picker
+func (p *Picker) Done() bool {
+	return p.result != nil || p.canceled
+}
+
+// Result returns the confirmed action and selection, or ErrCanceled
+func (p *Picker) Result() (*Result, error) {
+	if p.result == nil {
+		return nil, ErrCanceled
+	}
+	return p.result, nil
+}
+
+// filter updates the matches for the query, best first, and moves the cursor to the top
+func (p *Picker) filter() {
+	p.matches = p.matches[:0]
+	for i, item := range p.items {
+		if score, ok := fuzzyScore(p.query, item.Label+" "+item.Detail); ok {
+			p.matches = append(p.matches, match{index: i, score: score})
+		}
+	}
+	slices.SortStableFunc(p.matches, func(a, b match) int {
+		return b.score - a.score
+	})
+	p.cursor = 0
+	p.offset = 0
+}
+
+// fuzzyScore checks if the runes of query appear in s in order, ignoring case,
+// and scores the match: consecutive runes and runes at the start of a word
+// score higher, so "apih" ranks "api-handler" above "app-invoice-hook"
+func fuzzyScore(query []rune, s string) (int, bool) {
+	if len(query) == 0 {
+		return 0, true
+	}
+	score, q := 0, 0
+	prevMatched := false
+	prev := rune(0)
+	for i, r := range s {
+		if q < len(query) && unicode.ToLower(r) == unicode.ToLower(query[q]) {
+			score++
+			if prevMatched {
+				score += 5
+			}
+			if i == 0 || strings.ContainsRune("-_./: ", prev) {
+				score += 3
+			}
+			q++
+			prevMatched = true
+		} else {
+			prevMatched = false
+		}
+		prev = r
+	}
+	return score, q == len(query)
+}
+
+// current returns the index of the item under the cursor, or false if nothing matches
+func (p *Picker) current() (int, bool) {
+	if len(p.matches) == 0 {
+		return 0, false
+	}
+	return p.matches[p.cursor].index, true
+}
+
+// selection returns the indices of the selected items in item order
+func (p *Picker) selection() []int {
+	indices := make([]int, 0, len(p.selected))
+	for i := range p.selected {
+		indices = append(indices, i)
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// move moves the cursor by delta, staying on the matches
+func (p *Picker) move(delta int) {
+	p.cursor = max(0, min(p.cursor+delta, len(p.matches)-1))
+}
+
+// toggle selects the item under the cursor, or deselects it if it was selected
+func (p *Picker) toggle() {
+	i, ok := p.current()
+	if !ok {
+		return
+	}
+	if p.selected[i] {
+		delete(p.selected, i)
+	} else {
+		p.selected[i] = true
+	}
+}
+
+// toggleAll selects every matching item, or deselects them if all were selected
+func (p *Picker) toggleAll() {
+	all := true
+	for _, m := range p.matches {
+		if !p.selected[m.index] {
+			all = false
+			break
+		}
+	}
+	for _, m := range p.matches {
+		if all {
+			delete(p.selected, m.index)
+		} else {
+			p.selected[m.index] = true
+		}
+	}
+}
+
+// HandleKey updates the picker for a key press
+func (p *Picker) HandleKey(k Key) {
+	if k.Type == KeyCtrlC {
+		p.canceled = true
+		return
+	}
+	p.notice = ""
+
+	switch p.mode {
+	case modeBrowse:
+		p.browse(k)
+	case modeChoose:
+		switch k.Type {
+		case KeyRune:
+			for _, a := range p.actions {
+				if unicode.ToLower(k.Rune) == a.Key {
+					p.action = a
+					p.mode = modeConfirm
+				}
+			}
+		case KeyEscape:
+			p.mode = modeBrowse
+		}
+	case modeConfirm:
+		if k.Type == KeyRune && (k.Rune == 'y' || k.Rune == 'Y') {
+			p.result = &Result{Action: p.action, Selected: p.selection()}
+			return
+		}
+		// Anything but yes goes back to the list with nothing selected
+		clear(p.selected)
+		p.mode = modeBrowse
+	}
+}
+
+// browse handles a key press while the list is shown
+func (p *Picker) browse(k Key) {
+	switch k.Type {
+	case KeyUp, KeyCtrlP:
+		p.move(-1)
+	case KeyDown, KeyCtrlN:
+		p.move(1)
+	case KeyPageUp:
+		p.move(-10)
+	case KeyPageDown:
+		p.move(10)
+	case KeyTab:
+		p.toggle()
+		p.move(1)
+	case KeyCtrlA:
+		p.toggleAll()
+	case KeyCtrlU:
+		p.query = p.query[:0]
+		p.filter()
+	case KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case KeyEscape:
+		p.canceled = true
+	case KeyRune:
+		if k.Rune == ' ' {
+			p.toggle()
+			p.move(1)
+			return
+		}
+		p.query = append(p.query, k.Rune)
+		p.filter()
+	case KeyEnter:
+		// Only explicitly selected items are acted on
+		if len(p.selected) == 0 {
+			p.notice = "Nothing selected: select with tab or space first"
+			return
+		}
+		if len(p.actions) == 0 {
+			return
+		}
+		if len(p.actions) == 1 {
+			p.action = p.actions[0]
+			p.mode = modeConfirm
+			return
+		}
+		p.mode = modeChoose
+	}
+}
+
+// Render draws the picker as width columns by height lines: the query, the
+// matching items next to a preview of the current one, and the key help or
+// the question being asked
+func (p *Picker) Render(width, height int) string {
+	width = max(width, 20)
+	height = max(height, 3)
+	listHeight := height - 2
+
+	// Keep the cursor on screen
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+listHeight {
+		p.offset = p.cursor - listHeight + 1
+	}
+
+	listWidth, previewWidth := width, 0
+	if width >= minPreviewWidth {
+		listWidth = width * 3 / 5
+		previewWidth = width - listWidth - 3
+	}
+	preview := p.preview()
+
+	var b strings.Builder
+	status := fmt.Sprintf("%d/%d, %d selected", len(p.matches), len(p.items), len(p.selected))
+	prompt := "> " + string(p.query)
+	b.WriteString(fit(prompt, width-len(status)-1) + " " + status + "\r\n")
+
+	for row := range listHeight {
+		line := p.row(p.offset+row, listWidth)
+		if previewWidth > 0 {
+			var text string
+			if row < len(preview) {
+				text = preview[row]
+			}
+			line += " │ " + fit(text, previewWidth)
+		}
+		b.WriteString(line + "\r\n")
+	}
+
+	b.WriteString(fit(p.help(), width))
+	return b.String()
+}
+
+// row renders the match at position i of the list, highlighted under the cursor
+func (p *Picker) row(i, width int) string {
+	if i >= len(p.matches) {
+		return fit("", width)
+	}
+	item := p.items[p.matches[i].index]
+
+	check := "[ ]"
+	if p.selected[p.matches[i].index] {
+		check = "[x]"
+	}
+	badges := strings.Join(item.Badges, " ")
+	text := check + " " + item.Label
+	if item.Detail != "" {
+		text += "  " + item.Detail
+	}
+	text = fit(text, width-len([]rune(badges))-1) + " " + badges
+	text = fit(text, width)
+
+	if i == p.cursor {
+		return "\x1b[7m" + text + "\x1b[0m"
+	}
+	return text
+}
+
+// preview returns the preview pane: the selection when asking for confirmation,
+// otherwise the item under the cursor
+func (p *Picker) preview() []string {
+	if p.mode == modeConfirm {
+		lines := []string{"Selected:"}
+		for _, i := range p.selection() {
+			lines = append(lines, "  "+p.items[i].Label)
+		}
+		return lines
+	}
+	i, ok := p.current()
+	if !ok {
+		return nil
+	}
+	return p.items[i].Preview
+}
+
+// help returns the last line: the keys of the list, why enter did nothing,
+// the actions to choose from or the question asked before acting
+func (p *Picker) help() string {
+	switch p.mode {
+	case modeChoose:
+		keys := make([]string, 0, len(p.actions)+1)
+		for _, a := range p.actions {
+			keys = append(keys, fmt.Sprintf("%c: %s", a.Key, a.Label))
+		}
+		return strings.Join(append(keys, "esc: back"), " · ")
+	case modeConfirm:
+		return fmt.Sprintf(p.action.Confirm, len(p.selected)) + " [y/N]"
+	case modeBrowse:
+		if p.notice != "" {
+			return p.notice
+		}
+	}
+	return "↑/↓: move · tab/space: select · ctrl-a: select all · enter: act on the selection · ctrl-u: clear · esc: quit"
+}
+
+// fit truncates or pads s to exactly width runes
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// keys converts a string to rune key presses
+func keys(s string) []Key {
+	var ks []Key
+	for _, r := range s {
+		ks = append(ks, Key{Type: KeyRune, Rune: r})
+	}
+	return ks
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		better string
+		worse  string
+	}{
+		{name: "consecutive runes rank higher", query: "api", better: "api-handler", worse: "app-invoice"},
+		{name: "word starts rank higher", query: "ih", better: "invoice-hook", worse: "dish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, ok := fuzzyScore([]rune(tt.query), tt.better)
+			if !ok {
+				t.Fatalf("fuzzyScore(%q, %q) did not match", tt.query, tt.better)
+			}
+			worse, ok := fuzzyScore([]rune(tt.query), tt.worse)
+			if !ok {
+				t.Fatalf("fuzzyScore(%q, %q) did not match", tt.query, tt.worse)
+			}
+			if better <= worse {
+				t.Errorf("score of %q = %d, want more than %d for %q", tt.better, better, worse, tt.worse)
+			}
+		})
+	}
+
+	if _, ok := fuzzyScore([]rune("xyz"), "api-handler"); ok {
+		t.Error("fuzzyScore() matched runes that are not in the string")
+	}
+	if _, ok := fuzzyScore([]rune("API"), "api-handler"); !ok {
+		t.Error("fuzzyScore() did not ignore case")
+	}
+}
+
+func TestPicker(t *testing.T) {
+	items := []Item{
+		{Label: "api-handler", Detail: "dev-api", Badges: []string{"VPC"}},
+		{Label: "app-invoice-hook"},
+		{Label: "worker", Detail: "dev-jobs"},
+	}
+	actions := []Action{
+		{Key: 'd', Label: "detach VPC", Confirm: "Detach VPC from %d function(s)?"},
+		{Key: 'x', Label: "delete", Confirm: "Delete %d function(s)?"},
+	}
+
+	tests := []struct {
+		name       string
+		keys       []Key
+		wantAction rune
+		want       []int
+		wantErr    error
+	}{
+		{
+			name:       "enter acts on the selected item",
+			keys:       []Key{{Type: KeyDown}, {Type: KeyTab}, {Type: KeyEnter}, {Type: KeyRune, Rune: 'x'}, {Type: KeyRune, Rune: 'y'}},
+			wantAction: 'x',
+			want:       []int{1},
+		},
+		{
+			name:    "enter without a selection does nothing",
+			keys:    []Key{{Type: KeyDown}, {Type: KeyEnter}, {Type: KeyRune, Rune: 'x'}, {Type: KeyEscape}},
+			wantErr: ErrCanceled,
+		},
+		{
+			name:       "search and multi-select",
+			keys:       append(append(keys("dev"), Key{Type: KeyCtrlA}, Key{Type: KeyEnter}), keys("dy")...),
+			wantAction: 'd',
+			want:       []int{0, 2},
+		},
+		{
+			name:       "selection is kept across searches",
+			keys:       append(append(append(keys("work"), Key{Type: KeyTab}, Key{Type: KeyCtrlU}), keys("apih ")...), Key{Type: KeyEnter}, Key{Type: KeyRune, Rune: 'x'}, Key{Type: KeyRune, Rune: 'y'}),
+			wantAction: 'x',
+			want:       []int{0, 2},
+		},
+		{
+			name:    "declining the confirmation goes back to the list",
+			keys:    []Key{{Type: KeyTab}, {Type: KeyEnter}, {Type: KeyRune, Rune: 'd'}, {Type: KeyRune, Rune: 'n'}, {Type: KeyEscape}},
+			wantErr: ErrCanceled,
+		},
+		{
+			name:       "declining the confirmation clears the selection",
+			keys:       []Key{{Type: KeyTab}, {Type: KeyEnter}, {Type: KeyRune, Rune: 'd'}, {Type: KeyRune, Rune: 'n'}, {Type: KeyTab}, {Type: KeyEnter}, {Type: KeyRune, Rune: 'x'}, {Type: KeyRune, Rune: 'y'}},
+			wantAction: 'x',
+			want:       []int{1},
+		},
+		{
+			name:    "escape from the actions goes back to the list",
+			keys:    []Key{{Type: KeyTab}, {Type: KeyEnter}, {Type: KeyEscape}, {Type: KeyCtrlC}},
+			wantErr: ErrCanceled,
+		},
+		{
+			name:    "enter without matches does nothing",
+			keys:    append(keys("zzz"), Key{Type: KeyEnter}, Key{Type: KeyRune, Rune: 'd'}, Key{Type: KeyEscape}),
+			wantErr: ErrCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPicker(items, actions)
+			for _, k := range tt.keys {
+				if p.Done() {
+					t.Fatalf("picker done before key %+v", k)
+				}
+				p.HandleKey(k)
+			}
+			if !p.Done() {
+				t.Fatal("picker not done after all keys")
+			}
+
+			result, err := p.Result()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Result() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Action.Key != tt.wantAction {
+				t.Errorf("Result() action = %c, want %c", result.Action.Key, tt.wantAction)
+			}
+			if !reflect.DeepEqual(result.Selected, tt.want) {
+				t.Errorf("Result() selected = %v, want %v", result.Selected, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickerRender(t *testing.T) {
+	items := []Item{
+		{Label: "api-handler", Detail: "dev-api", Badges: []string{"VPC"}, Preview: []string{"Function: api-handler"}},
+		{Label: "worker"},
+	}
+	p := NewPicker(items, []Action{{Key: 'x', Label: "delete", Confirm: "Delete %d function(s)?"}})
+
+	lines := strings.Split(p.Render(100, 5), "\r\n")
+	if len(lines) != 5 {
+		t.Fatalf("Render() = %d lines, want 5", len(lines))
+	}
+	if !strings.Contains(lines[1], "[ ] api-handler  dev-api") || !strings.Contains(lines[1], "VPC") {
+		t.Errorf("Render() first item = %q", lines[1])
+	}
+	if !strings.Contains(lines[1], "│ Function: api-handler") {
+		t.Errorf("Render() preview = %q", lines[1])
+	}
+
+	p.HandleKey(Key{Type: KeyEnter})
+	lines = strings.Split(p.Render(60, 5), "\r\n")
+	if !strings.HasPrefix(lines[4], "Nothing selected") {
+		t.Errorf("Render() notice = %q", lines[4])
+	}
+
+	p.HandleKey(Key{Type: KeyTab})
+	p.HandleKey(Key{Type: KeyEnter})
+	lines = strings.Split(p.Render(60, 5), "\r\n")
+	if !strings.HasPrefix(lines[4], "Delete 1 function(s)? [y/N]") {
+		t.Errorf("Render() question = %q", lines[4])
+	}
+	if strings.Contains(lines[1], "│") {
+		t.Errorf("Render() shows the preview in a narrow terminal: %q", lines[1])
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a\x1b[B\x1b[C\t\r\x7fé\x03"))
+	want := []Key{
+		{Type: KeyRune, Rune: 'a'},
+		{Type: KeyDown},
+		{Type: KeyTab},
+		{Type: KeyEnter},
+		{Type: KeyBackspace},
+		{Type: KeyRune, Rune: 'é'},
+		{Type: KeyCtrlC},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %+v, want %+v", got, want)
+	}
+
+	if got := parseKeys([]byte("\x1b")); !reflect.DeepEqual(got, []Key{{Type: KeyEscape}}) {
+		t.Errorf("parseKeys(escape) = %+v", got)
+	}
+}
@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// KeyType is the kind of a key press
+type KeyType int
+
+const (
+	// KeyRune is a printable character, in Key.Rune
+	KeyRune KeyType = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyCtrlA
+	KeyCtrlC
+	KeyCtrlN
+	KeyCtrlP
+	KeyCtrlU
+)
+
+// Key is a key press
+type Key struct {
+	Type KeyType
+	Rune rune
+}
+
+// escapeSequences are the input sequences of the special keys the picker handles
+var escapeSequences = map[string]KeyType{
+	"\x1b[A":  KeyUp,
+	"\x1bOA":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+}
+
+// controlKeys are the control characters the picker handles
+var controlKeys = map[byte]KeyType{
+	0x01: KeyCtrlA,
+	0x03: KeyCtrlC,
+	0x09: KeyTab,
+	0x0d: KeyEnter,
+	0x0a: KeyEnter,
+	0x0e: KeyCtrlN,
+	0x10: KeyCtrlP,
+	0x15: KeyCtrlU,
+	0x7f: KeyBackspace,
+	0x08: KeyBackspace,
+}
+
+// parseKeys decodes the key presses in a chunk of terminal input. A lone
+// escape is the escape key; an escape sequence the picker does not handle,
+// such as left or right, is dropped.
+func parseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			if len(b) == 1 {
+				keys = append(keys, Key{Type: KeyEscape})
+				break
+			}
+			n := escapeSequenceLength(b)
+			if keyType, ok := escapeSequences[string(b[:n])]; ok {
+				keys = append(keys, Key{Type: keyType})
+			}
+			b = b[n:]
+			continue
+		}
+		if keyType, ok := controlKeys[b[0]]; ok {
+			keys = append(keys, Key{Type: keyType})
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if r != utf8.RuneError && printable(r) {
+			keys = append(keys, Key{Type: KeyRune, Rune: r})
+		}
+		b = b[size:]
+	}
+	return keys
+}
+
+// printable checks if r is a printable character rather than a control character
+func printable(r rune) bool {
+	return r >= 0x20 && r != 0x7f
+}
+
+// escapeSequenceLength returns the length of the escape sequence at the start
+// of b: ESC [ parameters final-byte, ESC O letter, or ESC followed by one byte
+func escapeSequenceLength(b []byte) int {
+	switch {
+	case len(b) >= 3 && b[1] == '[':
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(b)
+	case len(b) >= 3 && b[1] == 'O':
+		return 3
+	default:
+		return min(2, len(b))
+	}
+}
+
+// Run shows the picker on the terminal of in and out until the user confirms
+// an action or leaves, and returns the result. It switches to the alternate
+// screen and restores the terminal before returning.
+func Run(in, out *os.File, p *Picker) (*Result, error) {
+	inFd, outFd := int(in.Fd()), int(out.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return nil, errors.New("the picker needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(inFd, state)
+
+	// Alternate screen with a hidden cursor, restored on return
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 256)
+	for !p.Done() {
+		width, height, err := term.GetSize(outFd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Fprint(out, "\x1b[H"+p.Render(width, height))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from terminal: %w", err)
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			p.HandleKey(k)
+			if p.Done() {
+				break
+			}
+		}
+	}
+	return p.Result()
+}
@@ -0,0 +1,427 @@
+// Package awsfake is a stateful, in-memory fake of the Lambda, CloudWatch Logs
+// and CloudFormation API calls delambda makes. It models function state
+// transitions, stack deletion, pagination and injected faults, so code built
+// on delambda can be tested end to end without an AWS account.
+//
+//	backend := awsfake.New(awsfake.Options{TransitionPolls: 2})
+//	backend.AddFunction(awsfake.Function{
+//		Name: "api",
+//		VPC:  &awsfake.VPC{ID: "vpc-1", Subnets: []string{"subnet-1"}},
+//	})
+//	backend.AddStack(awsfake.Stack{
+//		Name:      "pr-123",
+//		Resources: []awsfake.Resource{awsfake.FunctionResource("Api", "api")},
+//	})
+//	engine, err := delambda.NewWithClients(delambda.Clients{
+//		Lambda:         backend.Lambda(),
+//		Logs:           backend.Logs(),
+//		CloudFormation: backend.CloudFormation(),
+//	})
+//
+// Errors are the SDK's own error types, so code that inspects them with
+// errors.As behaves as it does against AWS. The clients are Go values, not
+// HTTP endpoints, so they serve code that takes the API interfaces; the CLI,
+// which creates SDK clients, is tested with recorded cassettes instead.
+package awsfake
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// DefaultRegion is the region of the resources unless Options.Region is set
+	DefaultRegion = "us-east-1"
+
+	// DefaultAccount is the account of the resources unless Options.Account is set
+	DefaultAccount = "123456789012"
+
+	// defaultPageSize is how many items a list call returns per page by default
+	defaultPageSize = 50
+)
+
+// Options configures a Backend
+type Options struct {
+	// Region and Account are used in the ARNs and IDs of the resources
+	Region  string
+	Account string
+
+	// PageSize is the most items a list call returns per page, unless the
+	// request asks for fewer. The default is 50.
+	PageSize int
+
+	// TransitionPolls is how many GetFunction calls a function stays Pending
+	// after it was added as pending, or InProgress after a configuration
+	// update, before it settles. Zero settles changes right away.
+	TransitionPolls int
+
+	// StackDeletePolls is how many DescribeStacks calls a stack stays
+	// DELETE_IN_PROGRESS after DeleteStack. Zero deletes stacks right away.
+	StackDeletePolls int
+
+	// Now returns the time of stack events. The default is time.Now.
+	Now func() time.Time
+}
+
+// Backend holds the state shared by the fake clients. It is safe for concurrent use.
+type Backend struct {
+	mu   sync.Mutex
+	opts Options
+
+	functions map[string]*function
+	logGroups map[string]*logGroup
+	stacks    []*stack
+	faults    []*Fault
+	calls     map[string]int
+	sequence  int
+}
+
+// New creates an empty Backend
+func New(opts Options) *Backend {
+	if opts.Region == "" {
+		opts.Region = DefaultRegion
+	}
+	if opts.Account == "" {
+		opts.Account = DefaultAccount
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Backend{
+		opts:      opts,
+		functions: make(map[string]*function),
+		logGroups: make(map[string]*logGroup),
+		calls:     make(map[string]int),
+	}
+}
+
+// Lambda returns a client of the fake Lambda API
+func (b *Backend) Lambda() *LambdaClient {
+	return &LambdaClient{b: b}
+}
+
+// Logs returns a client of the fake CloudWatch Logs API
+func (b *Backend) Logs() *LogsClient {
+	return &LogsClient{b: b}
+}
+
+// CloudFormation returns a client of the fake CloudFormation API
+func (b *Backend) CloudFormation() *CloudFormationClient {
+	return &CloudFormationClient{b: b}
+}
+
+// Fault makes calls of an operation fail
+type Fault struct {
+	// Operation is the name of the API operation, e.g. "DeleteFunction"
+	Operation string
+
+	// Resource limits the fault to calls for this function, log group or
+	// stack name. Calls for any resource fail if it is empty.
+	Resource string
+
+	// Err is returned instead of performing the call, e.g.
+	// &types.TooManyRequestsException{} to simulate throttling
+	Err error
+
+	// Times is how many calls fail before the operation works again.
+	// Zero fails every call.
+	Times int
+}
+
+// InjectFault makes the matching calls fail with f.Err. Faults are matched
+// in the order they were injected.
+func (b *Backend) InjectFault(f Fault) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.faults = append(b.faults, &f)
+}
+
+// Calls returns how often an operation has been called, including calls that
+// failed because of a fault
+func (b *Backend) Calls(operation string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls[operation]
+}
+
+// call counts a call of operation for resource and returns the error of the
+// first matching fault. The caller must hold b.mu.
+func (b *Backend) call(operation, resource string) error {
+	b.calls[operation]++
+	for i, f := range b.faults {
+		if f.Operation != operation || (f.Resource != "" && f.Resource != resource) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				b.faults = slices.Delete(b.faults, i, i+1)
+			}
+		}
+		return f.Err
+	}
+	return nil
+}
+
+// nextID returns a number that is unique within the backend. The caller must hold b.mu.
+func (b *Backend) nextID() int {
+	b.sequence++
+	return b.sequence
+}
+
+// page returns the page of items starting at token, which is the index
+// returned as the next token of the previous page, and the next token.
+// limit caps the page size below the backend's page size if it is positive.
+func page[T any](items []T, token *string, limit int32, pageSize int) ([]T, *string, error) {
+	start := 0
+	if token != nil {
+		n, err := strconv.Atoi(*token)
+		if err != nil || n < 0 || n > len(items) {
+			return nil, nil, validationError(fmt.Sprintf("Invalid pagination token %q", *token))
+		}
+		start = n
+	}
+	if limit > 0 && int(limit) < pageSize {
+		pageSize = int(limit)
+	}
+
+	end := min(start+pageSize, len(items))
+	if end == len(items) {
+		return items[start:end], nil, nil
+	}
+	next := strconv.Itoa(end)
+	return items[start:end], &next, nil
+}
+
+// validationError returns the error AWS returns for an invalid request, and
+// CloudFormation returns for a stack that does not exist
+func validationError(message string) error {
+	return &smithy.GenericAPIError{Code: "ValidationError", Message: message, Fault: smithy.FaultClient}
+}
+
+// Function describes a function added with AddFunction
+type Function struct {
+	// Name is the function name
+	Name string
+
+	// Runtime defaults to python3.12
+	Runtime types.Runtime
+
+	// VPC attaches the function to a VPC
+	VPC *VPC
+
+	// Tags are the function's tags. AddStack adds the CloudFormation tags.
+	Tags map[string]string
+
+	// Pending adds the function in the Pending state it has right after creation
+	Pending bool
+}
+
+// VPC is the VPC configuration of a function
+type VPC struct {
+	ID             string
+	Subnets        []string
+	SecurityGroups []string
+
+	// IPv6 allows IPv6 traffic for dual-stack subnets
+	IPv6 bool
+}
+
+// AddFunction adds a function, replacing any function of the same name
+func (b *Backend) AddFunction(fn Function) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	runtime := fn.Runtime
+	if runtime == "" {
+		runtime = types.RuntimePython312
+	}
+	f := &function{
+		config: types.FunctionConfiguration{
+			FunctionName:     &fn.Name,
+			FunctionArn:      ptr(b.functionARN(fn.Name)),
+			Runtime:          runtime,
+			State:            types.StateActive,
+			LastUpdateStatus: types.LastUpdateStatusSuccessful,
+		},
+		tags: maps.Clone(fn.Tags),
+	}
+	if f.tags == nil {
+		f.tags = make(map[string]string)
+	}
+	if fn.VPC != nil {
+		f.config.VpcConfig = &types.VpcConfigResponse{
+			VpcId:                   ptr(fn.VPC.ID),
+			SubnetIds:               slices.Clone(fn.VPC.Subnets),
+			SecurityGroupIds:        slices.Clone(fn.VPC.SecurityGroups),
+			Ipv6AllowedForDualStack: ptr(fn.VPC.IPv6),
+		}
+	}
+	if fn.Pending {
+		f.config.State = types.StatePending
+		f.remainingPolls = b.opts.TransitionPolls
+	}
+	b.functions[fn.Name] = f
+}
+
+// Function returns the current configuration of a function, or false if it does not exist
+func (b *Backend) Function(name string) (types.FunctionConfiguration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f, ok := b.functions[name]
+	if !ok {
+		return types.FunctionConfiguration{}, false
+	}
+	return f.snapshot(), true
+}
+
+// FailNextUpdate makes the next configuration update of a function end with
+// LastUpdateStatus Failed and reasonCode, leaving its configuration unchanged
+func (b *Backend) FailNextUpdate(name string, reasonCode types.LastUpdateStatusReasonCode) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if f, ok := b.functions[name]; ok {
+		f.failNextUpdate = reasonCode
+	}
+}
+
+// LogGroup describes a log group added with AddLogGroup
+type LogGroup struct {
+	Name        string
+	StoredBytes int64
+
+	// LastEventTime is when the latest event was ingested, none if zero
+	LastEventTime time.Time
+}
+
+// AddLogGroup adds a log group, replacing any log group of the same name
+func (b *Backend) AddLogGroup(lg LogGroup) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.logGroups[lg.Name] = &logGroup{
+		name:          lg.Name,
+		storedBytes:   lg.StoredBytes,
+		creationTime:  b.opts.Now(),
+		lastEventTime: lg.LastEventTime,
+	}
+}
+
+// HasLogGroup checks if a log group exists
+func (b *Backend) HasLogGroup(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, ok := b.logGroups[name]
+	return ok
+}
+
+// Stack describes a stack added with AddStack
+type Stack struct {
+	Name string
+	Tags map[string]string
+
+	// Resources are the stack's resources, in CREATE_COMPLETE state
+	Resources []Resource
+
+	// Nested are the stack's nested stacks, added as AWS::CloudFormation::Stack resources
+	Nested []Stack
+
+	// LogicalID is the logical ID of a nested stack in its parent. It defaults to the name.
+	LogicalID string
+
+	// DeleteFailure makes the deletion of the stack end in DELETE_FAILED with this reason
+	DeleteFailure string
+}
+
+// Resource is a resource of a stack
+type Resource struct {
+	LogicalID  string
+	Type       string
+	PhysicalID string
+}
+
+// FunctionResource returns an AWS::Lambda::Function resource for the named function
+func FunctionResource(logicalID, functionName string) Resource {
+	return Resource{LogicalID: logicalID, Type: "AWS::Lambda::Function", PhysicalID: functionName}
+}
+
+// AddStack adds a stack in CREATE_COMPLETE state together with its nested
+// stacks, and returns its stack ID. Functions already added that are
+// resources of the stack get the tags CloudFormation adds.
+func (b *Backend) AddStack(st Stack) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.addStack(st, nil).id
+}
+
+// addStack adds st as a nested stack of parent, if any. The caller must hold b.mu.
+func (b *Backend) addStack(st Stack, parent *stack) *stack {
+	s := &stack{
+		id:            fmt.Sprintf("arn:aws:cloudformation:%s:%s:stack/%s/%08d-fake", b.opts.Region, b.opts.Account, st.Name, b.nextID()),
+		name:          st.Name,
+		status:        cfntypes.StackStatusCreateComplete,
+		tags:          maps.Clone(st.Tags),
+		resources:     slices.Clone(st.Resources),
+		deleteFailure: st.DeleteFailure,
+	}
+	if parent != nil {
+		s.parentID = parent.id
+	}
+	b.stacks = append(b.stacks, s)
+	b.addEvent(s, st.Name, "AWS::CloudFormation::Stack", s.id, string(cfntypes.StackStatusCreateComplete), "")
+
+	for _, nested := range st.Nested {
+		child := b.addStack(nested, s)
+		logicalID := nested.LogicalID
+		if logicalID == "" {
+			logicalID = nested.Name
+		}
+		s.resources = append(s.resources, Resource{LogicalID: logicalID, Type: "AWS::CloudFormation::Stack", PhysicalID: child.id})
+	}
+
+	for _, r := range s.resources {
+		if f, ok := b.functions[r.PhysicalID]; ok && r.Type == "AWS::Lambda::Function" {
+			f.tags["aws:cloudformation:stack-name"] = s.name
+			f.tags["aws:cloudformation:stack-id"] = s.id
+			f.tags["aws:cloudformation:logical-id"] = r.LogicalID
+		}
+	}
+	return s
+}
+
+// StackStatus returns the status of a stack by name or stack ID, or false if
+// it does not exist. Deleted stacks can only be found by their stack ID.
+func (b *Backend) StackStatus(nameOrID string) (cfntypes.StackStatus, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.findStack(nameOrID)
+	if s == nil {
+		return "", false
+	}
+	return s.status, true
+}
+
+// functionARN returns the ARN of the named function. The caller must hold b.mu.
+func (b *Backend) functionARN(name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", b.opts.Region, b.opts.Account, name)
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,219 @@
+package awsfake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+// getStatus returns the state and last update status a GetFunction call reports
+func getStatus(t *testing.T, c *LambdaClient, name string) (types.State, types.LastUpdateStatus) {
+	t.Helper()
+	output, err := c.GetFunction(context.Background(), &lambda.GetFunctionInput{FunctionName: aws.String(name)})
+	if err != nil {
+		t.Fatalf("GetFunction() error = %v", err)
+	}
+	return output.Configuration.State, output.Configuration.LastUpdateStatus
+}
+
+func TestFunctionTransitions(t *testing.T) {
+	ctx := context.Background()
+	b := New(Options{TransitionPolls: 2})
+	b.AddFunction(Function{Name: "api", Pending: true, VPC: &VPC{ID: "vpc-1", Subnets: []string{"subnet-1"}, IPv6: true}})
+	c := b.Lambda()
+
+	detach := &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String("api"),
+		VpcConfig:    &types.VpcConfig{SubnetIds: []string{}, SecurityGroupIds: []string{}},
+	}
+
+	var conflict *types.ResourceConflictException
+	if _, err := c.UpdateFunctionConfiguration(ctx, detach); !errors.As(err, &conflict) {
+		t.Fatalf("UpdateFunctionConfiguration() of a pending function error = %v, want conflict", err)
+	}
+	for i := 0; i < 2; i++ {
+		if state, _ := getStatus(t, c, "api"); state != types.StatePending {
+			t.Fatalf("poll %d: state = %s, want Pending", i, state)
+		}
+	}
+	if state, _ := getStatus(t, c, "api"); state != types.StateActive {
+		t.Fatalf("state = %s after the transition polls, want Active", state)
+	}
+
+	// A failed update leaves the configuration unchanged
+	b.FailNextUpdate("api", types.LastUpdateStatusReasonCodeSubnetOutOfIPAddresses)
+	if _, err := c.UpdateFunctionConfiguration(ctx, detach); err != nil {
+		t.Fatalf("UpdateFunctionConfiguration() error = %v", err)
+	}
+	for _, want := range []types.LastUpdateStatus{types.LastUpdateStatusInProgress, types.LastUpdateStatusInProgress, types.LastUpdateStatusFailed} {
+		if _, status := getStatus(t, c, "api"); status != want {
+			t.Fatalf("last update status = %s, want %s", status, want)
+		}
+	}
+	if config, _ := b.Function("api"); len(config.VpcConfig.SubnetIds) != 1 {
+		t.Errorf("failed update changed the VPC config to %+v", config.VpcConfig)
+	}
+
+	if _, err := c.UpdateFunctionConfiguration(ctx, detach); err != nil {
+		t.Fatalf("UpdateFunctionConfiguration() error = %v", err)
+	}
+	for _, want := range []types.LastUpdateStatus{types.LastUpdateStatusInProgress, types.LastUpdateStatusInProgress, types.LastUpdateStatusSuccessful} {
+		if _, status := getStatus(t, c, "api"); status != want {
+			t.Fatalf("last update status = %s, want %s", status, want)
+		}
+	}
+	if config, _ := b.Function("api"); len(config.VpcConfig.SubnetIds) != 0 || aws.ToString(config.VpcConfig.VpcId) != "" {
+		t.Errorf("VPC config = %+v after detaching, want none", config.VpcConfig)
+	}
+
+	if _, err := c.DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String("api")}); err != nil {
+		t.Fatalf("DeleteFunction() error = %v", err)
+	}
+	var notFound *types.ResourceNotFoundException
+	if _, err := c.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String("api")}); !errors.As(err, &notFound) {
+		t.Errorf("GetFunction() of a deleted function error = %v, want not found", err)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	b := New(Options{PageSize: 2})
+	for _, name := range []string{"e", "c", "a", "d", "b"} {
+		b.AddFunction(Function{Name: name})
+		b.AddLogGroup(LogGroup{Name: "/aws/lambda/" + name})
+	}
+	b.AddLogGroup(LogGroup{Name: "/ecs/service"})
+
+	var names []string
+	paginator := lambda.NewListFunctionsPaginator(b.Lambda(), &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("ListFunctions() error = %v", err)
+		}
+		if len(output.Functions) > 2 {
+			t.Errorf("page has %d functions, want at most 2", len(output.Functions))
+		}
+		for _, fn := range output.Functions {
+			names = append(names, aws.ToString(fn.FunctionName))
+		}
+	}
+	if got := len(names); got != 5 || names[0] != "a" || names[4] != "e" {
+		t.Errorf("ListFunctions() pages = %v, want a to e", names)
+	}
+
+	output, err := b.Logs().DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String("/aws/lambda/"),
+		Limit:              aws.Int32(1),
+	})
+	if err != nil {
+		t.Fatalf("DescribeLogGroups() error = %v", err)
+	}
+	if len(output.LogGroups) != 1 || aws.ToString(output.LogGroups[0].LogGroupName) != "/aws/lambda/a" || output.NextToken == nil {
+		t.Errorf("DescribeLogGroups() = %d group(s), next token %v, want /aws/lambda/a and more", len(output.LogGroups), output.NextToken)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	ctx := context.Background()
+	b := New(Options{})
+	b.AddFunction(Function{Name: "api"})
+	b.AddFunction(Function{Name: "worker"})
+	b.InjectFault(Fault{Operation: "DeleteFunction", Resource: "api", Err: &types.TooManyRequestsException{}, Times: 2})
+
+	deleteFunction := func(name string) error {
+		_, err := b.Lambda().DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(name)})
+		return err
+	}
+
+	if err := deleteFunction("worker"); err != nil {
+		t.Errorf("DeleteFunction(worker) error = %v, want the fault to match api only", err)
+	}
+	var throttled *types.TooManyRequestsException
+	for i := 0; i < 2; i++ {
+		if err := deleteFunction("api"); !errors.As(err, &throttled) {
+			t.Fatalf("DeleteFunction(api) call %d error = %v, want throttling", i, err)
+		}
+	}
+	if err := deleteFunction("api"); err != nil {
+		t.Errorf("DeleteFunction(api) error = %v after the fault was used up", err)
+	}
+	if got := b.Calls("DeleteFunction"); got != 4 {
+		t.Errorf("Calls() = %d, want 4", got)
+	}
+
+	var notFound *logstypes.ResourceNotFoundException
+	if _, err := b.Logs().DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String("/aws/lambda/api")}); !errors.As(err, &notFound) {
+		t.Errorf("DeleteLogGroup() of a missing log group error = %v, want not found", err)
+	}
+}
+
+func TestStackDeletion(t *testing.T) {
+	ctx := context.Background()
+	b := New(Options{StackDeletePolls: 1})
+	b.AddFunction(Function{Name: "api"})
+	b.AddFunction(Function{Name: "worker"})
+	b.AddFunction(Function{Name: "standalone"})
+	stackID := b.AddStack(Stack{
+		Name:      "app",
+		Resources: []Resource{FunctionResource("Api", "api")},
+		Nested:    []Stack{{Name: "app-Workers-1", LogicalID: "Workers", Resources: []Resource{FunctionResource("Worker", "worker")}}},
+	})
+	b.AddStack(Stack{Name: "broken", DeleteFailure: "Resource is in use"})
+	c := b.CloudFormation()
+
+	output, err := b.Lambda().GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String("worker")})
+	if err != nil || output.Tags["aws:cloudformation:stack-name"] != "app-Workers-1" {
+		t.Errorf("worker tags = %v, %v, want the nested stack", output, err)
+	}
+
+	describe := func(nameOrID string) (cfntypes.StackStatus, error) {
+		output, err := c.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(nameOrID)})
+		if err != nil {
+			return "", err
+		}
+		return output.Stacks[0].StackStatus, nil
+	}
+
+	for _, name := range []string{"app", "broken"} {
+		if _, err := c.DeleteStack(ctx, &cloudformation.DeleteStackInput{StackName: aws.String(name)}); err != nil {
+			t.Fatalf("DeleteStack(%s) error = %v", name, err)
+		}
+	}
+	if status, err := describe("app"); err != nil || status != cfntypes.StackStatusDeleteInProgress {
+		t.Fatalf("DescribeStacks() = %s, %v, want DELETE_IN_PROGRESS", status, err)
+	}
+	if status, err := describe(stackID); err != nil || status != cfntypes.StackStatusDeleteComplete {
+		t.Fatalf("DescribeStacks() by ID = %s, %v, want DELETE_COMPLETE", status, err)
+	}
+	if status, err := describe("broken"); err != nil || status != cfntypes.StackStatusDeleteFailed {
+		t.Errorf("DescribeStacks(broken) = %s, %v, want DELETE_FAILED", status, err)
+	}
+
+	var apiErr smithy.APIError
+	if _, err := describe("app"); !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationError" {
+		t.Errorf("DescribeStacks() of a deleted stack by name error = %v, want ValidationError", err)
+	}
+	for name, want := range map[string]bool{"api": false, "worker": false, "standalone": true} {
+		if _, ok := b.Function(name); ok != want {
+			t.Errorf("function %s exists = %v, want %v", name, ok, want)
+		}
+	}
+
+	events, err := c.DescribeStackEvents(ctx, &cloudformation.DescribeStackEventsInput{StackName: aws.String(stackID)})
+	if err != nil {
+		t.Fatalf("DescribeStackEvents() error = %v", err)
+	}
+	if latest := events.StackEvents[0]; latest.ResourceStatus != cfntypes.ResourceStatusDeleteComplete || aws.ToString(latest.LogicalResourceId) != "app" {
+		t.Errorf("latest event = %s %s, want app DELETE_COMPLETE", aws.ToString(latest.LogicalResourceId), latest.ResourceStatus)
+	}
+}
@@ -0,0 +1,273 @@
+package awsfake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// CloudFormationClient is a fake of the CloudFormation client backed by a Backend
+type CloudFormationClient struct {
+	b *Backend
+}
+
+// stack is the state of a fake stack
+type stack struct {
+	id       string
+	name     string
+	parentID string
+	status   types.StackStatus
+	reason   string
+	tags     map[string]string
+
+	resources []Resource
+
+	// events are the stack events, oldest first
+	events []types.StackEvent
+
+	// remainingPolls is how many more DescribeStacks calls see the stack
+	// DELETE_IN_PROGRESS before its deletion finishes
+	remainingPolls int
+
+	// deleteFailure is the reason the deletion fails with, if set
+	deleteFailure string
+}
+
+// findStack returns the stack with the given stack ID, or the live stack
+// with the given name. The caller must hold b.mu.
+func (b *Backend) findStack(nameOrID string) *stack {
+	for _, s := range b.stacks {
+		if s.id == nameOrID || (s.name == nameOrID && s.status != types.StackStatusDeleteComplete) {
+			return s
+		}
+	}
+	return nil
+}
+
+// stackName returns the name of the stack with the given name or stack ID,
+// so faults match either. The caller must hold b.mu.
+func (b *Backend) stackName(nameOrID string) string {
+	if s := b.findStack(nameOrID); s != nil {
+		return s.name
+	}
+	return nameOrID
+}
+
+// lookupStack is findStack returning the error CloudFormation returns for a
+// stack that does not exist. The caller must hold b.mu.
+func (b *Backend) lookupStack(nameOrID string) (*stack, error) {
+	s := b.findStack(nameOrID)
+	if s == nil {
+		return nil, validationError(fmt.Sprintf("Stack with id %s does not exist", nameOrID))
+	}
+	return s, nil
+}
+
+// addEvent records a stack event. The caller must hold b.mu.
+func (b *Backend) addEvent(s *stack, logicalID, resourceType, physicalID, status, reason string) {
+	event := types.StackEvent{
+		EventId:            aws.String(fmt.Sprintf("%s-%d", logicalID, b.nextID())),
+		StackId:            aws.String(s.id),
+		StackName:          aws.String(s.name),
+		LogicalResourceId:  aws.String(logicalID),
+		PhysicalResourceId: aws.String(physicalID),
+		ResourceType:       aws.String(resourceType),
+		ResourceStatus:     types.ResourceStatus(status),
+		Timestamp:          aws.Time(b.opts.Now()),
+	}
+	if reason != "" {
+		event.ResourceStatusReason = aws.String(reason)
+	}
+	s.events = append(s.events, event)
+}
+
+// pollStack advances the deletion of a stack by one DescribeStacks call. The caller must hold b.mu.
+func (b *Backend) pollStack(s *stack) {
+	if s.status != types.StackStatusDeleteInProgress {
+		return
+	}
+	if s.remainingPolls > 0 {
+		s.remainingPolls--
+		return
+	}
+	b.finishDelete(s)
+}
+
+// finishDelete completes the deletion of a stack, deleting its functions and
+// nested stacks, or fails it if a delete failure was set. The caller must hold b.mu.
+func (b *Backend) finishDelete(s *stack) {
+	if s.deleteFailure != "" {
+		s.status = types.StackStatusDeleteFailed
+		s.reason = s.deleteFailure
+		b.addEvent(s, s.name, "AWS::CloudFormation::Stack", s.id, string(types.StackStatusDeleteFailed), s.deleteFailure)
+		return
+	}
+
+	for _, r := range slices.Backward(s.resources) {
+		b.addEvent(s, r.LogicalID, r.Type, r.PhysicalID, string(types.ResourceStatusDeleteInProgress), "")
+		switch r.Type {
+		case "AWS::Lambda::Function":
+			delete(b.functions, r.PhysicalID)
+		case "AWS::CloudFormation::Stack":
+			if nested := b.findStack(r.PhysicalID); nested != nil && nested.status != types.StackStatusDeleteComplete {
+				nested.status = types.StackStatusDeleteInProgress
+				b.finishDelete(nested)
+			}
+		}
+		b.addEvent(s, r.LogicalID, r.Type, r.PhysicalID, string(types.ResourceStatusDeleteComplete), "")
+	}
+	s.status = types.StackStatusDeleteComplete
+	s.reason = ""
+	b.addEvent(s, s.name, "AWS::CloudFormation::Stack", s.id, string(types.StackStatusDeleteComplete), "")
+}
+
+// describe returns the description of a stack
+func (s *stack) describe() types.Stack {
+	out := types.Stack{
+		StackId:     aws.String(s.id),
+		StackName:   aws.String(s.name),
+		StackStatus: s.status,
+	}
+	if s.reason != "" {
+		out.StackStatusReason = aws.String(s.reason)
+	}
+	if s.parentID != "" {
+		out.ParentId = aws.String(s.parentID)
+		out.RootId = aws.String(s.parentID)
+	}
+	for key, value := range s.tags {
+		out.Tags = append(out.Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	slices.SortFunc(out.Tags, func(a, b types.Tag) int {
+		return strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key))
+	})
+	return out
+}
+
+// DescribeStacks describes the named stack, or a page of all stacks that have
+// not been deleted, sorted by name. Each call moves stack deletions in
+// progress one step closer to finishing.
+func (c *CloudFormationClient) DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	stackName := aws.ToString(params.StackName)
+	if err := c.b.call("DescribeStacks", c.b.stackName(stackName)); err != nil {
+		return nil, err
+	}
+	for _, s := range c.b.stacks {
+		c.b.pollStack(s)
+	}
+
+	if stackName != "" {
+		s, err := c.b.lookupStack(stackName)
+		if err != nil {
+			return nil, err
+		}
+		return &cloudformation.DescribeStacksOutput{Stacks: []types.Stack{s.describe()}}, nil
+	}
+
+	var stacks []types.Stack
+	for _, s := range c.b.stacks {
+		if s.status != types.StackStatusDeleteComplete {
+			stacks = append(stacks, s.describe())
+		}
+	}
+	slices.SortFunc(stacks, func(a, b types.Stack) int {
+		return strings.Compare(aws.ToString(a.StackName), aws.ToString(b.StackName))
+	})
+
+	stacks, next, err := page(stacks, params.NextToken, 0, c.b.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudformation.DescribeStacksOutput{Stacks: stacks, NextToken: next}, nil
+}
+
+// ListStackResources returns a page of the resources of a stack
+func (c *CloudFormationClient) ListStackResources(ctx context.Context, params *cloudformation.ListStackResourcesInput, optFns ...func(*cloudformation.Options)) (*cloudformation.ListStackResourcesOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	stackName := aws.ToString(params.StackName)
+	if err := c.b.call("ListStackResources", c.b.stackName(stackName)); err != nil {
+		return nil, err
+	}
+	s, err := c.b.lookupStack(stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := types.ResourceStatusCreateComplete
+	if s.status == types.StackStatusDeleteComplete {
+		status = types.ResourceStatusDeleteComplete
+	}
+	summaries := make([]types.StackResourceSummary, 0, len(s.resources))
+	for _, r := range s.resources {
+		summaries = append(summaries, types.StackResourceSummary{
+			LogicalResourceId:  aws.String(r.LogicalID),
+			PhysicalResourceId: aws.String(r.PhysicalID),
+			ResourceType:       aws.String(r.Type),
+			ResourceStatus:     status,
+		})
+	}
+
+	summaries, next, err := page(summaries, params.NextToken, 0, c.b.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudformation.ListStackResourcesOutput{StackResourceSummaries: summaries, NextToken: next}, nil
+}
+
+// DeleteStack starts the deletion of a stack. Like CloudFormation, it
+// succeeds for stacks that do not exist or are already being deleted.
+func (c *CloudFormationClient) DeleteStack(ctx context.Context, params *cloudformation.DeleteStackInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DeleteStackOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	stackName := aws.ToString(params.StackName)
+	if err := c.b.call("DeleteStack", c.b.stackName(stackName)); err != nil {
+		return nil, err
+	}
+	s := c.b.findStack(stackName)
+	if s == nil || s.status == types.StackStatusDeleteInProgress || s.status == types.StackStatusDeleteComplete {
+		return &cloudformation.DeleteStackOutput{}, nil
+	}
+
+	s.status = types.StackStatusDeleteInProgress
+	s.reason = ""
+	c.b.addEvent(s, s.name, "AWS::CloudFormation::Stack", s.id, string(types.StackStatusDeleteInProgress), "User Initiated")
+	s.remainingPolls = c.b.opts.StackDeletePolls
+	if s.remainingPolls == 0 {
+		c.b.finishDelete(s)
+	}
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+// DescribeStackEvents returns a page of the events of a stack, newest first
+func (c *CloudFormationClient) DescribeStackEvents(ctx context.Context, params *cloudformation.DescribeStackEventsInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	stackName := aws.ToString(params.StackName)
+	if err := c.b.call("DescribeStackEvents", c.b.stackName(stackName)); err != nil {
+		return nil, err
+	}
+	s, err := c.b.lookupStack(stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	events := slices.Clone(s.events)
+	slices.Reverse(events)
+	events, next, err := page(events, params.NextToken, 0, c.b.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudformation.DescribeStackEventsOutput{StackEvents: events, NextToken: next}, nil
+}
@@ -0,0 +1,227 @@
+package awsfake
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// LambdaClient is a fake of the Lambda client backed by a Backend
+type LambdaClient struct {
+	b *Backend
+}
+
+// function is the state of a fake function
+type function struct {
+	config types.FunctionConfiguration
+	tags   map[string]string
+
+	// remainingPolls is how many more GetFunction calls see the function
+	// Pending or InProgress before it settles
+	remainingPolls int
+
+	// pendingVPC is applied when the update in progress succeeds
+	pendingVPC *types.VpcConfigResponse
+
+	// failNextUpdate is the reason the next update fails with, if set
+	failNextUpdate types.LastUpdateStatusReasonCode
+}
+
+// inTransition checks if the function is pending or an update is in progress
+func (f *function) inTransition() bool {
+	return f.config.State == types.StatePending || f.config.LastUpdateStatus == types.LastUpdateStatusInProgress
+}
+
+// poll advances the function towards its settled state by one GetFunction call
+func (f *function) poll() {
+	if !f.inTransition() {
+		return
+	}
+	if f.remainingPolls > 0 {
+		f.remainingPolls--
+		return
+	}
+	f.settle()
+}
+
+// settle completes the pending creation or update in progress
+func (f *function) settle() {
+	if f.config.State == types.StatePending {
+		f.config.State = types.StateActive
+	}
+	if f.config.LastUpdateStatus != types.LastUpdateStatusInProgress {
+		return
+	}
+
+	if f.failNextUpdate != "" {
+		f.config.LastUpdateStatus = types.LastUpdateStatusFailed
+		f.config.LastUpdateStatusReasonCode = f.failNextUpdate
+		f.config.LastUpdateStatusReason = aws.String("Update failed: " + string(f.failNextUpdate))
+		f.failNextUpdate = ""
+	} else {
+		f.config.LastUpdateStatus = types.LastUpdateStatusSuccessful
+		f.config.LastUpdateStatusReasonCode = ""
+		f.config.LastUpdateStatusReason = nil
+		if f.pendingVPC != nil {
+			f.config.VpcConfig = f.pendingVPC
+		}
+	}
+	f.pendingVPC = nil
+}
+
+// snapshot returns a copy of the configuration that later changes do not affect
+func (f *function) snapshot() types.FunctionConfiguration {
+	config := f.config
+	if vpc := f.config.VpcConfig; vpc != nil {
+		config.VpcConfig = &types.VpcConfigResponse{
+			VpcId:                   vpc.VpcId,
+			SubnetIds:               slices.Clone(vpc.SubnetIds),
+			SecurityGroupIds:        slices.Clone(vpc.SecurityGroupIds),
+			Ipv6AllowedForDualStack: vpc.Ipv6AllowedForDualStack,
+		}
+	}
+	return config
+}
+
+// findFunction returns the function with the given name or ARN. The caller must hold b.mu.
+func (b *Backend) findFunction(nameOrARN string) (*function, error) {
+	name := nameOrARN
+	if strings.HasPrefix(nameOrARN, "arn:") {
+		parts := strings.Split(nameOrARN, ":")
+		if len(parts) >= 7 {
+			name = parts[6]
+		}
+	}
+	f, ok := b.functions[name]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{
+			Message: aws.String("Function not found: " + b.functionARN(name)),
+			Type:    aws.String("User"),
+		}
+	}
+	return f, nil
+}
+
+// GetFunction returns the configuration and tags of a function. Each call
+// moves a pending function or an update in progress one step closer to settling.
+func (c *LambdaClient) GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	if err := c.b.call("GetFunction", name); err != nil {
+		return nil, err
+	}
+	f, err := c.b.findFunction(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.poll()
+	config := f.snapshot()
+	return &lambda.GetFunctionOutput{
+		Configuration: &config,
+		Tags:          maps.Clone(f.tags),
+	}, nil
+}
+
+// UpdateFunctionConfiguration starts an update of the function's VPC
+// configuration; other settings are ignored. It fails with a
+// ResourceConflictException while the function is pending or another update
+// is in progress, as Lambda does.
+func (c *LambdaClient) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	if err := c.b.call("UpdateFunctionConfiguration", name); err != nil {
+		return nil, err
+	}
+	f, err := c.b.findFunction(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.inTransition() {
+		return nil, &types.ResourceConflictException{
+			Message: aws.String(fmt.Sprintf("The operation cannot be performed at this time. An update is in progress for resource: %s", aws.ToString(f.config.FunctionArn))),
+			Type:    aws.String("User"),
+		}
+	}
+
+	if vpc := params.VpcConfig; vpc != nil {
+		f.pendingVPC = &types.VpcConfigResponse{
+			SubnetIds:               slices.Clone(vpc.SubnetIds),
+			SecurityGroupIds:        slices.Clone(vpc.SecurityGroupIds),
+			Ipv6AllowedForDualStack: aws.Bool(aws.ToBool(vpc.Ipv6AllowedForDualStack)),
+			VpcId:                   aws.String(""),
+		}
+		if len(vpc.SubnetIds) > 0 && f.config.VpcConfig != nil {
+			f.pendingVPC.VpcId = f.config.VpcConfig.VpcId
+		}
+	}
+	f.config.LastUpdateStatus = types.LastUpdateStatusInProgress
+	f.remainingPolls = c.b.opts.TransitionPolls
+	if f.remainingPolls == 0 {
+		f.settle()
+	}
+
+	config := f.snapshot()
+	return &lambda.UpdateFunctionConfigurationOutput{
+		FunctionName:     config.FunctionName,
+		FunctionArn:      config.FunctionArn,
+		Runtime:          config.Runtime,
+		State:            config.State,
+		LastUpdateStatus: config.LastUpdateStatus,
+		VpcConfig:        config.VpcConfig,
+	}, nil
+}
+
+// DeleteFunction deletes a function
+func (c *LambdaClient) DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	if err := c.b.call("DeleteFunction", name); err != nil {
+		return nil, err
+	}
+	f, err := c.b.findFunction(name)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(c.b.functions, aws.ToString(f.config.FunctionName))
+	return &lambda.DeleteFunctionOutput{}, nil
+}
+
+// ListFunctions returns a page of functions, sorted by name. Like Lambda, it
+// does not return their tags.
+func (c *LambdaClient) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	if err := c.b.call("ListFunctions", ""); err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(c.b.functions))
+	configs := make([]types.FunctionConfiguration, 0, len(names))
+	for _, name := range names {
+		configs = append(configs, c.b.functions[name].snapshot())
+	}
+
+	functions, next, err := page(configs, params.Marker, aws.ToInt32(params.MaxItems), c.b.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &lambda.ListFunctionsOutput{
+		Functions:  functions,
+		NextMarker: next,
+	}, nil
+}
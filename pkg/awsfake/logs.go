@@ -0,0 +1,127 @@
+package awsfake
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// LogsClient is a fake of the CloudWatch Logs client backed by a Backend
+type LogsClient struct {
+	b *Backend
+}
+
+// logGroup is the state of a fake log group
+type logGroup struct {
+	name          string
+	storedBytes   int64
+	creationTime  time.Time
+	lastEventTime time.Time
+	retentionDays int32
+}
+
+// DescribeLogGroups returns a page of the log groups matching the name prefix, sorted by name
+func (c *LogsClient) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	prefix := aws.ToString(params.LogGroupNamePrefix)
+	if err := c.b.call("DescribeLogGroups", prefix); err != nil {
+		return nil, err
+	}
+
+	var groups []types.LogGroup
+	for _, name := range slices.Sorted(maps.Keys(c.b.logGroups)) {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		lg := c.b.logGroups[name]
+		group := types.LogGroup{
+			LogGroupName: aws.String(lg.name),
+			Arn:          aws.String("arn:aws:logs:" + c.b.opts.Region + ":" + c.b.opts.Account + ":log-group:" + lg.name + ":*"),
+			StoredBytes:  aws.Int64(lg.storedBytes),
+			CreationTime: aws.Int64(lg.creationTime.UnixMilli()),
+		}
+		if lg.retentionDays > 0 {
+			group.RetentionInDays = aws.Int32(lg.retentionDays)
+		}
+		groups = append(groups, group)
+	}
+
+	groups, next, err := page(groups, params.NextToken, aws.ToInt32(params.Limit), c.b.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.DescribeLogGroupsOutput{
+		LogGroups: groups,
+		NextToken: next,
+	}, nil
+}
+
+// DescribeLogStreams returns the single stream of a log group that has
+// events, named after the log group, whatever the order requested
+func (c *LogsClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.LogGroupName)
+	if err := c.b.call("DescribeLogStreams", name); err != nil {
+		return nil, err
+	}
+	lg, ok := c.b.logGroups[name]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+
+	output := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	if !lg.lastEventTime.IsZero() {
+		output.LogStreams = []types.LogStream{{
+			LogStreamName:      aws.String(name),
+			CreationTime:       aws.Int64(lg.creationTime.UnixMilli()),
+			LastEventTimestamp: aws.Int64(lg.lastEventTime.UnixMilli()),
+			StoredBytes:        aws.Int64(lg.storedBytes),
+		}}
+	}
+	return output, nil
+}
+
+// PutRetentionPolicy sets the retention of a log group
+func (c *LogsClient) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.LogGroupName)
+	if err := c.b.call("PutRetentionPolicy", name); err != nil {
+		return nil, err
+	}
+	lg, ok := c.b.logGroups[name]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+
+	lg.retentionDays = aws.ToInt32(params.RetentionInDays)
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+// DeleteLogGroup deletes a log group
+func (c *LogsClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+
+	name := aws.ToString(params.LogGroupName)
+	if err := c.b.call("DeleteLogGroup", name); err != nil {
+		return nil, err
+	}
+	if _, ok := c.b.logGroups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+
+	delete(c.b.logGroups, name)
+	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// cassetteVersion is the version of the cassette file format
+const cassetteVersion = 1
+
+// redacted replaces credentials and signatures in a cassette
+const redacted = "REDACTED"
+
+// Cassette is a recording of API calls, stored as JSON
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response it received
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of an Interaction, without credentials and signatures
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of an Interaction, without credentials
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// recordedRequestHeaders are the request headers kept in a cassette. The others
+// carry signatures, credentials or values that change with every request.
+var recordedRequestHeaders = []string{"Content-Type", "X-Amz-Target"}
+
+// droppedResponseHeaders are the response headers left out of a cassette
+var droppedResponseHeaders = []string{"Date", "Set-Cookie"}
+
+// secretPatterns match credentials and signatures in URLs and bodies, such as
+// the credentials STS returns or the presigned code location of GetFunction.
+// The first group is kept and the rest is redacted.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`((?i:X-Amz-(?:Security-Token|Credential|Signature))=)[^&"<\s\\]+`),
+	regexp.MustCompile(`(<(?:AccessKeyId|SecretAccessKey|SessionToken)>)[^<]*`),
+	regexp.MustCompile(`("(?:AccessKeyId|SecretAccessKey|SessionToken)"\s*:\s*")[^"]*`),
+	regexp.MustCompile(`()\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+}
+
+// scrub redacts credentials and signatures in s
+func scrub(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return s
+}
+
+// readBody reads the body of a request or response and replaces it with a
+// copy, so it can still be sent or read
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+// newRecordedRequest captures req without its credentials and signatures
+func newRecordedRequest(req *http.Request) (RecordedRequest, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return RecordedRequest{}, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	headers := make(http.Header)
+	for _, name := range recordedRequestHeaders {
+		if values := req.Header.Values(name); len(values) > 0 {
+			headers[name] = values
+		}
+	}
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     scrub(req.URL.String()),
+		Headers: headers,
+		Body:    scrub(body),
+	}, nil
+}
+
+// Recorder is an HTTP client that sends requests through another client and
+// records them with their responses. Pass it to every client of a run with
+// WithRecorder and call Save once the run is over.
+type Recorder struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a Recorder that saves the cassette to path
+func NewRecorder(path string) *Recorder {
+	return &Recorder{
+		path:     path,
+		cassette: Cassette{Version: cassetteVersion},
+	}
+}
+
+// wrap returns a Recorder sending requests through next and sharing the recording
+func (r *Recorder) wrap(next aws.HTTPClient) aws.HTTPClient {
+	return &recordingClient{recorder: r, next: next}
+}
+
+// recordingClient records the calls of one client into a shared Recorder
+type recordingClient struct {
+	recorder *Recorder
+	next     aws.HTTPClient
+}
+
+// Do sends req and records it with the response
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	recorded, err := newRecordedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	headers := resp.Header.Clone()
+	for _, name := range droppedResponseHeaders {
+		headers.Del(name)
+	}
+
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	c.recorder.cassette.Interactions = append(c.recorder.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: headers,
+			Body:    scrub(body),
+		},
+	})
+	return resp, nil
+}
+
+// Len returns how many API calls have been recorded
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cassette.Interactions)
+}
+
+// Path returns the path of the cassette file
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Save writes the cassette file, replacing it through a temporary file and rename
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cassette file: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cassette file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cassette file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace cassette file: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an HTTP client that serves the responses of a cassette instead
+// of calling AWS. A request is answered with the first unused interaction
+// recorded for the same request, or else for the same operation, so calls
+// repeated while polling get their responses in the recorded order.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// LoadReplayer loads the cassette file at path
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette file: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette file %s: %w", path, err)
+	}
+	if cassette.Version != cassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d in %s", cassette.Version, path)
+	}
+
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// Do answers req with the next matching recorded response
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	recorded, err := newRecordedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.interactions {
+		if r.used[i] || !sameOperation(interaction.Request, recorded) {
+			continue
+		}
+		if interaction.Request.Body == recorded.Body {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, &replayError{request: recorded}
+	}
+	r.used[match] = true
+
+	response := r.interactions[match].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        response.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Unused returns how many recorded interactions have not been replayed
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// sameOperation checks if two requests call the same operation on the same resource
+func sameOperation(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.URL == b.URL &&
+		a.Headers.Get("X-Amz-Target") == b.Headers.Get("X-Amz-Target") &&
+		queryAction(a.Body) == queryAction(b.Body)
+}
+
+// queryAction returns the Action parameter of a query protocol request body,
+// which CloudFormation and STS use to name the operation
+func queryAction(body string) string {
+	for _, param := range strings.Split(body, "&") {
+		if action, ok := strings.CutPrefix(param, "Action="); ok {
+			return action
+		}
+	}
+	return ""
+}
+
+// replayError is returned for a request the cassette has no response for.
+// It is not retryable, since retrying cannot produce a response either.
+type replayError struct {
+	request RecordedRequest
+}
+
+func (e *replayError) Error() string {
+	operation := e.request.Headers.Get("X-Amz-Target")
+	if operation == "" {
+		operation = queryAction(e.request.Body)
+	}
+	return strings.TrimSpace(fmt.Sprintf("no recorded response for %s %s %s", e.request.Method, e.request.URL, operation))
+}
+
+// RetryableError tells the SDK not to retry the request
+func (e *replayError) RetryableError() bool {
+	return false
+}
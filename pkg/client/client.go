@@ -6,12 +6,19 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 // AWSClient wraps AWS service clients
@@ -19,11 +26,99 @@ type AWSClient struct {
 	Lambda         *lambda.Client
 	Logs           *cloudwatchlogs.Client
 	CloudFormation *cloudformation.Client
+	EC2            *ec2.Client
+	CloudWatch     *cloudwatch.Client
 	Config         aws.Config
 }
 
+// Option configures optional behaviour of NewAWSClient
+type Option func(*clientOptions)
+
+// clientOptions holds the settings applied by Option functions
+type clientOptions struct {
+	assumeRole  *AssumeRole
+	endpointURL string
+	retries     *int
+	retryMode   aws.RetryMode
+	rateLimiter *RateLimiter
+	recorder    *Recorder
+	replayer    *Replayer
+}
+
+// AssumeRole describes an IAM role to assume on top of the base credentials
+type AssumeRole struct {
+	// RoleARN is the ARN of the role to assume
+	RoleARN string
+
+	// ExternalID is passed to STS when the role's trust policy requires it
+	ExternalID string
+
+	// SessionName identifies the session in CloudTrail. Defaults to "delambda".
+	SessionName string
+
+	// MFASerial is the serial number or ARN of the MFA device, if the role requires MFA.
+	// Every AssumeRole call, including credential refreshes, asks for a new code.
+	MFASerial string
+
+	// TokenProvider returns the current MFA code. Defaults to prompting on stdin.
+	TokenProvider func() (string, error)
+
+	// MFASession, if set, assumes the role from the session credentials of an
+	// MFA session shared with other clients instead of passing MFASerial
+	MFASession *MFASession
+}
+
+// WithAssumeRole makes the client assume the given role using STS
+func WithAssumeRole(role AssumeRole) Option {
+	return func(o *clientOptions) {
+		o.assumeRole = &role
+	}
+}
+
+// WithEndpointURL sends the API calls of every service client to endpointURL,
+// such as a LocalStack instance. It takes precedence over the AWS_ENDPOINT_URL
+// and AWS_ENDPOINT_URL_<SERVICE> environment variables, which are otherwise honoured.
+func WithEndpointURL(endpointURL string) Option {
+	return func(o *clientOptions) {
+		o.endpointURL = endpointURL
+	}
+}
+
+// WithRetries sets how often a failed API call is retried, on top of the first
+// attempt, and the retry mode. aws.RetryModeAdaptive also slows the client down
+// while the API is throttling it, which suits bulk runs on busy accounts.
+// It takes precedence over the AWS_MAX_ATTEMPTS and AWS_RETRY_MODE environment variables.
+func WithRetries(maxRetries int, mode aws.RetryMode) Option {
+	return func(o *clientOptions) {
+		o.retries = &maxRetries
+		o.retryMode = mode
+	}
+}
+
+// WithRecorder records every API call and its response with r, with
+// credentials and signatures scrubbed. Call r.Save once the client is done.
+func WithRecorder(r *Recorder) Option {
+	return func(o *clientOptions) {
+		o.recorder = r
+	}
+}
+
+// WithReplayer serves every API call from the responses recorded in r instead
+// of calling AWS. The profile and role are ignored and placeholder credentials
+// are used, so a cassette can be replayed without access to the account.
+func WithReplayer(r *Replayer) Option {
+	return func(o *clientOptions) {
+		o.replayer = r
+	}
+}
+
 // NewAWSClient creates a new AWS client with support for profile and proxy
-func NewAWSClient(ctx context.Context, region, profile string) (*AWSClient, error) {
+func NewAWSClient(ctx context.Context, region, profile string, optFns ...Option) (*AWSClient, error) {
+	var clientOpts clientOptions
+	for _, fn := range optFns {
+		fn(&clientOpts)
+	}
+
 	var opts []func(*config.LoadOptions) error
 
 	// Set region if provided
@@ -31,11 +126,31 @@ func NewAWSClient(ctx context.Context, region, profile string) (*AWSClient, erro
 		opts = append(opts, config.WithRegion(region))
 	}
 
+	// Replayed calls are not signed with real credentials
+	if clientOpts.replayer != nil {
+		profile = ""
+		clientOpts.assumeRole = nil
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("REPLAY", "REPLAY", "")))
+	}
+
 	// Set profile if provided
 	if profile != "" {
 		opts = append(opts, config.WithSharedConfigProfile(profile))
 	}
 
+	// Set a custom endpoint if provided
+	if clientOpts.endpointURL != "" {
+		opts = append(opts, config.WithBaseEndpoint(clientOpts.endpointURL))
+	}
+
+	// Set the retry behaviour if provided
+	if clientOpts.retries != nil {
+		opts = append(opts, config.WithRetryMaxAttempts(*clientOpts.retries+1))
+	}
+	if clientOpts.retryMode != "" {
+		opts = append(opts, config.WithRetryMode(clientOpts.retryMode))
+	}
+
 	// Configure HTTP client with proxy support
 	httpClient := &http.Client{
 		Transport: createTransportWithProxy(),
@@ -47,12 +162,113 @@ func NewAWSClient(ctx context.Context, region, profile string) (*AWSClient, erro
 		return nil, err
 	}
 
+	// Service specific endpoint variables such as AWS_ENDPOINT_URL_LAMBDA would
+	// otherwise win over the base endpoint, so force it on every service client
+	if clientOpts.endpointURL != "" {
+		cfg.ServiceOptions = append(cfg.ServiceOptions, overrideBaseEndpoint(clientOpts.endpointURL))
+	}
+
+	// Record or replay the API calls if requested
+	if clientOpts.recorder != nil {
+		cfg.HTTPClient = clientOpts.recorder.wrap(cfg.HTTPClient)
+	}
+	if clientOpts.replayer != nil {
+		cfg.HTTPClient = clientOpts.replayer
+	}
+
+	// Wait for the shared rate limit before every API call if requested
+	if clientOpts.rateLimiter != nil {
+		cfg.APIOptions = append(cfg.APIOptions, addRateLimitMiddleware(clientOpts.rateLimiter))
+	}
+
+	// Assume a role on top of the base credentials if requested
+	if clientOpts.assumeRole != nil {
+		cfg.Credentials = newAssumeRoleCredentials(cfg, *clientOpts.assumeRole)
+	}
+
+	return NewAWSClientFromConfig(cfg), nil
+}
+
+// NewAWSClientFromConfig creates a new AWS client from an existing configuration,
+// for callers that load and customise the configuration themselves
+func NewAWSClientFromConfig(cfg aws.Config) *AWSClient {
 	return &AWSClient{
 		Lambda:         lambda.NewFromConfig(cfg),
 		Logs:           cloudwatchlogs.NewFromConfig(cfg),
 		CloudFormation: cloudformation.NewFromConfig(cfg),
+		EC2:            ec2.NewFromConfig(cfg),
+		CloudWatch:     cloudwatch.NewFromConfig(cfg),
 		Config:         cfg,
-	}, nil
+	}
+}
+
+// ForRegion returns a client for another region with the same credentials,
+// endpoint, retries, rate limit and recording as c
+func (c *AWSClient) ForRegion(region string) *AWSClient {
+	cfg := c.Config.Copy()
+	cfg.Region = region
+	return NewAWSClientFromConfig(cfg)
+}
+
+// Replaying checks if the client serves its API calls from a recording
+func (c *AWSClient) Replaying() bool {
+	_, ok := c.Config.HTTPClient.(*Replayer)
+	return ok
+}
+
+// MaxRetries returns how often the client retries a failed API call
+func (c *AWSClient) MaxRetries() int {
+	if c.Config.RetryMaxAttempts > 0 {
+		return c.Config.RetryMaxAttempts - 1
+	}
+	return retry.DefaultMaxAttempts - 1
+}
+
+// overrideBaseEndpoint returns a service option that sets BaseEndpoint on the
+// options of any service client created from the config, including clients
+// added in the future, since every generated Options struct has that field
+func overrideBaseEndpoint(endpointURL string) func(string, any) {
+	return func(_ string, options any) {
+		v := reflect.ValueOf(options)
+		if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		field := v.Elem().FieldByName("BaseEndpoint")
+		if field.IsValid() && field.CanSet() && field.Type() == reflect.TypeOf((*string)(nil)) {
+			field.Set(reflect.ValueOf(aws.String(endpointURL)))
+		}
+	}
+}
+
+// newAssumeRoleCredentials creates a cached credentials provider that assumes the role
+// using the base credentials in cfg
+func newAssumeRoleCredentials(cfg aws.Config, role AssumeRole) aws.CredentialsProvider {
+	sessionName := role.SessionName
+	if sessionName == "" {
+		sessionName = "delambda"
+	}
+
+	// The MFA session authenticates the AssumeRole calls once for every role
+	if role.MFASession != nil {
+		cfg = cfg.Copy()
+		cfg.Credentials = role.MFASession.credentialsFor(cfg)
+	}
+
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+		if role.MFASerial != "" {
+			o.SerialNumber = aws.String(role.MFASerial)
+			o.TokenProvider = role.TokenProvider
+			if o.TokenProvider == nil {
+				o.TokenProvider = stscreds.StdinTokenProvider
+			}
+		}
+	})
+
+	return aws.NewCredentialsCache(provider)
 }
 
 // createTransportWithProxy creates an HTTP transport with proxy configuration
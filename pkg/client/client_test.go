@@ -2,8 +2,16 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 )
 
 func TestNewAWSClient(t *testing.T) {
@@ -121,3 +129,138 @@ func TestGetProxyURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAWSClientWithAssumeRole(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewAWSClient(ctx, "us-east-1", "", WithAssumeRole(AssumeRole{
+		RoleARN:    "arn:aws:iam::123456789012:role/delambda",
+		ExternalID: "external-id",
+	}))
+	if err != nil {
+		t.Fatalf("NewAWSClient() error = %v", err)
+	}
+
+	cache, ok := client.Config.Credentials.(*aws.CredentialsCache)
+	if !ok {
+		t.Fatalf("NewAWSClient() credentials = %T, want *aws.CredentialsCache", client.Config.Credentials)
+	}
+	if !cache.IsCredentialsProvider(&stscreds.AssumeRoleProvider{}) {
+		t.Error("NewAWSClient() credentials do not assume the role")
+	}
+}
+
+func TestNewAWSClientWithEndpointURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		setEnv      map[string]string
+		endpointURL string
+		want        string
+	}{
+		{
+			name:   "global endpoint variable",
+			setEnv: map[string]string{"AWS_ENDPOINT_URL": "http://localhost:4566"},
+			want:   "http://localhost:4566",
+		},
+		{
+			name: "service endpoint variable wins over global",
+			setEnv: map[string]string{
+				"AWS_ENDPOINT_URL":        "http://localhost:4566",
+				"AWS_ENDPOINT_URL_LAMBDA": "http://localhost:4567",
+			},
+			want: "http://localhost:4567",
+		},
+		{
+			name:        "option wins over environment",
+			setEnv:      map[string]string{"AWS_ENDPOINT_URL_LAMBDA": "http://localhost:4567"},
+			endpointURL: "http://localhost:9999",
+			want:        "http://localhost:9999",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.setEnv {
+				t.Setenv(k, v)
+			}
+
+			var opts []Option
+			if tt.endpointURL != "" {
+				opts = append(opts, WithEndpointURL(tt.endpointURL))
+			}
+
+			client, err := NewAWSClient(context.Background(), "us-east-1", "", opts...)
+			if err != nil {
+				t.Fatalf("NewAWSClient() error = %v", err)
+			}
+
+			if got := aws.ToString(client.Lambda.Options().BaseEndpoint); got != tt.want {
+				t.Errorf("Lambda endpoint = %v, want %v", got, tt.want)
+			}
+			if tt.endpointURL != "" {
+				for name, got := range map[string]*string{
+					"Logs":           client.Logs.Options().BaseEndpoint,
+					"CloudFormation": client.CloudFormation.Options().BaseEndpoint,
+				} {
+					if aws.ToString(got) != tt.want {
+						t.Errorf("%s endpoint = %v, want %v", name, aws.ToString(got), tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewAWSClientWithMFASession(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.Form.Get("Action")
+		actions = append(actions, action)
+		w.Header().Set("Content-Type", "text/xml")
+		credentials := `<Credentials><AccessKeyId>AKID</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration></Credentials>`
+		switch action {
+		case "GetSessionToken":
+			if r.Form.Get("TokenCode") != "123456" {
+				t.Errorf("GetSessionToken TokenCode = %q, want 123456", r.Form.Get("TokenCode"))
+			}
+			fmt.Fprintf(w, `<GetSessionTokenResponse><GetSessionTokenResult>%s</GetSessionTokenResult></GetSessionTokenResponse>`, credentials)
+		case "AssumeRole":
+			// The role is assumed from the session, without the code again
+			if r.Form.Get("TokenCode") != "" || !strings.Contains(r.Header.Get("X-Amz-Security-Token"), "token") {
+				t.Errorf("AssumeRole did not use the MFA session")
+			}
+			fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult>%s<AssumedRoleUser><Arn>arn</Arn><AssumedRoleId>id</AssumedRoleId></AssumedRoleUser></AssumeRoleResult></AssumeRoleResponse>`, credentials)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	codes := 0
+	session := NewMFASession("arn:aws:iam::123456789012:mfa/alice", func() (string, error) {
+		codes++
+		return "123456", nil
+	})
+
+	ctx := context.Background()
+	for _, roleARN := range []string{"arn:aws:iam::111111111111:role/delambda", "arn:aws:iam::222222222222:role/delambda"} {
+		client, err := NewAWSClient(ctx, "us-east-1", "", WithEndpointURL(server.URL), WithAssumeRole(AssumeRole{
+			RoleARN:    roleARN,
+			MFASession: session,
+		}))
+		if err != nil {
+			t.Fatalf("NewAWSClient() error = %v", err)
+		}
+		if _, err := client.Config.Credentials.Retrieve(ctx); err != nil {
+			t.Fatalf("Retrieve() error = %v", err)
+		}
+	}
+
+	if codes != 1 {
+		t.Errorf("MFA codes asked = %d, want 1", codes)
+	}
+	want := []string{"GetSessionToken", "AssumeRole", "AssumeRole"}
+	if !slices.Equal(actions, want) {
+		t.Errorf("STS calls = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// CallerIdentity identifies the principal the client's credentials belong to
+type CallerIdentity struct {
+	Account string
+	ARN     string
+	UserID  string
+}
+
+// CallerIdentity returns the account and principal of the client's credentials,
+// using STS GetCallerIdentity
+func (c *AWSClient) CallerIdentity(ctx context.Context) (*CallerIdentity, error) {
+	output, err := sts.NewFromConfig(c.Config).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+
+	return &CallerIdentity{
+		Account: aws.ToString(output.Account),
+		ARN:     aws.ToString(output.Arn),
+		UserID:  aws.ToString(output.UserId),
+	}, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// MFASession authenticates with an MFA code once, through STS GetSessionToken,
+// and assumes roles from the resulting session credentials. STS rejects an MFA
+// code it has already seen, so sharing a session between the clients of many
+// accounts asks for a single code instead of one per AssumeRole call.
+type MFASession struct {
+	serialNumber  string
+	tokenProvider func() (string, error)
+
+	mu          sync.Mutex
+	credentials *aws.CredentialsCache
+}
+
+// NewMFASession creates an MFASession for the MFA device serialNumber, taking
+// the codes from tokenProvider, such as stscreds.StdinTokenProvider. A new code
+// is only asked for when the session credentials expire.
+func NewMFASession(serialNumber string, tokenProvider func() (string, error)) *MFASession {
+	return &MFASession{
+		serialNumber:  serialNumber,
+		tokenProvider: tokenProvider,
+	}
+}
+
+// credentialsFor returns the session credentials, getting the session with
+// the base credentials of cfg the first time
+func (s *MFASession) credentialsFor(cfg aws.Config) aws.CredentialsProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.credentials == nil {
+		s.credentials = aws.NewCredentialsCache(&sessionTokenProvider{
+			client:        sts.NewFromConfig(cfg),
+			serialNumber:  s.serialNumber,
+			tokenProvider: s.tokenProvider,
+		})
+	}
+	return s.credentials
+}
+
+// sessionTokenProvider retrieves session credentials with STS GetSessionToken,
+// asking for a fresh MFA code every time
+type sessionTokenProvider struct {
+	client        *sts.Client
+	serialNumber  string
+	tokenProvider func() (string, error)
+}
+
+// Retrieve gets new session credentials
+func (p *sessionTokenProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	code, err := p.tokenProvider()
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to get MFA code: %w", err)
+	}
+
+	out, err := p.client.GetSessionToken(ctx, &sts.GetSessionTokenInput{
+		SerialNumber: aws.String(p.serialNumber),
+		TokenCode:    aws.String(code),
+	})
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to get MFA session: %w", err)
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     aws.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(out.Credentials.SessionToken),
+		Source:          "MFASession",
+		CanExpire:       true,
+		Expires:         aws.ToTime(out.Credentials.Expiration),
+	}, nil
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// RateLimiter limits the rate of API calls with a token bucket per service and
+// region. A single RateLimiter shared by every client of a run keeps concurrent
+// workers, accounts and polling loops under the same budget, leaving room for
+// other systems calling the same APIs.
+type RateLimiter struct {
+	tps   float64
+	burst float64
+
+	mu       sync.Mutex
+	buckets  map[rateLimitKey]*tokenBucket
+	throttle map[rateLimitKey]time.Duration
+}
+
+// rateLimitKey identifies the bucket of a service in a region
+type rateLimitKey struct {
+	service string
+	region  string
+}
+
+// tokenBucket holds the tokens available to a service in a region
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing tps calls per second per service
+// and region, with bursts of up to tps calls
+func NewRateLimiter(tps float64) *RateLimiter {
+	return &RateLimiter{
+		tps:      tps,
+		burst:    math.Max(1, math.Ceil(tps)),
+		buckets:  make(map[rateLimitKey]*tokenBucket),
+		throttle: make(map[rateLimitKey]time.Duration),
+	}
+}
+
+// Wait blocks until a call to service in region is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, service, region string) error {
+	delay := l.reserve(rateLimitKey{service: service, region: region}, time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token from the bucket of key and returns how long the caller
+// has to wait until the token is available. Reservations queue up fairly, since
+// the bucket may go negative while callers are waiting.
+func (l *RateLimiter) reserve(key rateLimitKey, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed.Seconds()*l.tps)
+		bucket.last = now
+	}
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+
+	delay := time.Duration(-bucket.tokens / l.tps * float64(time.Second))
+	l.throttle[key] += delay
+	return delay
+}
+
+// ThrottledTime returns the total time calls waited for the rate limit
+func (l *RateLimiter) ThrottledTime() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var total time.Duration
+	for _, d := range l.throttle {
+		total += d
+	}
+	return total
+}
+
+// ThrottledSummary describes the time calls waited per service and region,
+// longest first, such as "Lambda us-east-1 12.5s, CloudWatch Logs us-east-1 1.2s"
+func (l *RateLimiter) ThrottledSummary() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]rateLimitKey, 0, len(l.throttle))
+	for key := range l.throttle {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return l.throttle[keys[i]] > l.throttle[keys[j]]
+	})
+
+	var summary string
+	for i, key := range keys {
+		if i > 0 {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%s %s %s", key.service, key.region, l.throttle[key].Round(100*time.Millisecond))
+	}
+	return summary
+}
+
+// WithRateLimiter makes every API call of the client wait for limiter, including
+// each retry attempt. Share the limiter between clients to share the budget.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(o *clientOptions) {
+		o.rateLimiter = limiter
+	}
+}
+
+// addRateLimitMiddleware adds a middleware waiting for limiter before every attempt of an API call
+func addRateLimitMiddleware(limiter *RateLimiter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		wait := middleware.FinalizeMiddlewareFunc("DelambdaRateLimit",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				if err := limiter.Wait(ctx, awsmiddleware.GetServiceID(ctx), awsmiddleware.GetRegion(ctx)); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+				return next.HandleFinalize(ctx, in)
+			})
+		if _, ok := stack.Finalize.Get("Retry"); ok {
+			return stack.Finalize.Insert(wait, "Retry", middleware.After)
+		}
+		return stack.Finalize.Add(wait, middleware.After)
+	}
+}
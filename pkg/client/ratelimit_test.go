@@ -0,0 +1,40 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	limiter := NewRateLimiter(2)
+	lambda := rateLimitKey{service: "Lambda", region: "us-east-1"}
+	logs := rateLimitKey{service: "CloudWatch Logs", region: "us-east-1"}
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name string
+		key  rateLimitKey
+		at   time.Duration
+		want time.Duration
+	}{
+		{name: "burst", key: lambda, want: 0},
+		{name: "burst exhausted", key: lambda, want: 0},
+		{name: "queued behind the burst", key: lambda, want: 500 * time.Millisecond},
+		{name: "queued behind the previous caller", key: lambda, want: time.Second},
+		{name: "other service has its own bucket", key: logs, want: 0},
+		{name: "refilled over time", key: lambda, at: 2 * time.Second, want: 0},
+	}
+
+	for _, step := range steps {
+		if got := limiter.reserve(step.key, start.Add(step.at)); got != step.want {
+			t.Errorf("%s: reserve() = %s, want %s", step.name, got, step.want)
+		}
+	}
+
+	if got := limiter.ThrottledTime(); got != 1500*time.Millisecond {
+		t.Errorf("ThrottledTime() = %s, want 1.5s", got)
+	}
+	if got := limiter.ThrottledSummary(); got != "Lambda us-east-1 1.5s" {
+		t.Errorf("ThrottledSummary() = %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/account"
+	"github.com/aws/aws-sdk-go-v2/service/account/types"
+)
+
+// accountAPIRegion is the region the client of the Account Management API
+// needs to resolve its global endpoint when no region is configured
+const accountAPIRegion = "us-east-1"
+
+// EnabledRegions returns the regions that are enabled for the account,
+// using the Account Management API. A client without a region, as with
+// --all-regions and a profile that sets none, calls it in us-east-1.
+func (c *AWSClient) EnabledRegions(ctx context.Context) ([]string, error) {
+	input := &account.ListRegionsInput{
+		RegionOptStatusContains: []types.RegionOptStatus{
+			types.RegionOptStatusEnabled,
+			types.RegionOptStatusEnabledByDefault,
+		},
+	}
+
+	var regions []string
+	paginator := account.NewListRegionsPaginator(account.NewFromConfig(c.Config, func(o *account.Options) {
+		if o.Region == "" {
+			o.Region = accountAPIRegion
+		}
+	}), input)
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list enabled regions: %w", err)
+		}
+
+		for _, region := range output.Regions {
+			regions = append(regions, aws.ToString(region.RegionName))
+		}
+	}
+
+	return regions, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// roundTripFunc answers requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestEnabledRegions(t *testing.T) {
+	// The Account Management API is global and signed for us-east-1, whatever the region of the client
+	tests := []struct {
+		name   string
+		region string
+	}{
+		{name: "region of the client", region: "eu-west-1"},
+		{name: "no region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var authorization string
+			c := &AWSClient{Config: aws.Config{
+				Region:      tt.region,
+				Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+				HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					authorization = req.Header.Get("Authorization")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{"Content-Type": []string{"application/json"}},
+						Body:       io.NopCloser(strings.NewReader(`{"Regions":[{"RegionName":"eu-west-1"},{"RegionName":"us-east-1"}]}`)),
+						Request:    req,
+					}, nil
+				})},
+			}}
+
+			regions, err := c.EnabledRegions(context.Background())
+			if err != nil {
+				t.Fatalf("EnabledRegions() error = %v", err)
+			}
+			if want := []string{"eu-west-1", "us-east-1"}; !slices.Equal(regions, want) {
+				t.Errorf("EnabledRegions() = %v, want %v", regions, want)
+			}
+			if !strings.Contains(authorization, "/us-east-1/account/") {
+				t.Errorf("request signed for %q, want region us-east-1", authorization)
+			}
+		})
+	}
+}
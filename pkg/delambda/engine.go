@@ -0,0 +1,267 @@
+// Package delambda is the Go API of delambda. It lists Lambda functions,
+// detaches them from their VPC and deletes them together with their log
+// groups, using the same logic and protection rules as the delambda CLI.
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	engine, err := delambda.New(cfg, delambda.WithConcurrency(4))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := engine.DeleteStackFunctions(ctx, "my-stack", delambda.DeleteOptions{DeleteLogs: true})
+package delambda
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/shirasu/delambda/internal/application/usecase"
+	cloudformationpkg "github.com/shirasu/delambda/internal/cloudformation"
+	"github.com/shirasu/delambda/internal/config"
+	"github.com/shirasu/delambda/internal/domain/protection"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+	lambdapkg "github.com/shirasu/delambda/internal/lambda"
+	logspkg "github.com/shirasu/delambda/internal/logs"
+	"github.com/shirasu/delambda/pkg/client"
+)
+
+// ErrProtected is returned, wrapped, when the protection rules refuse an operation
+var ErrProtected = protection.ErrProtected
+
+// Engine runs delambda operations against the account and region of an aws.Config.
+// It is safe for concurrent use.
+type Engine struct {
+	lambda         LambdaAPI
+	logs           LogsAPI
+	cloudFormation CloudFormationAPI
+
+	// accountID returns the account the engine operates in
+	accountID  func(ctx context.Context) (string, error)
+	maxRetries int
+
+	concurrency  int
+	pollInterval time.Duration
+	waitTimeout  time.Duration
+	reporter     Reporter
+	output       io.Writer
+	rules        ProtectionRules
+}
+
+// LambdaAPI, LogsAPI and CloudFormationAPI are the AWS API calls an Engine
+// makes. The SDK clients implement them, and so do the clients of the
+// in-memory fake in package awsfake.
+type (
+	LambdaAPI         = lambdapkg.LambdaAPI
+	LogsAPI           = logspkg.LogsAPI
+	CloudFormationAPI = cloudformationpkg.CloudFormationAPI
+)
+
+// Clients are the API clients of an Engine created with NewWithClients
+type Clients struct {
+	Lambda         LambdaAPI
+	Logs           LogsAPI
+	CloudFormation CloudFormationAPI
+
+	// AccountID is the account checked against ProtectionRules.ProductionAccounts
+	AccountID string
+
+	// MaxRetries is how often a change is retried when it conflicts with an
+	// update in progress. Zero disables retrying. Throttling is left to the
+	// retryer of the clients.
+	MaxRetries int
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// ProtectionRules protect functions and stacks from being modified, like the
+// protection section of the CLI config file. Functions and stacks tagged
+// delambda:protect=true are always protected.
+type ProtectionRules struct {
+	// Tags protects functions and stacks carrying any of these tags
+	Tags map[string]string
+
+	// Names protects functions whose name matches any of these glob patterns
+	Names []string
+
+	// Stacks protects stacks matching any of these glob patterns and their functions
+	Stacks []string
+
+	// ProductionAccounts refuses any change in these accounts unless BreakGlass is set
+	ProductionAccounts []string
+
+	// BreakGlass allows changes in production accounts. It lifts no other rule.
+	BreakGlass bool
+}
+
+// WithConcurrency sets how many functions Detach and Delete process at once.
+// The default is one at a time.
+func WithConcurrency(n int) Option {
+	return func(e *Engine) {
+		e.concurrency = n
+	}
+}
+
+// WithWaiter sets the interval at which a function is polled while waiting
+// for a configuration update to complete, and how long to wait at most.
+// The default is every 5 seconds for up to 5 minutes.
+func WithWaiter(pollInterval, timeout time.Duration) Option {
+	return func(e *Engine) {
+		e.pollInterval = pollInterval
+		e.waitTimeout = timeout
+	}
+}
+
+// WithReporter sets the reporter receiving an event before and after every step
+func WithReporter(reporter Reporter) Option {
+	return func(e *Engine) {
+		e.reporter = reporter
+	}
+}
+
+// WithOutput writes the human readable progress the CLI prints to w
+func WithOutput(w io.Writer) Option {
+	return func(e *Engine) {
+		e.output = w
+	}
+}
+
+// WithProtectionRules sets the protection rules checked before any change
+func WithProtectionRules(rules ProtectionRules) Option {
+	return func(e *Engine) {
+		e.rules = rules
+	}
+}
+
+// New creates an Engine using cfg for credentials, region, retries and HTTP settings
+func New(cfg aws.Config, opts ...Option) (*Engine, error) {
+	awsClient := client.NewAWSClientFromConfig(cfg)
+	e := newEngine(awsClient.Lambda, awsClient.Logs, awsClient.CloudFormation, awsClient.MaxRetries())
+	e.accountID = func(ctx context.Context) (string, error) {
+		identity, err := awsClient.CallerIdentity(ctx)
+		if err != nil {
+			return "", err
+		}
+		return identity.Account, nil
+	}
+	return e.configure(opts)
+}
+
+// NewWithClients creates an Engine calling the given API clients, such as
+// SDK clients with custom middleware or the clients of an awsfake.Backend
+func NewWithClients(clients Clients, opts ...Option) (*Engine, error) {
+	if clients.Lambda == nil || clients.Logs == nil || clients.CloudFormation == nil {
+		return nil, fmt.Errorf("the Lambda, Logs and CloudFormation clients are required")
+	}
+	if clients.MaxRetries < 0 {
+		return nil, fmt.Errorf("max retries must not be negative, got %d", clients.MaxRetries)
+	}
+	e := newEngine(clients.Lambda, clients.Logs, clients.CloudFormation, clients.MaxRetries)
+	e.accountID = func(context.Context) (string, error) {
+		return clients.AccountID, nil
+	}
+	return e.configure(opts)
+}
+
+// newEngine creates an Engine with the default options
+func newEngine(lambdaClient LambdaAPI, logsClient LogsAPI, cloudFormationClient CloudFormationAPI, maxRetries int) *Engine {
+	return &Engine{
+		lambda:         lambdaClient,
+		logs:           logsClient,
+		cloudFormation: cloudFormationClient,
+		maxRetries:     maxRetries,
+		concurrency:    1,
+		pollInterval:   5 * time.Second,
+		waitTimeout:    5 * time.Minute,
+		reporter:       nopReporter{},
+		output:         io.Discard,
+	}
+}
+
+// configure applies opts and validates the result
+func (e *Engine) configure(opts []Option) (*Engine, error) {
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1, got %d", e.concurrency)
+	}
+	if e.pollInterval <= 0 || e.waitTimeout <= 0 {
+		return nil, fmt.Errorf("waiter poll interval and timeout must be positive")
+	}
+	settings := config.Settings{Protection: config.Protection{
+		Tags:               e.rules.Tags,
+		Names:              e.rules.Names,
+		Stacks:             e.rules.Stacks,
+		ProductionAccounts: e.rules.ProductionAccounts,
+	}}
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid protection rules: %w", err)
+	}
+	return e, nil
+}
+
+// ListOptions selects the functions to list
+type ListOptions struct {
+	// Stack lists the functions of this stack and its nested stacks, with their
+	// tags. All functions of the region are listed if it is empty.
+	Stack string
+}
+
+// List returns the functions of the region or of a stack
+func (e *Engine) List(ctx context.Context, opts ListOptions) ([]*Function, error) {
+	functionRepo := e.functionRepository()
+
+	if opts.Stack == "" {
+		functions, err := usecase.NewListFunctionsUseCase(functionRepo).Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]*Function, 0, len(functions))
+		for _, fn := range functions {
+			result = append(result, newFunction(fn, ""))
+		}
+		return result, nil
+	}
+
+	stackRepo := repository.NewStackRepository(e.cloudFormation)
+	functions, err := usecase.NewListStackFunctionsUseCase(functionRepo, stackRepo).Execute(ctx, opts.Stack)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Function, 0, len(functions))
+	for _, sf := range functions {
+		result = append(result, newFunction(sf.Function, sf.StackPath))
+	}
+	return result, nil
+}
+
+// functionRepository creates the function repository with the engine's waiter
+// and as many retries as the AWS configuration allows
+func (e *Engine) functionRepository() *repository.FunctionRepository {
+	return repository.NewFunctionRepository(e.lambda,
+		repository.WithMaxRetries(e.maxRetries),
+		repository.WithWaiter(e.pollInterval, e.waitTimeout))
+}
+
+// newGuard creates the protection guard, looking up the account only when
+// production accounts are configured
+func (e *Engine) newGuard(ctx context.Context) (*usecase.ProtectionGuard, error) {
+	rules := protection.NewRules(e.rules.Tags, e.rules.Names, e.rules.Stacks, e.rules.ProductionAccounts)
+
+	var accountID string
+	if rules.HasProductionAccounts() {
+		var err error
+		accountID, err = e.accountID(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return usecase.NewProtectionGuard(rules, accountID, e.rules.BreakGlass), nil
+}
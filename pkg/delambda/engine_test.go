@@ -0,0 +1,124 @@
+package delambda_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/shirasu/delambda/pkg/awsfake"
+	"github.com/shirasu/delambda/pkg/delambda"
+)
+
+// newFakeEngine creates an Engine backed by b that polls without waiting
+func newFakeEngine(t *testing.T, b *awsfake.Backend, opts ...delambda.Option) *delambda.Engine {
+	t.Helper()
+	engine, err := delambda.NewWithClients(delambda.Clients{
+		Lambda:         b.Lambda(),
+		Logs:           b.Logs(),
+		CloudFormation: b.CloudFormation(),
+		AccountID:      awsfake.DefaultAccount,
+		MaxRetries:     2,
+	}, append([]delambda.Option{delambda.WithWaiter(time.Millisecond, time.Second)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewWithClients() error = %v", err)
+	}
+	return engine
+}
+
+func TestEngineDeleteStackFunctionsOffline(t *testing.T) {
+	ctx := context.Background()
+	b := awsfake.New(awsfake.Options{PageSize: 1, TransitionPolls: 2})
+	b.AddFunction(awsfake.Function{Name: "api", VPC: &awsfake.VPC{ID: "vpc-1", Subnets: []string{"subnet-1"}, IPv6: true}})
+	b.AddFunction(awsfake.Function{Name: "worker", Pending: true})
+	b.AddFunction(awsfake.Function{Name: "unrelated"})
+	b.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/api"})
+	b.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/worker"})
+	b.AddStack(awsfake.Stack{
+		Name:      "pr-123",
+		Resources: []awsfake.Resource{awsfake.FunctionResource("Api", "api")},
+		Nested:    []awsfake.Stack{{Name: "pr-123-Workers", LogicalID: "Workers", Resources: []awsfake.Resource{awsfake.FunctionResource("Worker", "worker")}}},
+	})
+	// The pending worker conflicts once; the engine waits for it and retries
+	b.InjectFault(awsfake.Fault{Operation: "DeleteFunction", Resource: "worker", Err: &types.ResourceConflictException{}, Times: 1})
+
+	engine := newFakeEngine(t, b)
+
+	functions, err := engine.List(ctx, delambda.ListOptions{Stack: "pr-123"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(functions) != 2 || functions[1].StackPath != "pr-123/Workers" {
+		t.Fatalf("List() = %d function(s), want api and worker of the nested stack", len(functions))
+	}
+
+	result, err := engine.DeleteStackFunctions(ctx, "pr-123", delambda.DeleteOptions{DetachVPC: true, DisableIPv6: true, DeleteLogs: true})
+	if err != nil {
+		t.Fatalf("DeleteStackFunctions() error = %v", err)
+	}
+	if len(result.Succeeded()) != 2 {
+		t.Errorf("DeleteStackFunctions() succeeded for %d function(s), want 2", len(result.Succeeded()))
+	}
+
+	for name, want := range map[string]bool{"api": false, "worker": false, "unrelated": true} {
+		if _, ok := b.Function(name); ok != want {
+			t.Errorf("function %s exists = %v, want %v", name, ok, want)
+		}
+	}
+	for _, name := range []string{"/aws/lambda/api", "/aws/lambda/worker"} {
+		if b.HasLogGroup(name) {
+			t.Errorf("log group %s still exists", name)
+		}
+	}
+	if got := b.Calls("DeleteFunction"); got != 3 {
+		t.Errorf("DeleteFunction was called %d times, want 3", got)
+	}
+}
+
+func TestEngineDeleteStackFunctionsLogGroupFailureOffline(t *testing.T) {
+	b := awsfake.New(awsfake.Options{})
+	b.AddFunction(awsfake.Function{Name: "api"})
+	b.AddLogGroup(awsfake.LogGroup{Name: "/aws/lambda/api"})
+	b.AddStack(awsfake.Stack{Name: "pr-123", Resources: []awsfake.Resource{awsfake.FunctionResource("Api", "api")}})
+	b.InjectFault(awsfake.Fault{Operation: "DeleteLogGroup", Resource: "/aws/lambda/api", Err: errors.New("access denied"), Times: 10})
+
+	result, err := newFakeEngine(t, b).DeleteStackFunctions(context.Background(), "pr-123", delambda.DeleteOptions{DeleteLogs: true})
+	if err != nil {
+		t.Fatalf("DeleteStackFunctions() error = %v", err)
+	}
+	if len(result.Functions) != 1 {
+		t.Fatalf("DeleteStackFunctions() returned %d result(s), want 1", len(result.Functions))
+	}
+	// The function is deleted, but the log group left behind fails it
+	got := result.Functions[0]
+	if got.Status != delambda.StatusFailed || got.Err == nil {
+		t.Errorf("Status = %s, Err = %v, want failed with the log group error", got.Status, got.Err)
+	}
+	if _, ok := b.Function("api"); ok {
+		t.Error("function api still exists")
+	}
+	if len(result.Failed()) != 1 {
+		t.Errorf("Failed() = %d function(s), want 1", len(result.Failed()))
+	}
+}
+
+func TestEngineProductionAccountOffline(t *testing.T) {
+	b := awsfake.New(awsfake.Options{})
+	b.AddFunction(awsfake.Function{Name: "api"})
+
+	engine := newFakeEngine(t, b, delambda.WithProtectionRules(delambda.ProtectionRules{
+		ProductionAccounts: []string{awsfake.DefaultAccount},
+	}))
+
+	result, err := engine.Delete(context.Background(), []string{"api"}, delambda.DeleteOptions{})
+	if !errors.Is(err, delambda.ErrProtected) {
+		t.Fatalf("Delete() error = %v, want ErrProtected", err)
+	}
+	if result.Functions[0].Status != delambda.StatusSkipped {
+		t.Errorf("Status = %s, want skipped", result.Functions[0].Status)
+	}
+	if _, ok := b.Function("api"); !ok {
+		t.Error("function in a production account was deleted")
+	}
+}
@@ -0,0 +1,49 @@
+package delambda_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/shirasu/delambda/pkg/delambda"
+)
+
+func ExampleEngine_DeleteStackFunctions() {
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine, err := delambda.New(cfg,
+		delambda.WithWaiter(2*time.Second, 10*time.Minute),
+		delambda.WithOutput(os.Stderr),
+		delambda.WithProtectionRules(delambda.ProtectionRules{
+			Names:              []string{"billing-*"},
+			ProductionAccounts: []string{"111111111111"},
+		}),
+		delambda.WithReporter(delambda.ReporterFunc(func(event delambda.Event) {
+			if event.Type == delambda.EventStepFailed {
+				log.Printf("%s: %s failed: %v", event.Function, event.Step, event.Err)
+			}
+		})),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := engine.DeleteStackFunctions(ctx, "pr-123", delambda.DeleteOptions{
+		DetachVPC:   true,
+		DisableIPv6: true,
+		DeleteLogs:  true,
+	})
+	for _, fn := range result.Functions {
+		fmt.Printf("%s: %s\n", fn.Name, fn.Status)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+package delambda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/infrastructure/repository"
+)
+
+// DetachOptions controls Detach
+type DetachOptions struct {
+	// DisableIPv6 disables IPv6 for dual-stack subnets first, which Lambda
+	// requires before the VPC can be detached from such functions
+	DisableIPv6 bool
+
+	// DryRun checks the protection rules and writes the plan to the output without changing anything
+	DryRun bool
+}
+
+// DeleteOptions controls Delete and DeleteStackFunctions
+type DeleteOptions struct {
+	// DetachVPC detaches functions from their VPC before deleting them, so
+	// their network interfaces are released without waiting for Lambda
+	DetachVPC bool
+
+	// DisableIPv6 disables IPv6 for dual-stack subnets before detaching
+	DisableIPv6 bool
+
+	// DeleteLogs deletes the functions' CloudWatch Logs log groups as well
+	DeleteLogs bool
+
+	// DryRun checks the protection rules and writes the plan to the output without changing anything
+	DryRun bool
+}
+
+// Detach detaches the named functions from their VPC, processing up to the
+// configured concurrency at once. The result lists every function; the error
+// joins the errors of the functions that failed or were refused.
+func (e *Engine) Detach(ctx context.Context, functionNames []string, opts DetachOptions) (*Result, error) {
+	guard, err := e.newGuard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.forEachFunction(functionNames, opts.DryRun, func(name string, rec *recorder, out io.Writer) error {
+		functionRepo := &recordingFunctionRepository{Repository: e.functionRepository(), rec: rec}
+		return usecase.NewDetachVPCUseCase(functionRepo, out).Execute(ctx, &usecase.DetachVPCInput{
+			FunctionName: name,
+			DisableIPv6:  opts.DisableIPv6,
+			Guard:        guard,
+			DryRun:       opts.DryRun,
+		})
+	})
+}
+
+// Delete deletes the named functions, processing up to the configured
+// concurrency at once. The result lists every function; the error joins the
+// errors of the functions that failed or were refused.
+func (e *Engine) Delete(ctx context.Context, functionNames []string, opts DeleteOptions) (*Result, error) {
+	guard, err := e.newGuard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.forEachFunction(functionNames, opts.DryRun, func(name string, rec *recorder, out io.Writer) error {
+		functionRepo := &recordingFunctionRepository{Repository: e.functionRepository(), rec: rec}
+		logGroupRepo := &recordingLogGroupRepository{Repository: repository.NewLogGroupRepository(e.logs), rec: rec}
+		return usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, out).Execute(ctx, &usecase.DeleteFunctionInput{
+			FunctionName: name,
+			DetachVPC:    opts.DetachVPC,
+			DisableIPv6:  opts.DisableIPv6,
+			DeleteLogs:   opts.DeleteLogs,
+			Guard:        guard,
+			DryRun:       opts.DryRun,
+		})
+	})
+}
+
+// DeleteStackFunctions deletes every function of a stack and its nested stacks,
+// one at a time. Every function is checked against the protection rules before
+// any is changed. The error is non-nil if the operation was refused or any
+// function failed; the result tells which.
+func (e *Engine) DeleteStackFunctions(ctx context.Context, stackName string, opts DeleteOptions) (*Result, error) {
+	guard, err := e.newGuard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := newRecorder(stackName, e.reporter)
+	functionRepo := &recordingFunctionRepository{Repository: e.functionRepository(), rec: rec}
+	logGroupRepo := &recordingLogGroupRepository{Repository: repository.NewLogGroupRepository(e.logs), rec: rec}
+	stackRepo := repository.NewStackRepository(e.cloudFormation)
+
+	err = usecase.NewDeleteStackFunctionsUseCase(functionRepo, logGroupRepo, stackRepo, e.output).Execute(ctx, &usecase.DeleteStackFunctionsInput{
+		StackName:   stackName,
+		DetachVPC:   opts.DetachVPC,
+		DisableIPv6: opts.DisableIPv6,
+		DeleteLogs:  opts.DeleteLogs,
+		Guard:       guard,
+		DryRun:      opts.DryRun,
+	})
+
+	return &Result{
+		Functions: rec.finish(err, opts.DryRun, ""),
+		DryRun:    opts.DryRun,
+	}, err
+}
+
+// forEachFunction runs run for every function with up to the configured
+// concurrency. The output of each function is written in one piece once it
+// is done, so the output of concurrent functions does not interleave.
+func (e *Engine) forEachFunction(names []string, dryRun bool, run func(name string, rec *recorder, out io.Writer) error) (*Result, error) {
+	results := make([][]*FunctionResult, len(names))
+	errs := make([]error, len(names))
+
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, e.concurrency)
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var out bytes.Buffer
+			rec := newRecorder("", e.reporter)
+			err := run(name, rec, &out)
+			results[i] = rec.finish(err, dryRun, name)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", name, err)
+			}
+
+			outputMu.Lock()
+			defer outputMu.Unlock()
+			e.output.Write(out.Bytes())
+		}()
+	}
+	wg.Wait()
+
+	result := &Result{DryRun: dryRun}
+	for _, r := range results {
+		result.Functions = append(result.Functions, r...)
+	}
+	return result, errors.Join(errs...)
+}
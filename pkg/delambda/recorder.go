@@ -0,0 +1,183 @@
+package delambda
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/audit"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+)
+
+// recorder collects the result of every function a use case looks up and
+// changes through the recording repositories, and reports each step
+type recorder struct {
+	stack    string
+	reporter Reporter
+
+	mu      sync.Mutex
+	results []*FunctionResult
+	index   map[string]*FunctionResult
+}
+
+// newRecorder creates a recorder for functions selected through stack, if any
+func newRecorder(stack string, reporter Reporter) *recorder {
+	return &recorder{
+		stack:    stack,
+		reporter: reporter,
+		index:    make(map[string]*FunctionResult),
+	}
+}
+
+// result returns the result of the named function, creating it on first use.
+// The caller must hold mu.
+func (rec *recorder) result(name string) *FunctionResult {
+	fr, ok := rec.index[name]
+	if !ok {
+		fr = &FunctionResult{Name: name, Stack: rec.stack}
+		rec.index[name] = fr
+		rec.results = append(rec.results, fr)
+	}
+	return fr
+}
+
+// lookedUp records the state of a function before any change, or why it could not be looked up
+func (rec *recorder) lookedUp(name string, fn *function.Function, err error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	fr := rec.result(name)
+	if err != nil {
+		if fr.Err == nil {
+			fr.Err = err
+		}
+		return
+	}
+	if fr.Before == nil {
+		fr.Before = newFunction(fn, "")
+	}
+}
+
+// before returns the recorded state of a function, nil if it was not looked up
+func (rec *recorder) before(name string) *Function {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if fr, ok := rec.index[name]; ok {
+		return fr.Before
+	}
+	return nil
+}
+
+// step runs op as a step of the named function and records its outcome
+func (rec *recorder) step(name string, step Step, op func() error) error {
+	start := time.Now()
+	rec.reporter.Report(Event{Type: EventStepStarted, Time: start, Stack: rec.stack, Function: name, Step: step})
+
+	err := op()
+
+	end := time.Now()
+	event := Event{Type: EventStepSucceeded, Time: end, Stack: rec.stack, Function: name, Step: step, Duration: end.Sub(start), Err: err}
+	if err != nil {
+		event.Type = EventStepFailed
+	}
+
+	rec.mu.Lock()
+	fr := rec.result(name)
+	fr.Steps = append(fr.Steps, StepResult{Step: step, Start: start, Duration: end.Sub(start), Err: err})
+	if err != nil && fr.Err == nil {
+		fr.Err = err
+	}
+	rec.mu.Unlock()
+
+	rec.reporter.Report(event)
+	return err
+}
+
+// finish completes the results once the use case has returned err and returns them.
+// An error not attributed to any function, such as a protection rule refusing
+// the operation, is attributed to single function runs given by name.
+func (rec *recorder) finish(err error, dryRun bool, name string) []*FunctionResult {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if err != nil && name != "" {
+		if fr := rec.result(name); fr.Err == nil && !errors.Is(err, ErrProtected) {
+			fr.Err = err
+		}
+	}
+
+	for _, fr := range rec.results {
+		switch {
+		case fr.Err != nil:
+			fr.Status = StatusFailed
+		case dryRun || len(fr.Steps) == 0:
+			fr.Status = StatusSkipped
+		default:
+			fr.Status = StatusSucceeded
+		}
+	}
+	return rec.results
+}
+
+// recordingFunctionRepository records lookups and changes of functions
+type recordingFunctionRepository struct {
+	function.Repository
+	rec *recorder
+}
+
+// FindByName looks up the function and records its state
+func (r *recordingFunctionRepository) FindByName(ctx context.Context, name string) (*function.Function, error) {
+	fn, err := r.Repository.FindByName(ctx, name)
+	r.rec.lookedUp(name, fn, err)
+	return fn, err
+}
+
+// DisableIPv6 disables IPv6 as a recorded step
+func (r *recordingFunctionRepository) DisableIPv6(ctx context.Context, functionName string) error {
+	return r.vpcStep(functionName, StepDisableIPv6, func() error {
+		return r.Repository.DisableIPv6(ctx, functionName)
+	})
+}
+
+// DetachVPC detaches the VPC as a recorded step
+func (r *recordingFunctionRepository) DetachVPC(ctx context.Context, functionName string) error {
+	return r.vpcStep(functionName, StepDetachVPC, func() error {
+		return r.Repository.DetachVPC(ctx, functionName)
+	})
+}
+
+// Delete deletes the function as a recorded step
+func (r *recordingFunctionRepository) Delete(ctx context.Context, functionName string) error {
+	return r.rec.step(functionName, StepDeleteFunction, func() error {
+		return r.Repository.Delete(ctx, functionName)
+	})
+}
+
+// vpcStep records a VPC step, unless the function is known to have no VPC, in
+// which case the repository refuses it without changing anything
+func (r *recordingFunctionRepository) vpcStep(functionName string, step Step, op func() error) error {
+	if before := r.rec.before(functionName); before != nil && before.VPC == nil {
+		return op()
+	}
+	return r.rec.step(functionName, step, op)
+}
+
+// recordingLogGroupRepository records the deletion of function log groups
+type recordingLogGroupRepository struct {
+	loggroup.Repository
+	rec *recorder
+}
+
+// Delete deletes the log group as a recorded step of its function
+func (r *recordingLogGroupRepository) Delete(ctx context.Context, logGroup *loggroup.LogGroup) error {
+	functionName := audit.FunctionForLogGroup(logGroup.Name())
+	if functionName == "" {
+		return r.Repository.Delete(ctx, logGroup)
+	}
+	return r.rec.step(functionName, StepDeleteLogs, func() error {
+		return r.Repository.Delete(ctx, logGroup)
+	})
+}
@@ -0,0 +1,152 @@
+package delambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shirasu/delambda/internal/application/usecase"
+	"github.com/shirasu/delambda/internal/domain/function"
+	"github.com/shirasu/delambda/internal/domain/loggroup"
+	"github.com/shirasu/delambda/internal/domain/protection"
+)
+
+// fakeFunctionRepository serves the given functions and fails deleting those in failDelete
+type fakeFunctionRepository struct {
+	functions  map[string]*function.Function
+	failDelete map[string]bool
+}
+
+func (r *fakeFunctionRepository) FindAll(ctx context.Context) ([]*function.Function, error) {
+	var functions []*function.Function
+	for _, fn := range r.functions {
+		functions = append(functions, fn)
+	}
+	return functions, nil
+}
+
+func (r *fakeFunctionRepository) FindByName(ctx context.Context, name string) (*function.Function, error) {
+	fn, ok := r.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", function.ErrNotFound, name)
+	}
+	return fn, nil
+}
+
+func (r *fakeFunctionRepository) DisableIPv6(ctx context.Context, functionName string) error {
+	if !r.functions[functionName].IsAttachedToVPC() {
+		return fmt.Errorf("function %s is not attached to a VPC", functionName)
+	}
+	return nil
+}
+
+func (r *fakeFunctionRepository) DetachVPC(ctx context.Context, functionName string) error {
+	return r.DisableIPv6(ctx, functionName)
+}
+
+func (r *fakeFunctionRepository) Delete(ctx context.Context, functionName string) error {
+	if r.failDelete[functionName] {
+		return errors.New("access denied")
+	}
+	return nil
+}
+
+// fakeLogGroupRepository deletes every log group
+type fakeLogGroupRepository struct{}
+
+func (fakeLogGroupRepository) FindByPrefix(ctx context.Context, prefix string) ([]*loggroup.LogGroup, error) {
+	return nil, nil
+}
+
+func (fakeLogGroupRepository) LastEventTime(ctx context.Context, logGroup *loggroup.LogGroup) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (fakeLogGroupRepository) Exists(ctx context.Context, logGroup *loggroup.LogGroup) (bool, error) {
+	return true, nil
+}
+
+func (fakeLogGroupRepository) SetRetention(ctx context.Context, logGroup *loggroup.LogGroup, days int) error {
+	return nil
+}
+
+func (fakeLogGroupRepository) Delete(ctx context.Context, logGroup *loggroup.LogGroup) error {
+	return nil
+}
+
+func TestRecorderDelete(t *testing.T) {
+	vpc := &function.VPCConfig{VPCId: "vpc-1", SubnetIds: []string{"subnet-1"}, SecurityGroupIds: []string{"sg-1"}}
+	functions := map[string]*function.Function{
+		"api":       function.NewFunction("api", "python3.12", "Active", vpc, nil),
+		"worker":    function.NewFunction("worker", "python3.12", "Active", nil, nil),
+		"protected": function.NewFunction("protected", "python3.12", "Active", nil, map[string]string{"delambda:protect": "true"}),
+		"denied":    function.NewFunction("denied", "python3.12", "Active", nil, nil),
+	}
+	guard := usecase.NewProtectionGuard(protection.NewRules(nil, nil, nil, nil), "", false)
+
+	tests := []struct {
+		name       string
+		function   string
+		dryRun     bool
+		wantStatus Status
+		wantSteps  []Step
+		wantErr    bool
+	}{
+		{name: "VPC function", function: "api", wantStatus: StatusSucceeded, wantSteps: []Step{StepDisableIPv6, StepDetachVPC, StepDeleteFunction, StepDeleteLogs}},
+		{name: "function without VPC has no VPC steps", function: "worker", wantStatus: StatusSucceeded, wantSteps: []Step{StepDeleteFunction, StepDeleteLogs}},
+		{name: "dry run changes nothing", function: "api", dryRun: true, wantStatus: StatusSkipped},
+		{name: "protected function is skipped", function: "protected", wantStatus: StatusSkipped, wantErr: true},
+		{name: "failed step fails the function", function: "denied", wantStatus: StatusFailed, wantSteps: []Step{StepDeleteFunction}, wantErr: true},
+		{name: "missing function fails", function: "missing", wantStatus: StatusFailed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []Event
+			rec := newRecorder("", ReporterFunc(func(event Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+			}))
+			inner := &fakeFunctionRepository{functions: functions, failDelete: map[string]bool{"denied": true}}
+			functionRepo := &recordingFunctionRepository{Repository: inner, rec: rec}
+			logGroupRepo := &recordingLogGroupRepository{Repository: fakeLogGroupRepository{}, rec: rec}
+
+			err := usecase.NewDeleteFunctionUseCase(functionRepo, logGroupRepo, io.Discard).Execute(context.Background(), &usecase.DeleteFunctionInput{
+				FunctionName: tt.function,
+				DetachVPC:    true,
+				DisableIPv6:  true,
+				DeleteLogs:   true,
+				Guard:        guard,
+				DryRun:       tt.dryRun,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			results := rec.finish(err, tt.dryRun, tt.function)
+			if len(results) != 1 {
+				t.Fatalf("finish() returned %d results, want 1", len(results))
+			}
+			got := results[0]
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (err %v)", got.Status, tt.wantStatus, got.Err)
+			}
+			var steps []Step
+			for _, step := range got.Steps {
+				steps = append(steps, step.Step)
+			}
+			if fmt.Sprint(steps) != fmt.Sprint(tt.wantSteps) {
+				t.Errorf("Steps = %v, want %v", steps, tt.wantSteps)
+			}
+			if len(events) != 2*len(tt.wantSteps) {
+				t.Errorf("reported %d events, want %d", len(events), 2*len(tt.wantSteps))
+			}
+		})
+	}
+}
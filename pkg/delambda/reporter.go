@@ -0,0 +1,49 @@
+package delambda
+
+import "time"
+
+// EventType tells what an Event reports
+type EventType string
+
+const (
+	// EventStepStarted is reported before a step starts
+	EventStepStarted EventType = "step-started"
+	// EventStepSucceeded is reported when a step succeeded
+	EventStepSucceeded EventType = "step-succeeded"
+	// EventStepFailed is reported when a step failed
+	EventStepFailed EventType = "step-failed"
+)
+
+// Event reports the progress of a step
+type Event struct {
+	Type     EventType
+	Time     time.Time
+	Stack    string
+	Function string
+	Step     Step
+
+	// Duration is the time the step took, set once it has finished
+	Duration time.Duration
+
+	// Err is the reason the step failed, set for EventStepFailed
+	Err error
+}
+
+// Reporter receives progress events while the engine works. Events of
+// different functions may be reported concurrently.
+type Reporter interface {
+	Report(event Event)
+}
+
+// ReporterFunc adapts a function to the Reporter interface
+type ReporterFunc func(event Event)
+
+// Report calls f(event)
+func (f ReporterFunc) Report(event Event) {
+	f(event)
+}
+
+// nopReporter discards every event
+type nopReporter struct{}
+
+func (nopReporter) Report(Event) {}
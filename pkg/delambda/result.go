@@ -0,0 +1,146 @@
+package delambda
+
+import (
+	"slices"
+	"time"
+
+	"github.com/shirasu/delambda/internal/domain/function"
+)
+
+// Function describes a Lambda function
+type Function struct {
+	Name    string
+	Runtime string
+	State   string
+
+	// VPC is the function's VPC configuration, nil if it is not attached to a VPC
+	VPC *VPCConfig
+
+	// Tags are the function's tags. List without a stack does not fetch them.
+	Tags map[string]string
+
+	// StackName is the CloudFormation stack that created the function, if any
+	StackName string
+
+	// StackPath is the path of the (nested) stack the function was listed through
+	StackPath string
+}
+
+// VPCConfig is the VPC configuration of a function
+type VPCConfig struct {
+	VPCID                   string
+	SubnetIDs               []string
+	SecurityGroupIDs        []string
+	IPv6AllowedForDualStack bool
+}
+
+// newFunction converts a domain function into a Function
+func newFunction(fn *function.Function, stackPath string) *Function {
+	result := &Function{
+		Name:      fn.Name(),
+		Runtime:   string(fn.Runtime()),
+		State:     string(fn.State()),
+		Tags:      fn.Tags(),
+		StackName: fn.StackName(),
+		StackPath: stackPath,
+	}
+	if vpc := fn.VPCConfig(); fn.IsAttachedToVPC() {
+		result.VPC = &VPCConfig{
+			VPCID:                   vpc.VPCId,
+			SubnetIDs:               slices.Clone(vpc.SubnetIds),
+			SecurityGroupIDs:        slices.Clone(vpc.SecurityGroupIds),
+			IPv6AllowedForDualStack: vpc.IPv6AllowedForDualStack,
+		}
+	}
+	return result
+}
+
+// Step is a change the engine makes to a function
+type Step string
+
+const (
+	// StepDisableIPv6 disables IPv6 for dual-stack subnets
+	StepDisableIPv6 Step = "disable-ipv6"
+	// StepDetachVPC detaches the function from its VPC
+	StepDetachVPC Step = "detach-vpc"
+	// StepDeleteFunction deletes the function
+	StepDeleteFunction Step = "delete-function"
+	// StepDeleteLogs deletes the function's CloudWatch Logs log group
+	StepDeleteLogs Step = "delete-logs"
+)
+
+// StepResult is the outcome of a single step
+type StepResult struct {
+	Step     Step
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is the reason the step failed, nil if it succeeded
+	Err error
+}
+
+// Status is the outcome of processing a function
+type Status string
+
+const (
+	// StatusSucceeded means every step succeeded
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means the function could not be looked up or a step failed
+	StatusFailed Status = "failed"
+	// StatusSkipped means nothing was changed, because of a dry run or because
+	// the protection rules refused the operation
+	StatusSkipped Status = "skipped"
+)
+
+// FunctionResult is the outcome of processing a single function
+type FunctionResult struct {
+	Name string
+
+	// Stack is the stack the function was selected through, empty unless it was
+	Stack string
+
+	// Status summarises the outcome
+	Status Status
+
+	// Before is the function before any change, nil if it could not be looked up
+	Before *Function
+
+	// Steps are the changes made, in order
+	Steps []StepResult
+
+	// Err is the reason the function failed, nil unless Status is StatusFailed.
+	// A log group that could not be deleted fails the function as well, with
+	// the StepDeleteLogs step carrying the error, even in DeleteStackFunctions,
+	// which goes on with the next function and does not return an error for it.
+	Err error
+}
+
+// Result is the outcome of an operation on one or more functions
+type Result struct {
+	// Functions are the results per function, in the order they were processed
+	Functions []*FunctionResult
+
+	// DryRun tells that nothing was changed
+	DryRun bool
+}
+
+// Succeeded returns the functions that were processed successfully
+func (r *Result) Succeeded() []*FunctionResult {
+	return r.withStatus(StatusSucceeded)
+}
+
+// Failed returns the functions that failed
+func (r *Result) Failed() []*FunctionResult {
+	return r.withStatus(StatusFailed)
+}
+
+// withStatus returns the functions with the given status
+func (r *Result) withStatus(status Status) []*FunctionResult {
+	var results []*FunctionResult
+	for _, fr := range r.Functions {
+		if fr.Status == status {
+			results = append(results, fr)
+		}
+	}
+	return results
+}